@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/models"
+	revocationRepository "github.com/MohamedMosalm/Todo-App/repositories/revocationRepository"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 200
+)
+
+// AdminHandler serves the admin API. The routes check permissions; every
+// action is written to the audit log before it is taken, and refused if
+// that fails.
+type AdminHandler struct {
+	userService          services.UserService
+	roleService          services.RoleService
+	auditService         services.AuditService
+	taskService          services.TaskService
+	sessionService       services.SessionService
+	passwordResetService services.PasswordResetService
+	loginAttemptService  services.LoginAttemptService
+	jwtService           *auth.JWTService
+	revocationStore      revocationRepository.RevocationStore
+	impersonationTTL     time.Duration
+}
+
+// AdminServices are the collaborators AdminHandler delegates to.
+type AdminServices struct {
+	Users          services.UserService
+	Roles          services.RoleService
+	Audit          services.AuditService
+	Tasks          services.TaskService
+	Sessions       services.SessionService
+	PasswordResets services.PasswordResetService
+	LoginAttempts  services.LoginAttemptService
+	Tokens         *auth.JWTService
+	Revocations    revocationRepository.RevocationStore
+}
+
+func NewAdminHandler(adminServices AdminServices, config config.AppConfig) *AdminHandler {
+	return &AdminHandler{
+		userService:          adminServices.Users,
+		roleService:          adminServices.Roles,
+		auditService:         adminServices.Audit,
+		taskService:          adminServices.Tasks,
+		sessionService:       adminServices.Sessions,
+		passwordResetService: adminServices.PasswordResets,
+		loginAttemptService:  adminServices.LoginAttempts,
+		jwtService:           adminServices.Tokens,
+		revocationStore:      adminServices.Revocations,
+		impersonationTTL:     config.Auth.ImpersonationTTL,
+	}
+}
+
+// ListUsers searches users by part of their email or name with q, and
+// filters them by role and active.
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	options := services.UserListOptions{Search: c.Query("q"), Role: c.Query("role")}
+	options.Offset, options.Limit = pageParams(c)
+	if active, err := strconv.ParseBool(c.Query("active")); err == nil {
+		options.Active = &active
+	}
+
+	if !h.audit(c, models.AuditUsersList, nil, models.AuditDetails{"q": options.Search, "role": options.Role, "offset": options.Offset, "limit": options.Limit}) {
+		return
+	}
+
+	users, total, err := h.userService.ListUsers(options)
+	if err != nil {
+		appErr := errors.ErrFetchUsersFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	results := make([]*dtos.AdminUserDTO, len(users))
+	for i := range users {
+		results[i] = dtos.NewAdminUserDTO(&users[i])
+	}
+	httputil.SendSuccess(c, http.StatusOK, "Users retrieved", gin.H{
+		"users":  results,
+		"total":  total,
+		"offset": options.Offset,
+		"limit":  options.Limit,
+	})
+}
+
+// GetUser returns the user with their task counts and how many sessions
+// they have open.
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	target, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+
+	if !h.audit(c, models.AuditUserView, &target.ID, nil) {
+		return
+	}
+
+	counts, err := h.taskService.CountTasks(target.ID)
+	if err != nil {
+		appErr := errors.ErrFetchUsersFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	sessions, err := h.sessionService.GetSessionsByUserID(target.ID)
+	if err != nil {
+		appErr := errors.ErrFetchUsersFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "User retrieved", gin.H{
+		"user":            dtos.NewAdminUserDTO(target),
+		"tasks":           counts,
+		"active_sessions": len(sessions),
+	})
+}
+
+// DeactivateUser signs the user out everywhere and keeps them out until
+// they are reactivated. Admins cannot lock themselves out this way.
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	target, ok := h.manageableUser(c)
+	if !ok {
+		return
+	}
+	if target.ID.String() == c.GetString("user_id") {
+		httputil.HandleError(c, errors.ErrCannotDeactivateSelf)
+		return
+	}
+
+	if !h.audit(c, models.AuditUserDeactivate, &target.ID, nil) {
+		return
+	}
+	if err := h.userService.DeactivateUser(target.ID); err != nil {
+		appErr := errors.ErrDeactivateUserFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "User deactivated", nil)
+}
+
+func (h *AdminHandler) ReactivateUser(c *gin.Context) {
+	target, ok := h.manageableUser(c)
+	if !ok {
+		return
+	}
+
+	if !h.audit(c, models.AuditUserReactivate, &target.ID, nil) {
+		return
+	}
+	if err := h.userService.ReactivateUser(target.ID); err != nil {
+		appErr := errors.ErrReactivateUserFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "User reactivated", nil)
+}
+
+// ForcePasswordReset makes the user's password stop working, signs them
+// out and emails them a reset link. It also lifts a login lockout, so a
+// locked-out user can get back in once they have chosen a new password.
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	target, ok := h.manageableUser(c)
+	if !ok {
+		return
+	}
+
+	if !h.audit(c, models.AuditUserPasswordReset, &target.ID, nil) {
+		return
+	}
+	if err := h.passwordResetService.ForceReset(c.Request.Context(), target.ID); err != nil {
+		appErr := errors.ErrForcePasswordResetFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	if err := h.loginAttemptService.RecordSuccess(target.Email); err != nil {
+		log.Printf("clearing failed logins failed: %v", err)
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Password reset; the user has been emailed a link to choose a new one", nil)
+}
+
+// RevokeSessions signs the user out everywhere, like their own logout-all.
+func (h *AdminHandler) RevokeSessions(c *gin.Context) {
+	target, ok := h.manageableUser(c)
+	if !ok {
+		return
+	}
+
+	if !h.audit(c, models.AuditUserSessionsRevoke, &target.ID, nil) {
+		return
+	}
+	if err := h.sessionService.RevokeAllSessions(target.ID); err != nil {
+		appErr := errors.ErrRevokeSessionsFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	now := time.Now()
+	if err := h.revocationStore.RevokeUserTokens(target.ID, now, now.Add(h.jwtService.AccessTokenTTL())); err != nil {
+		appErr := errors.ErrRevokeSessionsFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Sessions revoked", nil)
+}
+
+// ChangeRole gives the user another role. Admins can only grant roles
+// whose permissions their own role has, and cannot change their own role.
+func (h *AdminHandler) ChangeRole(c *gin.Context) {
+	var roleDTO dtos.ChangeRoleDTO
+	if err := c.ShouldBindJSON(&roleDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	target, ok := h.manageableUser(c)
+	if !ok {
+		return
+	}
+	if target.ID.String() == c.GetString("user_id") {
+		httputil.HandleError(c, errors.ErrCannotChangeOwnRole)
+		return
+	}
+	if _, err := h.roleService.GetRole(roleDTO.Role); err != nil {
+		h.handleRoleError(c, err)
+		return
+	}
+	allowed, err := h.outranks(c.GetString("user_role"), roleDTO.Role)
+	if err != nil {
+		appErr := errors.ErrPermissionCheckFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	if !allowed {
+		httputil.HandleError(c, errors.ErrPermissionDenied)
+		return
+	}
+
+	if !h.audit(c, models.AuditUserRoleChange, &target.ID, models.AuditDetails{"from": target.Role, "to": roleDTO.Role}) {
+		return
+	}
+	if err := h.userService.UpdateUser(target.ID, map[string]interface{}{"role": roleDTO.Role}); err != nil {
+		appErr := errors.ErrChangeRoleFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	target.Role = roleDTO.Role
+	httputil.SendSuccess(c, http.StatusOK, "Role changed", dtos.NewAdminUserDTO(target))
+}
+
+// ImpersonateUser issues a short-lived access token that acts as the user
+// on the admin's behalf. It comes without a refresh token and stops
+// working when the admin's session ends. Admins may only impersonate users
+// whose role grants nothing their own role does not.
+func (h *AdminHandler) ImpersonateUser(c *gin.Context) {
+	actorID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	sessionID, err := uuid.Parse(c.GetString("session_id"))
+	if err != nil {
+		httputil.HandleError(c, errors.ErrUnauthorized)
+		return
+	}
+	// An impersonation token cannot be used to start another one.
+	if c.GetString("actor_id") != "" {
+		httputil.HandleError(c, errors.ErrCannotImpersonate)
+		return
+	}
+
+	target, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+	if target.ID == actorID || !target.IsActive() {
+		httputil.HandleError(c, errors.ErrCannotImpersonate)
+		return
+	}
+	allowed, err := h.outranks(c.GetString("user_role"), target.Role)
+	if err != nil {
+		appErr := errors.ErrPermissionCheckFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	if !allowed {
+		httputil.HandleError(c, errors.ErrCannotImpersonate)
+		return
+	}
+
+	if !h.audit(c, models.AuditUserImpersonate, &target.ID, models.AuditDetails{"expires_in": int(h.impersonationTTL.Seconds())}) {
+		return
+	}
+	accessToken, err := h.jwtService.GenerateImpersonationToken(target.ID, actorID, sessionID, h.impersonationTTL)
+	if err != nil {
+		appErr := errors.ErrImpersonationFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Impersonation started", gin.H{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(h.impersonationTTL.Seconds()),
+		"user":         dtos.NewAdminUserDTO(target),
+	})
+}
+
+// ListAuditEvents filters on actor_id, target_id, action and since, an
+// RFC 3339 time.
+func (h *AdminHandler) ListAuditEvents(c *gin.Context) {
+	var options services.AuditListOptions
+	options.Offset, options.Limit = pageParams(c)
+	options.Action = c.Query("action")
+	var err error
+	if options.ActorID, err = uuidQuery(c, "actor_id"); err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	if options.TargetID, err = uuidQuery(c, "target_id"); err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	if value := c.Query("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			appErr := errors.ErrInvalidRequest
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+			return
+		}
+		options.Since = &since
+	}
+
+	if !h.audit(c, models.AuditLogRead, nil, nil) {
+		return
+	}
+
+	events, total, err := h.auditService.ListEvents(options)
+	if err != nil {
+		appErr := errors.ErrFetchAuditFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Audit events retrieved", gin.H{
+		"events": events,
+		"total":  total,
+		"offset": options.Offset,
+		"limit":  options.Limit,
+	})
+}
+
+// targetUser loads the user named by the :id path parameter, responding
+// with an error if there is none.
+func (h *AdminHandler) targetUser(c *gin.Context) (*models.User, bool) {
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	target, err := h.userService.FindUserByID(targetID)
+	if err == gorm.ErrRecordNotFound {
+		httputil.HandleError(c, errors.ErrUserNotFound)
+		return nil, false
+	}
+	if err != nil {
+		appErr := errors.ErrFetchUsersFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+	return target, true
+}
+
+// manageableUser is targetUser for actions that change the user, which the
+// signed-in user may only take on users whose role grants nothing theirs
+// does not.
+func (h *AdminHandler) manageableUser(c *gin.Context) (*models.User, bool) {
+	target, ok := h.targetUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	allowed, err := h.outranks(c.GetString("user_role"), target.Role)
+	if err != nil {
+		appErr := errors.ErrPermissionCheckFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+	if !allowed {
+		httputil.HandleError(c, errors.ErrCannotManageUser)
+		return nil, false
+	}
+	return target, true
+}
+
+// outranks reports whether role grants every permission other does.
+func (h *AdminHandler) outranks(role, other string) (bool, error) {
+	otherRole, err := h.roleService.GetRole(other)
+	if err == services.ErrRoleNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return h.roleService.HasPermissions(role, otherRole.Permissions...)
+}
+
+// audit records action by the signed-in user, responding with an error and
+// returning false if it could not be recorded.
+func (h *AdminHandler) audit(c *gin.Context, action string, targetID *uuid.UUID, details models.AuditDetails) bool {
+	event := &models.AuditEvent{
+		Action:    action,
+		TargetID:  targetID,
+		Details:   details,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if actorID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		event.ActorID = &actorID
+	}
+
+	if err := h.auditService.Record(event); err != nil {
+		appErr := errors.ErrAuditFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return false
+	}
+	return true
+}
+
+// uuidQuery parses the query parameter param, which may be left out.
+func uuidQuery(c *gin.Context, param string) (*uuid.UUID, error) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// pageParams reads the offset and limit query parameters, falling back to
+// the first page of defaultAdminPageSize.
+func pageParams(c *gin.Context) (offset, limit int) {
+	offset, _ = strconv.Atoi(c.Query("offset"))
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAdminPageSize)))
+	if err != nil || limit < 1 {
+		limit = defaultAdminPageSize
+	}
+	return max(offset, 0), min(limit, maxAdminPageSize)
+}
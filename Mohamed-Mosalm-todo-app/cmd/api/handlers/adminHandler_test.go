@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/MohamedMosalm/Todo-App/models"
+	revocationRepository "github.com/MohamedMosalm/Todo-App/repositories/revocationRepository"
+	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// stubRoleRepository holds custom roles in memory, so the tests exercise
+// the real RoleService.
+type stubRoleRepository struct {
+	roles map[string]models.Role
+}
+
+func (r *stubRoleRepository) CreateRole(role *models.Role) error {
+	r.roles[role.Name] = *role
+	return nil
+}
+
+func (r *stubRoleRepository) GetRoles() ([]models.Role, error) {
+	var roles []models.Role
+	for _, role := range r.roles {
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (r *stubRoleRepository) FindRoleByName(name string) (*models.Role, error) {
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &role, nil
+}
+
+func (r *stubRoleRepository) UpdateRole(role *models.Role) error {
+	r.roles[role.Name] = *role
+	return nil
+}
+
+func (r *stubRoleRepository) DeleteRole(name string) error {
+	delete(r.roles, name)
+	return nil
+}
+
+func (r *stubRoleRepository) CountUsersWithRole(name string) (int64, error) {
+	return 0, nil
+}
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(event *models.AuditEvent) error {
+	return m.Called(event).Error(0)
+}
+
+func (m *MockAuditService) ListEvents(options services.AuditListOptions) ([]models.AuditEvent, int64, error) {
+	args := m.Called(options)
+	return args.Get(0).([]models.AuditEvent), args.Get(1).(int64), args.Error(2)
+}
+
+// adminFixture serves the admin routes behind the real AuthMiddleware and
+// RequirePermission, with the built-in roles, a "support" role that may
+// only read and impersonate users, a "moderator" role that may read and
+// manage them, and a "rolekeeper" role that may only manage roles.
+type adminFixture struct {
+	router        *gin.Engine
+	jwt           *auth.JWTService
+	users         *MockUserService
+	sessions      *MockSessionService
+	tasks         *MockTaskService
+	resets        *MockPasswordResetService
+	loginAttempts *MockLoginAttemptService
+	audit         *MockAuditService
+}
+
+func newAdminFixture(t *testing.T) *adminFixture {
+	t.Helper()
+	jwtService, err := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	require.NoError(t, err)
+	f := &adminFixture{
+		jwt:           jwtService,
+		users:         new(MockUserService),
+		sessions:      new(MockSessionService),
+		tasks:         new(MockTaskService),
+		resets:        new(MockPasswordResetService),
+		loginAttempts: new(MockLoginAttemptService),
+		audit:         new(MockAuditService),
+	}
+
+	roleService := services.NewRoleService(&stubRoleRepository{roles: map[string]models.Role{
+		"support":    {Name: "support", Permissions: models.StringList{models.PermissionUsersRead, models.PermissionUsersImpersonate}},
+		"moderator":  {Name: "moderator", Permissions: models.StringList{models.PermissionUsersRead, models.PermissionUsersManage}},
+		"rolekeeper": {Name: "rolekeeper", Permissions: models.StringList{models.PermissionRolesManage}},
+	}})
+
+	revocationStore := revocationRepository.NewMemoryRevocationStore()
+	adminHandler := NewAdminHandler(AdminServices{
+		Users:          f.users,
+		Roles:          roleService,
+		Audit:          f.audit,
+		Tasks:          f.tasks,
+		Sessions:       f.sessions,
+		PasswordResets: f.resets,
+		LoginAttempts:  f.loginAttempts,
+		Tokens:         jwtService,
+		Revocations:    revocationStore,
+	}, config.AppConfig{Auth: config.AuthConfig{ImpersonationTTL: 5 * time.Minute}})
+	authMiddleware := middleware.AuthMiddleware(jwtService, revocationStore, f.sessions, f.users, f.audit)
+	can := func(permission string) gin.HandlerFunc {
+		return middleware.RequirePermission(roleService, permission)
+	}
+	gin.SetMode(gin.TestMode)
+	f.router = gin.New()
+	admin := f.router.Group("/api/admin", authMiddleware, middleware.ForbidImpersonation())
+	admin.GET("/users", can(models.PermissionUsersRead), adminHandler.ListUsers)
+	admin.GET("/users/:id", can(models.PermissionUsersRead), adminHandler.GetUser)
+	admin.POST("/users/:id/deactivate", can(models.PermissionUsersManage), adminHandler.DeactivateUser)
+	admin.POST("/users/:id/reactivate", can(models.PermissionUsersManage), adminHandler.ReactivateUser)
+	admin.POST("/users/:id/password-reset", can(models.PermissionUsersManage), adminHandler.ForcePasswordReset)
+	admin.DELETE("/users/:id/sessions", can(models.PermissionUsersManage), adminHandler.RevokeSessions)
+	admin.PUT("/users/:id/role", can(models.PermissionUsersManage), adminHandler.ChangeRole)
+	admin.POST("/users/:id/impersonate", can(models.PermissionUsersImpersonate), adminHandler.ImpersonateUser)
+	admin.POST("/roles", can(models.PermissionRolesManage), adminHandler.CreateRole)
+	admin.PUT("/roles/:name", can(models.PermissionRolesManage), adminHandler.UpdateRole)
+	admin.DELETE("/roles/:name", can(models.PermissionRolesManage), adminHandler.DeleteRole)
+	me := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id"), "actor_id": c.GetString("actor_id")})
+	}
+	f.router.GET("/api/me", authMiddleware, me)
+	f.router.POST("/api/auth/password/change", authMiddleware, middleware.ForbidImpersonation(), me)
+	return f
+}
+
+// signIn adds a user with role and returns an access token for them.
+func (f *adminFixture) signIn(t *testing.T, role string) (*models.User, string) {
+	t.Helper()
+	user := &models.User{ID: uuid.New(), Email: role + "@example.com", Role: role}
+	sessionID := uuid.New()
+	f.users.On("FindUserByID", user.ID).Return(user, nil)
+	f.sessions.On("ValidateSession", sessionID, user.ID).Return(nil)
+	token, err := f.jwt.GenerateToken(user.ID, sessionID)
+	require.NoError(t, err)
+	return user, token
+}
+
+func (f *adminFixture) do(method, path, token string) *httptest.ResponseRecorder {
+	return f.doJSON(method, path, token, "")
+}
+
+func (f *adminFixture) doJSON(method, path, token, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	f.router.ServeHTTP(resp, req)
+	return resp
+}
+
+// actions lists the actions recorded in the audit log so far.
+func (f *adminFixture) actions() []string {
+	var actions []string
+	for _, call := range f.audit.Calls {
+		actions = append(actions, call.Arguments.Get(0).(*models.AuditEvent).Action)
+	}
+	return actions
+}
+
+func TestAdminRoutesRequirePermission(t *testing.T) {
+	f := newAdminFixture(t)
+	_, userToken := f.signIn(t, models.RoleUser)
+	_, supportToken := f.signIn(t, "support")
+	admin, adminToken := f.signIn(t, models.RoleAdmin)
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(nil)
+	f.users.On("ListUsers", mock.Anything).Return([]models.User{*admin}, int64(1), nil)
+
+	resp := f.do(http.MethodGet, "/api/admin/users", userToken)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Contains(t, resp.Body.String(), "PERMISSION_DENIED")
+
+	resp = f.do(http.MethodGet, "/api/admin/users", supportToken)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "password", "the admin listing leaves out password hashes")
+	assert.Equal(t, http.StatusForbidden, f.do(http.MethodPost, "/api/admin/users/"+uuid.NewString()+"/deactivate", supportToken).Code)
+
+	assert.Equal(t, http.StatusOK, f.do(http.MethodGet, "/api/admin/users?limit=1000", adminToken).Code)
+	f.users.AssertCalled(t, "ListUsers", services.UserListOptions{Limit: maxAdminPageSize})
+	assert.Equal(t, http.StatusOK, f.do(http.MethodGet, "/api/admin/users?q=jane&role=admin&active=false", adminToken).Code)
+	inactive := false
+	f.users.AssertCalled(t, "ListUsers", services.UserListOptions{Search: "jane", Role: models.RoleAdmin, Active: &inactive, Limit: defaultAdminPageSize})
+	f.audit.AssertNumberOfCalls(t, "Record", 3)
+}
+
+func TestAdminUserManagement(t *testing.T) {
+	f := newAdminFixture(t)
+	target, targetToken := f.signIn(t, models.RoleUser)
+	admin, adminToken := f.signIn(t, models.RoleAdmin)
+	_, moderatorToken := f.signIn(t, "moderator")
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(nil)
+	userPath := "/api/admin/users/" + target.ID.String()
+
+	f.tasks.On("CountTasks", target.ID).Return(taskRepository.TaskCounts{Total: 7, Completed: 4, Overdue: 1}, nil)
+	f.sessions.On("GetSessionsByUserID", target.ID).Return([]models.Session{{ID: uuid.New()}, {ID: uuid.New()}}, nil)
+	resp := f.do(http.MethodGet, userPath, moderatorToken)
+	require.Equal(t, http.StatusOK, resp.Code)
+	var details struct {
+		Data struct {
+			Tasks          taskRepository.TaskCounts `json:"tasks"`
+			ActiveSessions int                       `json:"active_sessions"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&details))
+	assert.Equal(t, taskRepository.TaskCounts{Total: 7, Completed: 4, Overdue: 1}, details.Data.Tasks)
+	assert.Equal(t, 2, details.Data.ActiveSessions)
+
+	f.users.On("ReactivateUser", target.ID).Return(nil)
+	assert.Equal(t, http.StatusOK, f.do(http.MethodPost, userPath+"/reactivate", moderatorToken).Code)
+
+	f.resets.On("ForceReset", target.ID).Return(nil)
+	f.loginAttempts.On("RecordSuccess", target.Email).Return(nil)
+	assert.Equal(t, http.StatusOK, f.do(http.MethodPost, userPath+"/password-reset", moderatorToken).Code)
+	f.loginAttempts.AssertExpectations(t)
+
+	f.sessions.On("RevokeAllSessions", target.ID).Return(nil)
+	assert.Equal(t, http.StatusOK, f.do(http.MethodDelete, userPath+"/sessions", moderatorToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, f.do(http.MethodGet, "/api/me", targetToken).Code, "the user's access tokens are revoked too")
+
+	f.users.On("UpdateUser", target.ID, map[string]interface{}{"role": "moderator"}).Return(nil)
+	assert.Equal(t, http.StatusOK, f.doJSON(http.MethodPut, userPath+"/role", moderatorToken, `{"role": "moderator"}`).Code)
+	resp = f.doJSON(http.MethodPut, userPath+"/role", moderatorToken, `{"role": "admin"}`)
+	assert.Equal(t, http.StatusForbidden, resp.Code, "moderators cannot grant permissions they lack")
+	assert.Equal(t, http.StatusNotFound, f.doJSON(http.MethodPut, userPath+"/role", adminToken, `{"role": "superuser"}`).Code)
+	assert.Equal(t, http.StatusConflict, f.doJSON(http.MethodPut, "/api/admin/users/"+admin.ID.String()+"/role", adminToken, `{"role": "user"}`).Code)
+
+	resp = f.do(http.MethodPost, "/api/admin/users/"+admin.ID.String()+"/deactivate", moderatorToken)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Contains(t, resp.Body.String(), "CANNOT_MANAGE_USER")
+
+	assert.Equal(t, []string{
+		models.AuditUserView,
+		models.AuditUserReactivate,
+		models.AuditUserPasswordReset,
+		models.AuditUserSessionsRevoke,
+		models.AuditUserRoleChange,
+	}, f.actions())
+	event := f.audit.Calls[4].Arguments.Get(0).(*models.AuditEvent)
+	assert.Equal(t, models.AuditDetails{"from": models.RoleUser, "to": "moderator"}, event.Details)
+}
+
+func TestRoleManagersCannotEscalate(t *testing.T) {
+	f := newAdminFixture(t)
+	_, keeperToken := f.signIn(t, "rolekeeper")
+	_, adminToken := f.signIn(t, models.RoleAdmin)
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(nil)
+
+	for _, request := range []struct{ method, path, body string }{
+		{http.MethodPut, "/api/admin/roles/rolekeeper", `{"permissions": ["roles:manage", "users:impersonate"]}`},
+		{http.MethodPost, "/api/admin/roles", `{"name": "sidekick", "permissions": ["users:impersonate"]}`},
+		{http.MethodPut, "/api/admin/roles/support", `{"permissions": ["roles:manage"]}`},
+		{http.MethodDelete, "/api/admin/roles/support", ""},
+	} {
+		resp := f.doJSON(request.method, request.path, keeperToken, request.body)
+		assert.Equal(t, http.StatusForbidden, resp.Code, request.path)
+		assert.Contains(t, resp.Body.String(), "PERMISSION_DENIED", request.path)
+	}
+	f.audit.AssertNotCalled(t, "Record", mock.Anything)
+	assert.Equal(t, http.StatusForbidden, f.do(http.MethodPost, "/api/admin/users/"+uuid.NewString()+"/impersonate", keeperToken).Code)
+
+	assert.Equal(t, http.StatusOK, f.doJSON(http.MethodPut, "/api/admin/roles/rolekeeper", keeperToken, `{"description": "Keeps roles", "permissions": ["roles:manage"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, f.doJSON(http.MethodPut, "/api/admin/roles/rolekeeper", adminToken, `{"permissions": ["roles:everything"]}`).Code)
+	assert.Equal(t, http.StatusOK, f.doJSON(http.MethodPut, "/api/admin/roles/support", adminToken, `{"permissions": ["users:read"]}`).Code)
+}
+
+func TestAdminActionsFailClosedWithoutAudit(t *testing.T) {
+	f := newAdminFixture(t)
+	_, adminToken := f.signIn(t, models.RoleAdmin)
+	target, _ := f.signIn(t, models.RoleUser)
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(errors.New("database is down")).Once()
+
+	resp := f.do(http.MethodPost, "/api/admin/users/"+target.ID.String()+"/deactivate", adminToken)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	f.users.AssertNotCalled(t, "DeactivateUser", mock.Anything)
+
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(nil)
+	f.users.On("DeactivateUser", target.ID).Return(nil)
+	assert.Equal(t, http.StatusOK, f.do(http.MethodPost, "/api/admin/users/"+target.ID.String()+"/deactivate", adminToken).Code)
+	event := f.audit.Calls[1].Arguments.Get(0).(*models.AuditEvent)
+	assert.Equal(t, models.AuditUserDeactivate, event.Action)
+	assert.Equal(t, target.ID, *event.TargetID)
+}
+
+func TestImpersonateUser(t *testing.T) {
+	f := newAdminFixture(t)
+	target, _ := f.signIn(t, models.RoleUser)
+	support, supportToken := f.signIn(t, "support")
+	admin, adminToken := f.signIn(t, models.RoleAdmin)
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(nil)
+
+	resp := f.do(http.MethodPost, "/api/admin/users/"+target.ID.String()+"/impersonate", supportToken)
+	require.Equal(t, http.StatusOK, resp.Code)
+	var response struct {
+		Data struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+			ExpiresIn    int    `json:"expires_in"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Empty(t, response.Data.RefreshToken, "impersonation cannot be extended")
+	assert.Equal(t, 300, response.Data.ExpiresIn)
+	event := f.audit.Calls[0].Arguments.Get(0).(*models.AuditEvent)
+	assert.Equal(t, models.AuditUserImpersonate, event.Action)
+	assert.Equal(t, support.ID, *event.ActorID)
+
+	// The token acts as the target on the support agent's behalf.
+	token := response.Data.AccessToken
+	resp = f.do(http.MethodGet, "/api/me", token)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"user_id": "`+target.ID.String()+`", "actor_id": "`+support.ID.String()+`"}`, resp.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, f.do(http.MethodPost, "/api/admin/users/"+admin.ID.String()+"/impersonate", supportToken).Code, "support cannot become someone with more permissions")
+	assert.Equal(t, http.StatusForbidden, f.do(http.MethodPost, "/api/admin/users/"+admin.ID.String()+"/impersonate", adminToken).Code, "admins cannot impersonate themselves")
+	assert.Equal(t, http.StatusOK, f.do(http.MethodPost, "/api/admin/users/"+support.ID.String()+"/impersonate", adminToken).Code)
+
+	// Once the support agent is deactivated, their impersonation ends too.
+	deactivatedAt := time.Now()
+	support.DeactivatedAt = &deactivatedAt
+	assert.Equal(t, http.StatusForbidden, f.do(http.MethodGet, "/api/me", token).Code)
+}
+
+func TestImpersonatedRequests(t *testing.T) {
+	f := newAdminFixture(t)
+	target, _ := f.signIn(t, models.RoleUser)
+	support, supportToken := f.signIn(t, "support")
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(errors.New("database is down")).Once()
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(nil)
+
+	resp := f.do(http.MethodPost, "/api/admin/users/"+target.ID.String()+"/impersonate", supportToken)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Header().Get(middleware.ImpersonatedByHeader))
+	var response struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	token := response.Data.AccessToken
+
+	// A request that cannot be audited is refused.
+	resp = f.do(http.MethodGet, "/api/me", token)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Contains(t, resp.Body.String(), "AUDIT_FAILED")
+
+	resp = f.do(http.MethodGet, "/api/me?page=2", token)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, support.ID.String(), resp.Header().Get(middleware.ImpersonatedByHeader))
+	event := f.audit.Calls[2].Arguments.Get(0).(*models.AuditEvent)
+	assert.Equal(t, models.AuditImpersonatedRequest, event.Action)
+	assert.Equal(t, support.ID, *event.ActorID)
+	assert.Equal(t, target.ID, *event.TargetID)
+	assert.Equal(t, models.AuditDetails{"method": http.MethodGet, "path": "/api/me"}, event.Details)
+
+	// Sensitive actions are refused, but still audited and marked.
+	for _, path := range []string{"/api/auth/password/change", "/api/admin/users/" + support.ID.String() + "/impersonate"} {
+		resp = f.do(http.MethodPost, path, token)
+		assert.Equal(t, http.StatusForbidden, resp.Code, path)
+		assert.Contains(t, resp.Body.String(), "IMPERSONATION_FORBIDDEN", path)
+		assert.Equal(t, support.ID.String(), resp.Header().Get(middleware.ImpersonatedByHeader), path)
+	}
+	f.audit.AssertNumberOfCalls(t, "Record", 5)
+	assert.Equal(t, http.StatusOK, f.do(http.MethodPost, "/api/auth/password/change", supportToken).Code)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	var keyDTO dtos.CreateAPIKeyDTO
+	if err := c.ShouldBindJSON(&keyDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	apiKey, key, err := h.apiKeyService.CreateKey(userID, keyDTO.Name, keyDTO.Scopes, keyDTO.ExpiresAt)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidAPIKeyScope:
+			httputil.HandleError(c, errors.ErrInvalidAPIKeyScope)
+		case services.ErrAPIKeyExpiryPassed:
+			httputil.HandleError(c, errors.ErrAPIKeyExpiryPassed)
+		default:
+			appErr := errors.ErrCreateAPIKeyFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+		}
+		return
+	}
+
+	response := dtos.NewAPIKeyResponseDTO(apiKey)
+	response.Key = key
+	httputil.SendSuccess(c, http.StatusCreated, "API key created successfully; copy it now, it will not be shown again", response)
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	keys, err := h.apiKeyService.GetKeysByUserID(userID)
+	if err != nil {
+		appErr := errors.ErrFetchAPIKeysFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	response := make([]*dtos.APIKeyResponseDTO, len(keys))
+	for i := range keys {
+		response[i] = dtos.NewAPIKeyResponseDTO(&keys[i])
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "API keys retrieved successfully", response)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		appErr := errors.ErrInvalidAPIKeyID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(keyID, userID); err != nil {
+		if err == services.ErrAPIKeyNotFound {
+			httputil.HandleError(c, errors.ErrAPIKeyNotFound)
+			return
+		}
+		appErr := errors.ErrRevokeAPIKeyFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "API key revoked successfully", nil)
+}
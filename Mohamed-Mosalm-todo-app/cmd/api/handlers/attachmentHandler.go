@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/MohamedMosalm/Todo-App/utils/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AttachmentHandler struct {
+	attachmentService services.AttachmentService
+	taskService       services.TaskService
+}
+
+func NewAttachmentHandler(attachmentService services.AttachmentService, taskService services.TaskService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+		taskService:       taskService,
+	}
+}
+
+// UploadAttachment reads the multipart body part by part so the file is
+// streamed to storage instead of being buffered by the form parser.
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	task, ok := h.loadTask(c)
+	if !ok {
+		return
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			httputil.HandleError(c, errors.ErrMissingFile)
+			return
+		}
+		if err != nil {
+			appErr := errors.ErrInvalidRequest
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.attachmentService.Upload(c.Request.Context(), task, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			switch err {
+			case services.ErrAttachmentTooLarge:
+				httputil.HandleError(c, errors.ErrAttachmentTooLarge)
+			case services.ErrMIMETypeNotAllowed:
+				httputil.HandleError(c, errors.ErrMIMETypeNotAllowed)
+			case services.ErrQuotaExceeded:
+				httputil.HandleError(c, errors.ErrQuotaExceeded)
+			default:
+				appErr := errors.ErrUploadFailed
+				appErr.Details = err
+				httputil.HandleError(c, appErr)
+			}
+			return
+		}
+
+		httputil.SendSuccess(c, http.StatusCreated, "Attachment uploaded successfully", dtos.NewAttachmentResponseDTO(attachment))
+		return
+	}
+}
+
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	task, ok := h.loadTask(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentService.GetAttachmentsByTaskID(task.ID)
+	if err != nil {
+		appErr := errors.ErrFetchAttachmentsFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	attachmentResponses := make([]dtos.AttachmentResponseDTO, len(attachments))
+	for i, attachment := range attachments {
+		attachmentResponses[i] = *dtos.NewAttachmentResponseDTO(&attachment)
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Attachments retrieved successfully", attachmentResponses)
+}
+
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachment, ok := h.loadAttachment(c)
+	if !ok {
+		return
+	}
+
+	content, err := h.attachmentService.Open(c.Request.Context(), attachment)
+	if err != nil {
+		if err == storage.ErrBlobNotFound {
+			httputil.HandleError(c, errors.ErrAttachmentNotFound)
+			return
+		}
+		appErr := errors.ErrDownloadFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	attachment, ok := h.loadAttachment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(c.Request.Context(), attachment); err != nil {
+		appErr := errors.ErrDeleteAttachmentFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Attachment deleted successfully", nil)
+}
+
+func (h *AttachmentHandler) loadTask(c *gin.Context) (*models.Task, bool) {
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		appErr := errors.ErrInvalidTaskID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	task, err := h.taskService.GetTaskByID(taskID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.HandleError(c, errors.ErrTaskNotFound)
+			return nil, false
+		}
+		appErr := errors.ErrFetchTasksFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	if task.UserID != userID {
+		httputil.HandleError(c, errors.ErrUnauthorized)
+		return nil, false
+	}
+
+	return task, true
+}
+
+func (h *AttachmentHandler) loadAttachment(c *gin.Context) (*models.Attachment, bool) {
+	task, ok := h.loadTask(c)
+	if !ok {
+		return nil, false
+	}
+
+	attachmentID, err := uuid.Parse(c.Param("attachment_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidAttachmentID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	attachment, err := h.attachmentService.GetAttachmentByID(attachmentID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.HandleError(c, errors.ErrAttachmentNotFound)
+			return nil, false
+		}
+		appErr := errors.ErrFetchAttachmentsFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	if attachment.TaskID != task.ID {
+		httputil.HandleError(c, errors.ErrAttachmentNotFound)
+		return nil, false
+	}
+
+	return attachment, true
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FilterHandler struct {
+	filterService services.FilterService
+}
+
+func NewFilterHandler(filterService services.FilterService) *FilterHandler {
+	return &FilterHandler{filterService: filterService}
+}
+
+func (h *FilterHandler) CreateFilter(c *gin.Context) {
+	var filterDTO dtos.FilterDTO
+	if err := c.ShouldBindJSON(&filterDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	filter := models.SavedFilter{UserID: userID}
+	filterDTO.ApplyTo(&filter)
+
+	if err := h.filterService.CreateFilter(&filter); err != nil {
+		if appErr := filterServiceError(err); appErr != nil {
+			httputil.HandleError(c, appErr)
+			return
+		}
+		appErr := errors.ErrCreateFilterFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusCreated, "Filter created successfully", dtos.NewFilterResponseDTO(&filter))
+}
+
+func (h *FilterHandler) GetFilters(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	filters, err := h.filterService.GetFiltersByUserID(userID)
+	if err != nil {
+		appErr := errors.ErrFetchFiltersFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	filterResponses := make([]dtos.FilterResponseDTO, len(filters))
+	for i, filter := range filters {
+		filterResponses[i] = *dtos.NewFilterResponseDTO(&filter)
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Filters retrieved successfully", filterResponses)
+}
+
+func (h *FilterHandler) GetFilter(c *gin.Context) {
+	filter, ok := h.loadFilter(c)
+	if !ok {
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Filter retrieved successfully", dtos.NewFilterResponseDTO(filter))
+}
+
+func (h *FilterHandler) UpdateFilter(c *gin.Context) {
+	var filterDTO dtos.FilterDTO
+	if err := c.ShouldBindJSON(&filterDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	filter, ok := h.loadFilter(c)
+	if !ok {
+		return
+	}
+
+	filterDTO.ApplyTo(filter)
+	if err := h.filterService.UpdateFilter(filter); err != nil {
+		if appErr := filterServiceError(err); appErr != nil {
+			httputil.HandleError(c, appErr)
+			return
+		}
+		appErr := errors.ErrUpdateFilterFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Filter updated successfully", dtos.NewFilterResponseDTO(filter))
+}
+
+func (h *FilterHandler) DeleteFilter(c *gin.Context) {
+	filter, ok := h.loadFilter(c)
+	if !ok {
+		return
+	}
+
+	if err := h.filterService.DeleteFilter(filter.ID, filter.UserID); err != nil {
+		appErr := errors.ErrDeleteFilterFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Filter deleted successfully", nil)
+}
+
+func (h *FilterHandler) GetFilterTasks(c *gin.Context) {
+	filter, ok := h.loadFilter(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.filterService.FilterTasks(filter, time.Now())
+	if err != nil {
+		if appErr := filterServiceError(err); appErr != nil {
+			httputil.HandleError(c, appErr)
+			return
+		}
+		appErr := errors.ErrFetchTasksFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	taskResponses := make([]dtos.TaskResponseDTO, len(tasks))
+	for i, task := range tasks {
+		taskResponses[i] = *dtos.NewTaskResponseDTO(&task)
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Tasks retrieved successfully", taskResponses)
+}
+
+func (h *FilterHandler) loadFilter(c *gin.Context) (*models.SavedFilter, bool) {
+	filterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		appErr := errors.ErrInvalidFilterID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	filter, err := h.filterService.GetFilterByID(filterID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.HandleError(c, errors.ErrFilterNotFound)
+			return nil, false
+		}
+		appErr := errors.ErrFetchFiltersFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	if filter.UserID != userID {
+		httputil.HandleError(c, errors.ErrFilterNotFound)
+		return nil, false
+	}
+
+	return filter, true
+}
+
+func filterServiceError(err error) *errors.AppError {
+	if queryErr, ok := err.(*services.FilterQueryError); ok {
+		appErr := errors.ErrInvalidFilterQuery
+		appErr.Details = queryErr
+		return appErr
+	}
+	if err == services.ErrInvalidSort {
+		return errors.ErrInvalidSort
+	}
+	return nil
+}
@@ -2,19 +2,32 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/MohamedMosalm/Todo-App/config"
 	"github.com/MohamedMosalm/Todo-App/dtos"
 	"github.com/MohamedMosalm/Todo-App/models"
+	revocationRepository "github.com/MohamedMosalm/Todo-App/repositories/revocationRepository"
+	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+	"github.com/MohamedMosalm/Todo-App/services"
 	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
 	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type MockUserService struct {
@@ -35,6 +48,10 @@ func (m *MockUserService) FindUserByEmail(email string) (*models.User, error) {
 	return user.(*models.User), args.Error(1)
 }
 
+func (m *MockUserService) UpdatePassword(id uuid.UUID, hashedPassword string) error {
+	return m.Called(id, hashedPassword).Error(0)
+}
+
 func (m *MockUserService) FindUserByID(id uuid.UUID) (*models.User, error) {
 	args := m.Called(id)
 	user := args.Get(0)
@@ -44,6 +61,264 @@ func (m *MockUserService) FindUserByID(id uuid.UUID) (*models.User, error) {
 	return user.(*models.User), args.Error(1)
 }
 
+func (m *MockUserService) ListUsers(options services.UserListOptions) ([]models.User, int64, error) {
+	args := m.Called(options)
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserService) UpdateUser(id uuid.UUID, updates map[string]interface{}) error {
+	return m.Called(id, updates).Error(0)
+}
+
+func (m *MockUserService) DeactivateUser(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockUserService) DeprovisionUser(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockUserService) ReactivateUser(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+type MockRefreshTokenService struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenService) Issue(userID, sessionID uuid.UUID) (string, error) {
+	args := m.Called(userID, sessionID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenService) Rotate(token string) (string, *models.RefreshToken, error) {
+	args := m.Called(token)
+	record := args.Get(1)
+	if record == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), record.(*models.RefreshToken), args.Error(2)
+}
+
+type MockSessionService struct {
+	mock.Mock
+}
+
+func (m *MockSessionService) CreateSession(userID uuid.UUID, userAgent, ip string) (*models.Session, error) {
+	args := m.Called(userID, userAgent, ip)
+	session := args.Get(0)
+	if session == nil {
+		return nil, args.Error(1)
+	}
+	return session.(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionService) GetSessionsByUserID(userID uuid.UUID) ([]models.Session, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionService) ValidateSession(sessionID, userID uuid.UUID) error {
+	return m.Called(sessionID, userID).Error(0)
+}
+
+func (m *MockSessionService) RevokeSession(sessionID, userID uuid.UUID) error {
+	return m.Called(sessionID, userID).Error(0)
+}
+
+func (m *MockSessionService) RevokeAllSessions(userID uuid.UUID) error {
+	return m.Called(userID).Error(0)
+}
+
+type MockPasswordResetService struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetService) RequestReset(ctx context.Context, email string) error {
+	return m.Called(email).Error(0)
+}
+
+func (m *MockPasswordResetService) ResetPassword(token, newPassword string) error {
+	return m.Called(token, newPassword).Error(0)
+}
+
+func (m *MockPasswordResetService) ForceReset(ctx context.Context, userID uuid.UUID) error {
+	return m.Called(userID).Error(0)
+}
+
+type MockEmailVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	return m.Called(user).Error(0)
+}
+
+func (m *MockEmailVerificationService) ResendVerification(ctx context.Context, email string) error {
+	return m.Called(email).Error(0)
+}
+
+func (m *MockEmailVerificationService) VerifyEmail(token string) error {
+	return m.Called(token).Error(0)
+}
+
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) Enroll(user *models.User) (string, string, error) {
+	args := m.Called(user)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockMFAService) Confirm(userID uuid.UUID, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAService) IsEnabled(userID uuid.UUID) (bool, error) {
+	args := m.Called(userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFAService) Verify(userID uuid.UUID, code string) error {
+	return m.Called(userID, code).Error(0)
+}
+
+func (m *MockMFAService) Disable(userID uuid.UUID) error {
+	return m.Called(userID).Error(0)
+}
+
+type MockMagicLinkService struct {
+	mock.Mock
+}
+
+func (m *MockMagicLinkService) RequestLink(ctx context.Context, email, deviceHash string) (time.Duration, error) {
+	args := m.Called(ctx, email, deviceHash)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockMagicLinkService) ConsumeLink(token, deviceHash string) (*models.User, error) {
+	args := m.Called(token, deviceHash)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*models.User), args.Error(1)
+}
+
+type MockPasskeyService struct {
+	mock.Mock
+}
+
+func (m *MockPasskeyService) BeginRegistration(user *models.User) (*protocol.CredentialCreation, string, error) {
+	args := m.Called(user)
+	creation, _ := args.Get(0).(*protocol.CredentialCreation)
+	return creation, args.String(1), args.Error(2)
+}
+
+func (m *MockPasskeyService) FinishRegistration(user *models.User, ceremony, name string, response []byte) (*models.Passkey, error) {
+	args := m.Called(user, ceremony, name, response)
+	passkey, _ := args.Get(0).(*models.Passkey)
+	return passkey, args.Error(1)
+}
+
+func (m *MockPasskeyService) GetPasskeys(userID uuid.UUID) ([]models.Passkey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Passkey), args.Error(1)
+}
+
+func (m *MockPasskeyService) HasPasskeys(userID uuid.UUID) (bool, error) {
+	args := m.Called(userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasskeyService) DeletePasskey(passkeyID, userID uuid.UUID) error {
+	return m.Called(passkeyID, userID).Error(0)
+}
+
+func (m *MockPasskeyService) BeginLogin() (*protocol.CredentialAssertion, string, error) {
+	args := m.Called()
+	assertion, _ := args.Get(0).(*protocol.CredentialAssertion)
+	return assertion, args.String(1), args.Error(2)
+}
+
+func (m *MockPasskeyService) FinishLogin(ceremony string, response []byte) (*models.User, error) {
+	args := m.Called(ceremony, response)
+	user, _ := args.Get(0).(*models.User)
+	return user, args.Error(1)
+}
+
+func (m *MockPasskeyService) BeginSecondFactor(userID uuid.UUID) (*protocol.CredentialAssertion, string, error) {
+	args := m.Called(userID)
+	assertion, _ := args.Get(0).(*protocol.CredentialAssertion)
+	return assertion, args.String(1), args.Error(2)
+}
+
+func (m *MockPasskeyService) FinishSecondFactor(userID uuid.UUID, ceremony string, response []byte) error {
+	return m.Called(userID, ceremony, response).Error(0)
+}
+
+type MockLoginAttemptService struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptService) Check(email, ip string) (time.Duration, error) {
+	args := m.Called(email, ip)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginAttemptService) RecordFailure(ctx context.Context, email, ip string) error {
+	return m.Called(email, ip).Error(0)
+}
+
+func (m *MockLoginAttemptService) RecordSuccess(email string) error {
+	return m.Called(email).Error(0)
+}
+
+// newAllowingLoginAttemptService never locks anyone out.
+func newAllowingLoginAttemptService() *MockLoginAttemptService {
+	m := new(MockLoginAttemptService)
+	m.On("Check", mock.Anything, mock.Anything).Return(time.Duration(0), nil)
+	m.On("RecordFailure", mock.Anything, mock.Anything).Return(nil)
+	m.On("RecordSuccess", mock.Anything).Return(nil)
+	return m
+}
+
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) CreateKey(userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	args := m.Called(userID, name, scopes, expiresAt)
+	key := args.Get(0)
+	if key == nil {
+		return nil, "", args.Error(2)
+	}
+	return key.(*models.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyService) GetKeysByUserID(userID uuid.UUID) ([]models.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeKey(keyID, userID uuid.UUID) error {
+	return m.Called(keyID, userID).Error(0)
+}
+
+func (m *MockAPIKeyService) Authenticate(key string) (*models.APIKey, error) {
+	args := m.Called(key)
+	apiKey := args.Get(0)
+	if apiKey == nil {
+		return nil, args.Error(1)
+	}
+	return apiKey.(*models.APIKey), args.Error(1)
+}
+
 type MockTaskService struct {
 	mock.Mock
 }
@@ -58,13 +333,18 @@ func (m *MockTaskService) GetTasksByUserID(userID uuid.UUID) ([]models.Task, err
 	return args.Get(0).([]models.Task), args.Error(1)
 }
 
+func (m *MockTaskService) ListTasks(userID uuid.UUID, options services.TaskListOptions) ([]models.Task, error) {
+	args := m.Called(userID, options)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
 func (m *MockTaskService) UpdateTask(taskID uuid.UUID, updates map[string]interface{}) error {
 	args := m.Called(taskID, updates)
 	return args.Error(0)
 }
 
-func (m *MockTaskService) DeleteTask(taskID, userID uuid.UUID) error {
-	args := m.Called(taskID, userID)
+func (m *MockTaskService) DeleteTask(ctx context.Context, taskID, userID uuid.UUID) error {
+	args := m.Called(ctx, taskID, userID)
 	return args.Error(0)
 }
 
@@ -73,11 +353,35 @@ func (m *MockTaskService) GetTaskByID(taskID uuid.UUID) (*models.Task, error) {
 	return args.Get(0).(*models.Task), args.Error(1)
 }
 
+func (m *MockTaskService) CountTasks(userID uuid.UUID) (taskRepository.TaskCounts, error) {
+	args := m.Called(userID)
+	return args.Get(0).(taskRepository.TaskCounts), args.Error(1)
+}
+
+func newTestKeySet() *auth.KeySet {
+	keys, _ := auth.NewHMACKeySet("test_secret")
+	return keys
+}
+
+var testJWTOptions = auth.JWTOptions{Issuer: "https://todo.example.com", Audience: "todo-api", AccessTokenTTL: time.Minute}
+
+// newTestPasswordService hashes with bcrypt's minimum cost to keep tests fast.
+func newTestPasswordService() *auth.PasswordService {
+	return auth.NewPasswordService(auth.NewBcryptHasher(bcrypt.MinCost))
+}
+
+func newTestPasswordPolicy() *auth.PasswordPolicy {
+	policy, _ := auth.NewPasswordPolicy(config.PasswordConfig{MinLength: 8, MaxLength: 72, MinStrength: 2})
+	return policy
+}
+
 func setupUserRouter(authHandler *AuthHandler) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
 	router.POST("/api/auth/register", authHandler.Register)
 	router.POST("/api/auth/login", authHandler.Login)
+	router.POST("/api/auth/refresh", authHandler.Refresh)
+	router.POST("/api/auth/2fa/verify", authHandler.VerifyMFA)
 	return router
 }
 
@@ -93,12 +397,15 @@ func setupTaskRouter(taskHandler *TaskHandler) *gin.Engine {
 
 func TestRegister(t *testing.T) {
 	mockUserService := new(MockUserService)
-	jwtService, _ := auth.NewJWTService("test_secret")
-	passwordService := auth.NewPasswordService()
+	mockEmailVerificationService := new(MockEmailVerificationService)
+	jwtService, _ := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	passwordService := newTestPasswordService()
 	authHandler := &AuthHandler{
-		userService:     mockUserService,
-		jwtService:      jwtService,
-		passwordService: passwordService,
+		userService:              mockUserService,
+		jwtService:               jwtService,
+		passwordService:          passwordService,
+		passwordPolicy:           newTestPasswordPolicy(),
+		emailVerificationService: mockEmailVerificationService,
 	}
 
 	router := setupUserRouter(authHandler)
@@ -108,11 +415,14 @@ func TestRegister(t *testing.T) {
 		LastName:  "Doe",
 		Email:     "john.doe@example.com",
 		Phone:     "1234567890",
-		Password:  "password123",
+		Password:  "plum-orbit-canvas",
 	}
 
 	mockUserService.On("FindUserByEmail", registerDTO.Email).Return(nil, nil)
 	mockUserService.On("CreateUser", mock.AnythingOfType("*models.User")).Return(nil)
+	mockEmailVerificationService.On("SendVerification", mock.MatchedBy(func(user *models.User) bool {
+		return user.Email == registerDTO.Email && user.EmailVerifiedAt == nil
+	})).Return(nil)
 
 	body, _ := json.Marshal(registerDTO)
 	req, _ := http.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBuffer(body))
@@ -123,19 +433,70 @@ func TestRegister(t *testing.T) {
 
 	assert.Equal(t, http.StatusCreated, resp.Code)
 	mockUserService.AssertExpectations(t)
+	mockEmailVerificationService.AssertExpectations(t)
+}
+
+func TestRegisterRejectsWeakPassword(t *testing.T) {
+	mockUserService := new(MockUserService)
+	authHandler := &AuthHandler{
+		userService:     mockUserService,
+		passwordService: newTestPasswordService(),
+		passwordPolicy:  newTestPasswordPolicy(),
+	}
+	router := setupUserRouter(authHandler)
+
+	mockUserService.On("FindUserByEmail", "john.doe@example.com").Return(nil, nil)
+
+	body, _ := json.Marshal(dtos.RegisterDTO{
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     "john.doe@example.com",
+		Phone:     "1234567890",
+		Password:  "johndoe",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	var response struct {
+		Error struct {
+			Code   string              `json:"code"`
+			Fields map[string][]string `json:"fields"`
+		} `json:"error"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, "WEAK_PASSWORD", response.Error.Code)
+	assert.ElementsMatch(t, []string{
+		"must be at least 8 characters long",
+		"must not contain your email address or name",
+		"is too easy to guess; try a longer passphrase or less common words",
+	}, response.Error.Fields["password"])
+	mockUserService.AssertNotCalled(t, "CreateUser", mock.Anything)
 }
 
 func TestLogin(t *testing.T) {
 	mockUserService := new(MockUserService)
-	passwordService := auth.NewPasswordService()
+	passwordService := newTestPasswordService()
 
-	jwtService, err := auth.NewJWTService("test_secret")
+	jwtService, err := auth.NewJWTService(newTestKeySet(), testJWTOptions)
 	assert.NoError(t, err)
 
+	mockRefreshTokenService := new(MockRefreshTokenService)
+	mockSessionService := new(MockSessionService)
+	mockMFAService := new(MockMFAService)
+	mockPasskeyService := new(MockPasskeyService)
 	authHandler := &AuthHandler{
-		userService:     mockUserService,
-		jwtService:      jwtService,
-		passwordService: passwordService,
+		userService:         mockUserService,
+		authenticator:       services.NewPasswordAuthenticator(mockUserService, passwordService),
+		jwtService:          jwtService,
+		passwordService:     passwordService,
+		refreshTokenService: mockRefreshTokenService,
+		sessionService:      mockSessionService,
+		mfaService:          mockMFAService,
+		passkeyService:      mockPasskeyService,
+		loginAttemptService: newAllowingLoginAttemptService(),
 	}
 
 	router := setupUserRouter(authHandler)
@@ -157,6 +518,11 @@ func TestLogin(t *testing.T) {
 	}
 
 	mockUserService.On("FindUserByEmail", loginDTO.Email).Return(testUser, nil)
+	mockMFAService.On("IsEnabled", testUser.ID).Return(false, nil)
+	mockPasskeyService.On("HasPasskeys", testUser.ID).Return(false, nil)
+	session := &models.Session{ID: uuid.New(), UserID: testUser.ID}
+	mockSessionService.On("CreateSession", testUser.ID, "test-agent", mock.AnythingOfType("string")).Return(session, nil)
+	mockRefreshTokenService.On("Issue", testUser.ID, session.ID).Return("refresh-token", nil)
 
 	body, err := json.Marshal(loginDTO)
 	assert.NoError(t, err)
@@ -164,6 +530,7 @@ func TestLogin(t *testing.T) {
 	req, err := http.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
 	assert.NoError(t, err)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "test-agent")
 
 	resp := httptest.NewRecorder()
 	router.ServeHTTP(resp, req)
@@ -179,7 +546,930 @@ func TestLogin(t *testing.T) {
 	data := response["data"].(map[string]interface{})
 	assert.NotEmpty(t, data["access_token"])
 	assert.Equal(t, "Bearer", data["token_type"])
+	assert.Equal(t, "refresh-token", data["refresh_token"])
+	assert.Equal(t, float64(60), data["expires_in"])
+
+	mockUserService.AssertExpectations(t)
+	mockRefreshTokenService.AssertExpectations(t)
+	mockSessionService.AssertExpectations(t)
+}
+
+func TestLoginRehashesOutdatedPassword(t *testing.T) {
+	mockUserService := new(MockUserService)
+	mockMFAService := new(MockMFAService)
+	mockPasskeyService := new(MockPasskeyService)
+	jwtService, _ := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	passwordService := auth.NewPasswordService(auth.NewArgon2idHasher(64, 1, 1))
+	authHandler := &AuthHandler{
+		userService:         mockUserService,
+		authenticator:       services.NewPasswordAuthenticator(mockUserService, passwordService),
+		jwtService:          jwtService,
+		passwordService:     passwordService,
+		mfaService:          mockMFAService,
+		passkeyService:      mockPasskeyService,
+		loginAttemptService: newAllowingLoginAttemptService(),
+		mfaChallengeTTL:     time.Minute,
+	}
+	router := setupUserRouter(authHandler)
+
+	bcryptHash, err := auth.NewBcryptHasher(bcrypt.MinCost).Hash("password123")
+	assert.NoError(t, err)
+	testUser := &models.User{ID: uuid.New(), Email: "old@example.com", Password: bcryptHash}
+	mockUserService.On("FindUserByEmail", testUser.Email).Return(testUser, nil)
+	mockUserService.On("UpdatePassword", testUser.ID, mock.MatchedBy(func(hash string) bool {
+		return strings.HasPrefix(hash, "$argon2id$")
+	})).Return(nil).Once()
+	mockMFAService.On("IsEnabled", testUser.ID).Return(true, nil)
+	mockPasskeyService.On("HasPasskeys", testUser.ID).Return(false, nil)
+
+	body, _ := json.Marshal(dtos.LoginDTO{Email: testUser.Email, Password: "password123"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.True(t, strings.HasPrefix(testUser.Password, "$argon2id$"))
+	mockUserService.AssertExpectations(t)
+}
+
+func TestLoginRequiresVerifiedEmail(t *testing.T) {
+	mockUserService := new(MockUserService)
+	passwordService := newTestPasswordService()
+	authHandler := &AuthHandler{
+		userService:          mockUserService,
+		authenticator:        services.NewPasswordAuthenticator(mockUserService, passwordService),
+		passwordService:      passwordService,
+		loginAttemptService:  newAllowingLoginAttemptService(),
+		requireVerifiedLogin: true,
+	}
+	router := setupUserRouter(authHandler)
+
+	hashedPassword, err := passwordService.HashPassword("password123")
+	assert.NoError(t, err)
+	mockUserService.On("FindUserByEmail", "new@example.com").Return(&models.User{ID: uuid.New(), Email: "new@example.com", Password: hashedPassword}, nil)
+
+	body, _ := json.Marshal(dtos.LoginDTO{Email: "new@example.com", Password: "password123"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	mockUserService.AssertExpectations(t)
+}
+
+func TestLoginWithMFA(t *testing.T) {
+	mockUserService := new(MockUserService)
+	mockRefreshTokenService := new(MockRefreshTokenService)
+	mockSessionService := new(MockSessionService)
+	mockMFAService := new(MockMFAService)
+	mockPasskeyService := new(MockPasskeyService)
+	passwordService := newTestPasswordService()
+	jwtService, _ := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	authHandler := &AuthHandler{
+		userService:         mockUserService,
+		authenticator:       services.NewPasswordAuthenticator(mockUserService, passwordService),
+		jwtService:          jwtService,
+		passwordService:     passwordService,
+		refreshTokenService: mockRefreshTokenService,
+		sessionService:      mockSessionService,
+		mfaService:          mockMFAService,
+		passkeyService:      mockPasskeyService,
+		loginAttemptService: newAllowingLoginAttemptService(),
+		mfaChallengeTTL:     5 * time.Minute,
+	}
+	router := setupUserRouter(authHandler)
+
+	hashedPassword, err := passwordService.HashPassword("password123")
+	assert.NoError(t, err)
+	testUser := &models.User{ID: uuid.New(), Email: "mfa@example.com", Password: hashedPassword}
+	mockUserService.On("FindUserByEmail", testUser.Email).Return(testUser, nil)
+	mockUserService.On("FindUserByID", testUser.ID).Return(testUser, nil)
+	mockMFAService.On("IsEnabled", testUser.ID).Return(true, nil)
+	mockPasskeyService.On("HasPasskeys", testUser.ID).Return(false, nil)
+
+	body, _ := json.Marshal(dtos.LoginDTO{Email: testUser.Email, Password: "password123"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, true, data["mfa_required"])
+	assert.Equal(t, []interface{}{"totp"}, data["mfa_methods"])
+	assert.Nil(t, data["access_token"])
+	mfaToken := data["mfa_token"].(string)
+
+	t.Run("wrong code", func(t *testing.T) {
+		mockMFAService.On("Verify", testUser.ID, "000000").Return(services.ErrInvalidMFACode).Once()
+
+		body, _ := json.Marshal(dtos.VerifyMFADTO{MFAToken: mfaToken, Code: "000000"})
+		req, _ := http.NewRequest(http.MethodPost, "/api/auth/2fa/verify", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("challenge token is not an access token", func(t *testing.T) {
+		body, _ := json.Marshal(dtos.VerifyMFADTO{MFAToken: "not-a-token", Code: "123456"})
+		req, _ := http.NewRequest(http.MethodPost, "/api/auth/2fa/verify", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("valid code", func(t *testing.T) {
+		session := &models.Session{ID: uuid.New(), UserID: testUser.ID}
+		mockMFAService.On("Verify", testUser.ID, "123456").Return(nil).Once()
+		mockSessionService.On("CreateSession", testUser.ID, mock.Anything, mock.Anything).Return(session, nil)
+		mockRefreshTokenService.On("Issue", testUser.ID, session.ID).Return("refresh-token", nil)
+
+		body, _ := json.Marshal(dtos.VerifyMFADTO{MFAToken: mfaToken, Code: "123456"})
+		req, _ := http.NewRequest(http.MethodPost, "/api/auth/2fa/verify", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		data := response["data"].(map[string]interface{})
+		assert.NotEmpty(t, data["access_token"])
+		assert.Equal(t, "refresh-token", data["refresh_token"])
+	})
+
+	mockMFAService.AssertExpectations(t)
+}
+
+func TestPasskeyLogin(t *testing.T) {
+	mockUserService := new(MockUserService)
+	mockRefreshTokenService := new(MockRefreshTokenService)
+	mockSessionService := new(MockSessionService)
+	mockMFAService := new(MockMFAService)
+	mockPasskeyService := new(MockPasskeyService)
+	passwordService := newTestPasswordService()
+	jwtService, _ := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	authHandler := &AuthHandler{
+		userService:         mockUserService,
+		authenticator:       services.NewPasswordAuthenticator(mockUserService, passwordService),
+		jwtService:          jwtService,
+		passwordService:     passwordService,
+		refreshTokenService: mockRefreshTokenService,
+		sessionService:      mockSessionService,
+		mfaService:          mockMFAService,
+		passkeyService:      mockPasskeyService,
+		loginAttemptService: newAllowingLoginAttemptService(),
+		mfaChallengeTTL:     5 * time.Minute,
+		passkeyCeremonyTTL:  5 * time.Minute,
+	}
+	router := setupUserRouter(authHandler)
+	router.POST("/api/auth/2fa/passkey/begin", authHandler.BeginPasskeyMFA)
+	router.POST("/api/auth/2fa/passkey/verify", authHandler.VerifyPasskeyMFA)
+	router.POST("/api/auth/passkeys/login/finish", authHandler.FinishPasskeyLogin)
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		encoded, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(encoded))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	hashedPassword, err := passwordService.HashPassword("password123")
+	assert.NoError(t, err)
+	testUser := &models.User{ID: uuid.New(), Email: "passkey@example.com", Password: hashedPassword}
+	mockUserService.On("FindUserByEmail", testUser.Email).Return(testUser, nil)
+	mockUserService.On("FindUserByID", testUser.ID).Return(testUser, nil)
+	mockMFAService.On("IsEnabled", testUser.ID).Return(false, nil)
+	mockPasskeyService.On("HasPasskeys", testUser.ID).Return(true, nil)
+	session := &models.Session{ID: uuid.New(), UserID: testUser.ID}
+	mockSessionService.On("CreateSession", testUser.ID, mock.Anything, mock.Anything).Return(session, nil)
+	mockRefreshTokenService.On("Issue", testUser.ID, session.ID).Return("refresh-token", nil)
+
+	// A passkey is a second factor for password logins.
+	resp := post("/api/auth/login", dtos.LoginDTO{Email: testUser.Email, Password: "password123"})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"passkey"}, data["mfa_methods"])
+	assert.Nil(t, data["access_token"])
+	mfaToken := data["mfa_token"].(string)
+
+	mockPasskeyService.On("BeginSecondFactor", testUser.ID).Return(&protocol.CredentialAssertion{}, "ceremony", nil).Once()
+	resp = post("/api/auth/2fa/passkey/begin", dtos.BeginPasskeyMFADTO{MFAToken: mfaToken})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"ceremony":"ceremony"`)
+
+	credential := json.RawMessage(`{"id":"abc"}`)
+	mockPasskeyService.On("FinishSecondFactor", testUser.ID, "ceremony", []byte(credential)).Return(fmt.Errorf("%w: bad signature", services.ErrPasskeyRejected)).Once()
+	resp = post("/api/auth/2fa/passkey/verify", dtos.VerifyPasskeyMFADTO{MFAToken: mfaToken, Ceremony: "ceremony", Credential: credential})
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Contains(t, resp.Body.String(), "PASSKEY_REJECTED")
+
+	mockPasskeyService.On("FinishSecondFactor", testUser.ID, "ceremony", []byte(credential)).Return(nil).Once()
+	resp = post("/api/auth/2fa/passkey/verify", dtos.VerifyPasskeyMFADTO{MFAToken: mfaToken, Ceremony: "ceremony", Credential: credential})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"refresh_token":"refresh-token"`)
+
+	// Passwordless, the passkey is both factors.
+	mockPasskeyService.On("FinishLogin", "login-ceremony", []byte(credential)).Return(testUser, nil).Once()
+	resp = post("/api/auth/passkeys/login/finish", dtos.FinishPasskeyLoginDTO{Ceremony: "login-ceremony", Credential: credential})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"refresh_token":"refresh-token"`)
+
+	deactivatedAt := time.Now()
+	deactivated := &models.User{ID: uuid.New(), Email: "gone@example.com", DeactivatedAt: &deactivatedAt}
+	mockPasskeyService.On("FinishLogin", "login-ceremony", []byte(credential)).Return(deactivated, nil).Once()
+	resp = post("/api/auth/passkeys/login/finish", dtos.FinishPasskeyLoginDTO{Ceremony: "login-ceremony", Credential: credential})
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	mockPasskeyService.AssertExpectations(t)
+}
+
+func TestReauthenticateWithoutPassword(t *testing.T) {
+	mockUserService := new(MockUserService)
+	mockMFAService := new(MockMFAService)
+	mockPasskeyService := new(MockPasskeyService)
+	passwordService := newTestPasswordService()
+	authHandler := &AuthHandler{
+		userService:        mockUserService,
+		passwordService:    passwordService,
+		passwordPolicy:     newTestPasswordPolicy(),
+		mfaService:         mockMFAService,
+		passkeyService:     mockPasskeyService,
+		passkeyCeremonyTTL: 5 * time.Minute,
+	}
+
+	hashedPassword, err := passwordService.HashPassword("password123")
+	assert.NoError(t, err)
+	ssoUser := &models.User{ID: uuid.New(), Email: "sso@example.com"}
+	passwordUser := &models.User{ID: uuid.New(), Email: "local@example.com", Password: hashedPassword}
+	mockUserService.On("FindUserByID", ssoUser.ID).Return(ssoUser, nil)
+	mockUserService.On("FindUserByID", passwordUser.ID).Return(passwordUser, nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User"))
+	})
+	router.POST("/api/auth/password/change", authHandler.ChangePassword)
+	router.POST("/api/auth/2fa/disable", authHandler.DisableMFA)
+	router.POST("/api/auth/passkeys/reauth/begin", authHandler.BeginPasskeyReauthentication)
+	post := func(user *models.User, path string, body interface{}) *httptest.ResponseRecorder {
+		encoded, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(encoded))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user.ID.String())
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	newPassword := "correct horse battery staple"
+
+	t.Run("change password needs a second factor", func(t *testing.T) {
+		resp := post(ssoUser, "/api/auth/password/change", dtos.ChangePasswordDTO{NewPassword: newPassword})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "REAUTHENTICATION_REQUIRED")
+	})
+
+	t.Run("change password with a code", func(t *testing.T) {
+		mockMFAService.On("Verify", ssoUser.ID, "000000").Return(services.ErrInvalidMFACode).Once()
+		resp := post(ssoUser, "/api/auth/password/change", dtos.ChangePasswordDTO{NewPassword: newPassword, Code: "000000"})
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+		mockMFAService.On("Verify", ssoUser.ID, "123456").Return(nil).Once()
+		mockUserService.On("UpdatePassword", ssoUser.ID, mock.AnythingOfType("string")).Return(nil).Once()
+		resp = post(ssoUser, "/api/auth/password/change", dtos.ChangePasswordDTO{NewPassword: newPassword, Code: "123456"})
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("change password with a passkey", func(t *testing.T) {
+		mockPasskeyService.On("BeginSecondFactor", ssoUser.ID).Return(&protocol.CredentialAssertion{}, "ceremony", nil).Once()
+		resp := post(ssoUser, "/api/auth/passkeys/reauth/begin", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"ceremony":"ceremony"`)
+
+		credential := json.RawMessage(`{"id":"abc"}`)
+		mockPasskeyService.On("FinishSecondFactor", ssoUser.ID, "ceremony", []byte(credential)).Return(nil).Once()
+		mockUserService.On("UpdatePassword", ssoUser.ID, mock.AnythingOfType("string")).Return(nil).Once()
+		resp = post(ssoUser, "/api/auth/password/change", dtos.ChangePasswordDTO{NewPassword: newPassword, PasskeyCeremony: "ceremony", PasskeyCredential: credential})
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("a code does not stand in for an existing password", func(t *testing.T) {
+		resp := post(passwordUser, "/api/auth/password/change", dtos.ChangePasswordDTO{NewPassword: newPassword, Code: "123456"})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "INCORRECT_PASSWORD")
+
+		resp = post(passwordUser, "/api/auth/2fa/disable", dtos.DisableMFADTO{Code: "123456"})
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("disable 2FA with the code alone", func(t *testing.T) {
+		mockMFAService.On("Verify", ssoUser.ID, "123456").Return(nil).Once()
+		mockMFAService.On("Disable", ssoUser.ID).Return(nil).Once()
+		resp := post(ssoUser, "/api/auth/2fa/disable", dtos.DisableMFADTO{Code: "123456"})
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	mockMFAService.AssertExpectations(t)
+	mockPasskeyService.AssertExpectations(t)
+	mockUserService.AssertExpectations(t)
+}
+
+func TestLoginLockout(t *testing.T) {
+	mockUserService := new(MockUserService)
+	mockLoginAttemptService := new(MockLoginAttemptService)
+	authHandler := &AuthHandler{
+		userService:         mockUserService,
+		authenticator:       services.NewPasswordAuthenticator(mockUserService, newTestPasswordService()),
+		loginAttemptService: mockLoginAttemptService,
+	}
+	router := setupUserRouter(authHandler)
+
+	login := func(email string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dtos.LoginDTO{Email: email, Password: "wrong-password"})
+		req, _ := http.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("failures are recorded", func(t *testing.T) {
+		mockLoginAttemptService.On("Check", "nobody@example.com", mock.Anything).Return(time.Duration(0), nil).Once()
+		mockUserService.On("FindUserByEmail", "nobody@example.com").Return(nil, errors.New("record not found")).Once()
+		mockLoginAttemptService.On("RecordFailure", "nobody@example.com", mock.Anything).Return(nil).Once()
+
+		resp := login("nobody@example.com")
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		mockLoginAttemptService.AssertExpectations(t)
+	})
+
+	t.Run("locked out logins are refused before the password is checked", func(t *testing.T) {
+		mockLoginAttemptService.On("Check", "locked@example.com", mock.Anything).Return(90*time.Second+time.Millisecond, nil).Once()
+
+		resp := login("locked@example.com")
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "91", resp.Header().Get("Retry-After"))
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.Equal(t, "ACCOUNT_LOCKED", response["error"].(map[string]interface{})["code"])
+		mockUserService.AssertNotCalled(t, "FindUserByEmail", "locked@example.com")
+	})
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	mockUserService := new(MockUserService)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	verifiedAt := time.Now()
+	verified := &models.User{ID: uuid.New(), EmailVerifiedAt: &verifiedAt}
+	unverified := &models.User{ID: uuid.New()}
+	mockUserService.On("FindUserByID", verified.ID).Return(verified, nil)
+	mockUserService.On("FindUserByID", unverified.ID).Return(unverified, nil)
+
+	router.POST("/api/tasks/:user", func(c *gin.Context) {
+		c.Set("user_id", c.Param("user"))
+	}, middleware.RequireVerifiedEmail(mockUserService), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	for user, status := range map[*models.User]int{verified: http.StatusCreated, unverified: http.StatusForbidden} {
+		req, _ := http.NewRequest(http.MethodPost, "/api/tasks/"+user.ID.String(), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, status, resp.Code)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	jwtService, err := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	assert.NoError(t, err)
+
+	mockRefreshTokenService := new(MockRefreshTokenService)
+	authHandler := &AuthHandler{
+		jwtService:          jwtService,
+		refreshTokenService: mockRefreshTokenService,
+	}
+	router := setupUserRouter(authHandler)
+
+	userID := uuid.New()
+	mockRefreshTokenService.On("Rotate", "current").Return("next", &models.RefreshToken{UserID: userID, FamilyID: uuid.New()}, nil)
+	mockRefreshTokenService.On("Rotate", "replayed").Return("", nil, services.ErrRefreshTokenReused)
+
+	body, _ := json.Marshal(dtos.RefreshTokenDTO{RefreshToken: "current"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, "next", data["refresh_token"])
+	assert.NotEmpty(t, data["access_token"])
+
+	body, _ = json.Marshal(dtos.RefreshTokenDTO{RefreshToken: "replayed"})
+	req, _ = http.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	mockRefreshTokenService.AssertExpectations(t)
+}
+
+func TestLogout(t *testing.T) {
+	jwtService, err := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	assert.NoError(t, err)
+
+	mockSessionService := new(MockSessionService)
+	mockUserService := new(MockUserService)
+	revocationStore := revocationRepository.NewMemoryRevocationStore()
+	authHandler := &AuthHandler{
+		jwtService:      jwtService,
+		sessionService:  mockSessionService,
+		revocationStore: revocationStore,
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	authMiddleware := middleware.AuthMiddleware(jwtService, revocationStore, mockSessionService, mockUserService, new(MockAuditService))
+	router.POST("/api/auth/logout", authMiddleware, authHandler.Logout)
+	router.POST("/api/auth/logout-all", authMiddleware, authHandler.LogoutAll)
+
+	userID := uuid.New()
+	sessionID, otherSessionID := uuid.New(), uuid.New()
+	mockUserService.On("FindUserByID", userID).Return(&models.User{ID: userID}, nil)
+	mockSessionService.On("ValidateSession", mock.Anything, userID).Return(nil)
+	mockSessionService.On("RevokeSession", sessionID, userID).Return(nil)
+	mockSessionService.On("RevokeAllSessions", userID).Return(nil)
+
+	logout := func(path, token string) int {
+		req, _ := http.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	token, err := jwtService.GenerateToken(userID, sessionID)
+	assert.NoError(t, err)
+	other, err := jwtService.GenerateToken(userID, otherSessionID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, logout("/api/auth/logout", token))
+	assert.Equal(t, http.StatusUnauthorized, logout("/api/auth/logout", token))
+
+	// Tokens issued to the user before logout-all stop working, the one used
+	// for the request included.
+	assert.Equal(t, http.StatusOK, logout("/api/auth/logout-all", other))
+	assert.Equal(t, http.StatusUnauthorized, logout("/api/auth/logout-all", other))
+
+	mockSessionService.AssertExpectations(t)
+}
+
+func TestRevokedSessionIsRejected(t *testing.T) {
+	jwtService, err := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	assert.NoError(t, err)
+
+	mockSessionService := new(MockSessionService)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/tasks", middleware.AuthMiddleware(jwtService, revocationRepository.NewMemoryRevocationStore(), mockSessionService, new(MockUserService), new(MockAuditService)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	userID, sessionID := uuid.New(), uuid.New()
+	mockSessionService.On("ValidateSession", sessionID, userID).Return(services.ErrSessionRevoked)
+
+	token, err := jwtService.GenerateToken(userID, sessionID)
+	assert.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/api/tasks", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	mockSessionService.AssertExpectations(t)
+}
+
+func TestAuthMiddlewareErrors(t *testing.T) {
+	jwtService, err := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	assert.NoError(t, err)
+	expiredOptions := testJWTOptions
+	expiredOptions.AccessTokenTTL = time.Nanosecond
+	expiringService, err := auth.NewJWTService(newTestKeySet(), expiredOptions)
+	assert.NoError(t, err)
 
+	mockSessionService := new(MockSessionService)
+	mockUserService := new(MockUserService)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/tasks", middleware.AuthMiddleware(jwtService, revocationRepository.NewMemoryRevocationStore(), mockSessionService, mockUserService, new(MockAuditService)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	deletedUser, sessionID := uuid.New(), uuid.New()
+	mockSessionService.On("ValidateSession", sessionID, deletedUser).Return(nil)
+	mockUserService.On("FindUserByID", deletedUser).Return(nil, gorm.ErrRecordNotFound)
+
+	deleted, _ := jwtService.GenerateToken(deletedUser, sessionID)
+	expired, _ := expiringService.GenerateToken(uuid.New(), uuid.New())
+	time.Sleep(time.Second)
+
+	for token, code := range map[string]string{
+		deleted:     "ACCOUNT_NOT_FOUND",
+		expired:     "TOKEN_EXPIRED",
+		"not-a-jwt": "TOKEN_MALFORMED",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/api/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.Equal(t, code, response["error"].(map[string]interface{})["code"])
+	}
+
+	deactivatedUser, deactivatedAt := uuid.New(), time.Now()
+	mockSessionService.On("ValidateSession", sessionID, deactivatedUser).Return(nil)
+	mockUserService.On("FindUserByID", deactivatedUser).Return(&models.User{ID: deactivatedUser, DeactivatedAt: &deactivatedAt}, nil)
+	deactivated, _ := jwtService.GenerateToken(deactivatedUser, sessionID)
+	req, _ := http.NewRequest(http.MethodGet, "/api/tasks", nil)
+	req.Header.Set("Authorization", "Bearer "+deactivated)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Contains(t, resp.Body.String(), "ACCOUNT_DEACTIVATED")
+
+	unreachableUser := uuid.New()
+	mockSessionService.On("ValidateSession", sessionID, unreachableUser).Return(nil)
+	mockUserService.On("FindUserByID", unreachableUser).Return(nil, errors.New("database is down"))
+	unreachable, _ := jwtService.GenerateToken(unreachableUser, sessionID)
+	req, _ = http.NewRequest(http.MethodGet, "/api/tasks", nil)
+	req.Header.Set("Authorization", "Bearer "+unreachable)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Contains(t, resp.Body.String(), "USER_LOOKUP_FAILED")
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	mockAPIKeyService := new(MockAPIKeyService)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	jwtOnly := func(c *gin.Context) {
+		c.Set("user_id", "jwt-user")
+		c.Next()
+	}
+	mockUserService := new(MockUserService)
+	router.Use(middleware.AcceptAPIKeys(mockAPIKeyService, mockUserService, jwtOnly))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("user_id")) }
+	router.GET("/api/tasks", middleware.RequireScope(models.ScopeTasksRead), ok)
+	router.POST("/api/tasks", middleware.RequireScope(models.ScopeTasksWrite), ok)
+
+	readOnly := &models.APIKey{ID: uuid.New(), UserID: uuid.New(), Scopes: models.StringList{models.ScopeTasksRead}}
+	mockAPIKeyService.On("Authenticate", "todo_readonly").Return(readOnly, nil)
+	mockAPIKeyService.On("Authenticate", "todo_revoked").Return(nil, services.ErrInvalidAPIKey)
+	mockAPIKeyService.On("Authenticate", "todo_unreachable").Return(nil, errors.New("database is down"))
+	deactivatedAt := time.Now()
+	ofDeactivated := &models.APIKey{ID: uuid.New(), UserID: uuid.New(), Scopes: models.StringList{models.ScopeTasksRead}}
+	mockAPIKeyService.On("Authenticate", "todo_deactivated").Return(ofDeactivated, nil)
+	mockUserService.On("FindUserByID", readOnly.UserID).Return(&models.User{ID: readOnly.UserID}, nil)
+	mockUserService.On("FindUserByID", ofDeactivated.UserID).Return(&models.User{ID: ofDeactivated.UserID, DeactivatedAt: &deactivatedAt}, nil)
+
+	tests := []struct {
+		method, token string
+		status        int
+		user          string
+	}{
+		{http.MethodGet, "todo_readonly", http.StatusOK, readOnly.UserID.String()},
+		{http.MethodPost, "todo_readonly", http.StatusForbidden, ""},
+		{http.MethodGet, "todo_revoked", http.StatusUnauthorized, ""},
+		{http.MethodGet, "todo_deactivated", http.StatusForbidden, ""},
+		{http.MethodGet, "todo_unreachable", http.StatusInternalServerError, ""},
+		{http.MethodPost, "a.jwt.token", http.StatusOK, "jwt-user"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, "/api/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, tt.status, resp.Code, "%s with %s", tt.method, tt.token)
+		if tt.status == http.StatusOK {
+			assert.Equal(t, tt.user, resp.Body.String())
+		}
+	}
+}
+
+func TestForgotPasswordAlwaysAccepts(t *testing.T) {
+	mockPasswordResetService := new(MockPasswordResetService)
+	authHandler := &AuthHandler{passwordResetService: mockPasswordResetService}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/auth/password/forgot", authHandler.ForgotPassword)
+
+	mockPasswordResetService.On("RequestReset", "known@example.com").Return(nil)
+	mockPasswordResetService.On("RequestReset", "broken@example.com").Return(errors.New("smtp unavailable"))
+
+	for _, email := range []string{"known@example.com", "broken@example.com"} {
+		body, _ := json.Marshal(dtos.ForgotPasswordDTO{Email: email})
+		req, _ := http.NewRequest(http.MethodPost, "/api/auth/password/forgot", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusAccepted, resp.Code, email)
+	}
+
+	mockPasswordResetService.AssertExpectations(t)
+}
+
+func TestResetPasswordWithInvalidToken(t *testing.T) {
+	mockPasswordResetService := new(MockPasswordResetService)
+	authHandler := &AuthHandler{passwordResetService: mockPasswordResetService}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/auth/password/reset", authHandler.ResetPassword)
+
+	mockPasswordResetService.On("ResetPassword", "used-token", "new-password").Return(services.ErrInvalidOneTimeToken)
+
+	body, _ := json.Marshal(dtos.ResetPasswordDTO{Token: "used-token", Password: "new-password"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/auth/password/reset", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	mockPasswordResetService.AssertExpectations(t)
+}
+
+func TestMagicLinkIsBoundToDevice(t *testing.T) {
+	mockMagicLinkService := new(MockMagicLinkService)
+	mockMFAService := new(MockMFAService)
+	mockPasskeyService := new(MockPasskeyService)
+	jwtService, _ := auth.NewJWTService(newTestKeySet(), testJWTOptions)
+	authHandler := &AuthHandler{
+		jwtService:       jwtService,
+		mfaService:       mockMFAService,
+		passkeyService:   mockPasskeyService,
+		magicLinkService: mockMagicLinkService,
+		magicLinkTTL:     15 * time.Minute,
+		bindMagicLinks:   true,
+		mfaChallengeTTL:  5 * time.Minute,
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/auth/magic-link", authHandler.RequestMagicLink)
+	router.POST("/api/auth/magic-link/consume", authHandler.ConsumeMagicLink)
+	post := func(path string, body interface{}, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		encoded, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(encoded))
+		req.Header.Set("Content-Type", "application/json")
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var deviceHash string
+	mockMagicLinkService.On("RequestLink", mock.Anything, "jane@example.com", mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { deviceHash = args.String(2) }).Return(time.Duration(0), nil).Once()
+	resp := post("/api/auth/magic-link", dtos.MagicLinkRequestDTO{Email: "jane@example.com"})
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	cookies := resp.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, magicLinkDeviceCookie, cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, auth.HashOpaqueToken(cookies[0].Value), deviceHash, "only the secret's hash reaches the service")
+
+	mockMagicLinkService.On("RequestLink", mock.Anything, "jane@example.com", mock.AnythingOfType("string")).Return(10*time.Minute, nil).Once()
+	resp = post("/api/auth/magic-link", dtos.MagicLinkRequestDTO{Email: "jane@example.com"})
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "600", resp.Header().Get("Retry-After"))
+
+	// Opened elsewhere, the cookie is missing.
+	mockMagicLinkService.On("ConsumeLink", "link-token", "").Return(nil, services.ErrMagicLinkWrongDevice).Once()
+	resp = post("/api/auth/magic-link/consume", dtos.MagicLinkConsumeDTO{Token: "link-token"})
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Contains(t, resp.Body.String(), "MAGIC_LINK_WRONG_DEVICE")
+
+	// The link replaces the password only; two-factor users are challenged.
+	jane := &models.User{ID: uuid.New(), Email: "jane@example.com"}
+	mockMagicLinkService.On("ConsumeLink", "link-token", deviceHash).Return(jane, nil).Once()
+	mockMFAService.On("IsEnabled", jane.ID).Return(true, nil)
+	mockPasskeyService.On("HasPasskeys", jane.ID).Return(false, nil)
+	resp = post("/api/auth/magic-link/consume", dtos.MagicLinkConsumeDTO{Token: "link-token"}, cookies[0])
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"mfa_required":true`)
+
+	mockMagicLinkService.AssertExpectations(t)
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	// The state is checked against the cookie before the service is asked.
+	authHandler := &AuthHandler{}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/auth/oidc/:provider/callback", authHandler.OIDCCallback)
+
+	callback := func(cookie string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/api/auth/oidc/corp/callback?code=abc&state=victim-state", nil)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: cookie})
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	for _, cookie := range []string{"", "attacker-state"} {
+		resp := callback(cookie)
+		assert.Equal(t, http.StatusBadRequest, resp.Code, cookie)
+		assert.Contains(t, resp.Body.String(), "INVALID_OIDC_STATE")
+		assert.Contains(t, resp.Header().Get("Set-Cookie"), oidcStateCookie+"=;", "the state cookie is cleared")
+	}
+}
+
+const testSCIMToken = "scim-token-0123456789abcdef0123456789"
+
+func newSCIMRouter(userService services.UserService) *gin.Engine {
+	scimHandler := &SCIMHandler{userService: userService, baseURL: "https://todo.example.com"}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	users := router.Group("/scim/v2/Users", middleware.SCIMAuth(testSCIMToken))
+	users.POST("", scimHandler.CreateUser)
+	users.GET("", scimHandler.ListUsers)
+	users.PATCH("/:id", scimHandler.PatchUser)
+	users.DELETE("/:id", scimHandler.DeleteUser)
+	return router
+}
+
+func scimRequest(router *gin.Engine, method, path, token, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/scim+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestSCIMRequiresToken(t *testing.T) {
+	router := newSCIMRouter(new(MockUserService))
+
+	resp := scimRequest(router, http.MethodGet, "/scim/v2/Users", "not-the-token", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Contains(t, resp.Header().Get("Content-Type"), "application/scim+json")
+	assert.NotEmpty(t, resp.Header().Get("WWW-Authenticate"))
+}
+
+func TestSCIMCreateUser(t *testing.T) {
+	mockUserService := new(MockUserService)
+	router := newSCIMRouter(mockUserService)
+
+	mockUserService.On("FindUserByEmail", "jane@example.com").Return(nil, gorm.ErrRecordNotFound).Once()
+	mockUserService.On("CreateUser", mock.MatchedBy(func(user *models.User) bool {
+		return user.Email == "jane@example.com" && user.ExternalID == "00u1" && user.Password == "" &&
+			user.EmailVerifiedAt != nil && user.DeactivatedAt == nil
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.User).ID = uuid.New()
+	}).Return(nil).Once()
+
+	body := `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"jane@example.com","externalId":"00u1",
+		"name":{"givenName":"Jane","familyName":"Doe"},"password":"ignored","active":true}`
+	resp := scimRequest(router, http.MethodPost, "/scim/v2/Users", testSCIMToken, body)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+
+	var created dtos.SCIMUserDTO
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	assert.Equal(t, "jane@example.com", created.UserName)
+	assert.True(t, *created.Active)
+	assert.Equal(t, "https://todo.example.com/scim/v2/Users/"+created.ID, resp.Header().Get("Location"))
+
+	// A second push of the same user is a conflict.
+	mockUserService.On("FindUserByEmail", "jane@example.com").Return(&models.User{ID: uuid.New()}, nil).Once()
+	resp = scimRequest(router, http.MethodPost, "/scim/v2/Users", testSCIMToken, body)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"scimType":"uniqueness"`)
+
+	// So is losing the race to create the user.
+	mockUserService.On("FindUserByEmail", "jane@example.com").Return(nil, gorm.ErrRecordNotFound).Once()
+	mockUserService.On("CreateUser", mock.Anything).Return(gorm.ErrDuplicatedKey).Once()
+	resp = scimRequest(router, http.MethodPost, "/scim/v2/Users", testSCIMToken, body)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+
+	// A user the client deleted earlier is brought back.
+	now := time.Now()
+	deleted := &models.User{ID: uuid.New(), Email: "jane@example.com", DeactivatedAt: &now, DeprovisionedAt: &now}
+	restored := &models.User{ID: deleted.ID, Email: "jane@example.com", FirstName: "Jane", LastName: "Doe", ExternalID: "00u1"}
+	mockUserService.On("FindUserByEmail", "jane@example.com").Return(deleted, nil).Once()
+	mockUserService.On("UpdateUser", deleted.ID, mock.MatchedBy(func(updates map[string]interface{}) bool {
+		return updates["external_id"] == "00u1" && updates["deactivated_at"] == nil && updates["deprovisioned_at"] == nil
+	})).Return(nil).Once()
+	mockUserService.On("FindUserByID", deleted.ID).Return(restored, nil).Once()
+	resp = scimRequest(router, http.MethodPost, "/scim/v2/Users", testSCIMToken, body)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	assert.Equal(t, deleted.ID.String(), created.ID)
+	assert.True(t, *created.Active)
+
+	resp = scimRequest(router, http.MethodPost, "/scim/v2/Users", testSCIMToken, `{"userName":"jane"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	mockUserService.AssertExpectations(t)
+}
+
+func TestSCIMListUsersFilter(t *testing.T) {
+	mockUserService := new(MockUserService)
+	router := newSCIMRouter(mockUserService)
+
+	active := true
+	jane := models.User{ID: uuid.New(), Email: "jane@example.com"}
+	mockUserService.On("ListUsers", services.UserListOptions{Email: "jane@example.com", Active: &active, Offset: 10, Limit: 5, ExcludeDeprovisioned: true}).
+		Return([]models.User{jane}, int64(11), nil).Once()
+
+	filter := url.QueryEscape(`userName eq "jane@example.com" and active eq true`)
+	resp := scimRequest(router, http.MethodGet, "/scim/v2/Users?startIndex=11&count=5&filter="+filter, testSCIMToken, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var list dtos.SCIMListResponseDTO
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	assert.Equal(t, int64(11), list.TotalResults)
+	assert.Equal(t, 11, list.StartIndex)
+	assert.Equal(t, jane.ID.String(), list.Resources[0].ID)
+
+	for _, filter := range []string{`name.givenName eq "Jane"`, `userName co "jane"`, `userName eq "jane@example.com" and`} {
+		resp = scimRequest(router, http.MethodGet, "/scim/v2/Users?filter="+url.QueryEscape(filter), testSCIMToken, "")
+		assert.Equal(t, http.StatusBadRequest, resp.Code, filter)
+		assert.Contains(t, resp.Body.String(), "invalidFilter", filter)
+	}
+	mockUserService.AssertExpectations(t)
+}
+
+func TestSCIMPatchUser(t *testing.T) {
+	mockUserService := new(MockUserService)
+	router := newSCIMRouter(mockUserService)
+
+	jane := &models.User{ID: uuid.New(), FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	path := "/scim/v2/Users/" + jane.ID.String()
+	mockUserService.On("FindUserByID", jane.ID).Return(jane, nil)
+
+	// Entra ID sends the value as a string and the path-less form for names.
+	mockUserService.On("UpdateUser", jane.ID, map[string]interface{}{"last_name": "Smith", "external_id": "00u1"}).Return(nil).Once()
+	mockUserService.On("DeactivateUser", jane.ID).Return(nil).Once()
+	body := `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[
+		{"op":"Replace","path":"active","value":"False"},
+		{"op":"replace","value":{"name.familyName":"Smith","externalId":"00u1","title":"Engineer"}}]}`
+	resp := scimRequest(router, http.MethodPatch, path, testSCIMToken, body)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// Taking an address another user has is a conflict.
+	mockUserService.On("FindUserByEmail", "john@example.com").Return(&models.User{ID: uuid.New()}, nil).Once()
+	body = `{"Operations":[{"op":"replace","path":"emails[type eq \"work\"].value","value":"john@example.com"}]}`
+	resp = scimRequest(router, http.MethodPatch, path, testSCIMToken, body)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+
+	body = `{"Operations":[{"op":"remove","path":"userName"}]}`
+	resp = scimRequest(router, http.MethodPatch, path, testSCIMToken, body)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	// DELETE deactivates too, and the user is gone as far as SCIM can tell.
+	mockUserService.On("DeprovisionUser", jane.ID).Return(nil).Once()
+	resp = scimRequest(router, http.MethodDelete, path, testSCIMToken, "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+
+	now := time.Now()
+	jane.DeactivatedAt, jane.DeprovisionedAt = &now, &now
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+		resp = scimRequest(router, method, path, testSCIMToken, `{"Operations":[{"op":"replace","path":"active","value":true}]}`)
+		assert.Equal(t, http.StatusNotFound, resp.Code, method)
+	}
+
+	resp = scimRequest(router, http.MethodDelete, "/scim/v2/Users/not-a-uuid", testSCIMToken, "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
 	mockUserService.AssertExpectations(t)
 }
 
@@ -247,7 +1537,7 @@ func TestGetTasks(t *testing.T) {
 		},
 	}
 
-	mockTaskService.On("GetTasksByUserID", userID).Return(tasks, nil)
+	mockTaskService.On("ListTasks", userID, services.TaskListOptions{}).Return(tasks, nil)
 
 	req, _ := http.NewRequest(http.MethodGet, "/api/tasks", nil)
 	req.Header.Set("Content-Type", "application/json")
@@ -306,10 +1596,11 @@ func TestUpdateTask(t *testing.T) {
 		}
 	}).Return(nil)
 
+	done := true
 	updateTaskDTO := dtos.UpdateTaskDTO{
 		Title:       "Updated Task",
 		Description: "Updated Description",
-		Status:      true,
+		Status:      &done,
 	}
 
 	body, _ := json.Marshal(updateTaskDTO)
@@ -358,7 +1649,7 @@ func TestDeleteTask(t *testing.T) {
 	}
 
 	mockTaskService.On("GetTaskByID", taskID).Return(existingTask, nil)
-	mockTaskService.On("DeleteTask", taskID, userID).Return(nil)
+	mockTaskService.On("DeleteTask", mock.Anything, taskID, userID).Return(nil)
 
 	req, _ := http.NewRequest(http.MethodDelete, "/api/tasks/"+taskID.String(), nil)
 	req.Header.Set("Content-Type", "application/json")
@@ -376,3 +1667,36 @@ func TestDeleteTask(t *testing.T) {
 
 	mockTaskService.AssertExpectations(t)
 }
+
+func TestGetTasksWithCustomFieldFilter(t *testing.T) {
+	mockTaskService := new(MockTaskService)
+	taskHandler := NewTaskHandler(mockTaskService, config.AppConfig{})
+
+	router := gin.Default()
+
+	userID := uuid.New()
+	projectID := uuid.New()
+
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Next()
+	})
+
+	router.GET("/api/tasks", taskHandler.GetTasks)
+
+	options := services.TaskListOptions{
+		ProjectID:    &projectID,
+		CustomFields: map[string]string{"customer": "Acme", "points": "3..8"},
+		SortBy:       "cf.points",
+		Desc:         true,
+	}
+	mockTaskService.On("ListTasks", userID, options).Return([]models.Task{}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/tasks?project_id="+projectID.String()+"&cf.customer=Acme&cf.points=3..8&sort=cf.points&order=desc", nil)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	mockTaskService.AssertExpectations(t)
+}
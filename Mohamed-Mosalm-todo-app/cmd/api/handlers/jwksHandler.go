@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set. Keys scheduled for
+// rotation are published before they sign, so caches pick them up in time
+// as long as the schedule leaves more than this much notice.
+const jwksMaxAge = "max-age=300"
+
+type JWKSHandler struct {
+	keys *auth.KeySet
+}
+
+func NewJWKSHandler(keys *auth.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS serves the public signing keys in the standard JWKS format rather
+// than the usual response envelope, so JWT libraries can consume it as is.
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", jwksMaxAge)
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// magicLinkDeviceCookie holds a secret whose hash the emailed link is bound
+// to, so that a link forwarded or intercepted elsewhere does not sign in.
+const (
+	magicLinkDeviceCookie     = "magic_link_device"
+	magicLinkDeviceCookiePath = "/api/auth/magic-link"
+)
+
+// RequestMagicLink answers 202 Accepted whether or not the email belongs to
+// an account, like ForgotPassword.
+func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
+	var requestDTO dtos.MagicLinkRequestDTO
+
+	if err := c.ShouldBindJSON(&requestDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	var deviceSecret, deviceHash string
+	if h.bindMagicLinks {
+		var err error
+		if deviceSecret, deviceHash, err = auth.GenerateOpaqueToken(); err != nil {
+			appErr := errors.ErrTokenGenerationFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+			return
+		}
+	}
+
+	retryAfter, err := h.magicLinkService.RequestLink(c.Request.Context(), requestDTO.Email, deviceHash)
+	if err != nil {
+		log.Printf("magic link request failed: %v", err)
+	}
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		httputil.HandleError(c, errors.ErrTooManyMagicLinks)
+		return
+	}
+
+	// The cookie is set for unknown addresses too, so it gives nothing away.
+	if h.bindMagicLinks {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(magicLinkDeviceCookie, deviceSecret, int(h.magicLinkTTL.Seconds()), magicLinkDeviceCookiePath, "", h.secureCookies, true)
+	}
+	httputil.SendSuccess(c, http.StatusAccepted, "If the email belongs to an account, a sign-in link is on its way", nil)
+}
+
+// ConsumeMagicLink exchanges an emailed link for tokens. It responds like
+// Login, so users with two-factor authentication still get a challenge.
+func (h *AuthHandler) ConsumeMagicLink(c *gin.Context) {
+	var consumeDTO dtos.MagicLinkConsumeDTO
+
+	if err := c.ShouldBindJSON(&consumeDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	var deviceHash string
+	if deviceSecret, err := c.Cookie(magicLinkDeviceCookie); err == nil {
+		deviceHash = auth.HashOpaqueToken(deviceSecret)
+	}
+
+	user, err := h.magicLinkService.ConsumeLink(consumeDTO.Token, deviceHash)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidOneTimeToken:
+			httputil.HandleError(c, errors.ErrInvalidMagicLink)
+		case services.ErrMagicLinkWrongDevice:
+			httputil.HandleError(c, errors.ErrMagicLinkWrongDevice)
+		default:
+			appErr := errors.ErrMagicLinkFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+		}
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(magicLinkDeviceCookie, "", -1, magicLinkDeviceCookiePath, "", h.secureCookies, true)
+	h.signIn(c, user)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// EnrollMFA starts TOTP enrolment. The secret stays inactive until
+// ConfirmMFA proves the authenticator app produces valid codes.
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	user, err := h.userService.FindUserByID(userID)
+	if err != nil {
+		appErr := errors.ErrUserNotFound
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	secret, uri, err := h.mfaService.Enroll(user)
+	if err != nil {
+		if err == services.ErrMFAAlreadyEnabled {
+			httputil.HandleError(c, errors.ErrMFAAlreadyEnabled)
+			return
+		}
+		appErr := errors.ErrMFAFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Scan the code with your authenticator app, then confirm it", gin.H{
+		"secret":      secret,
+		"otpauth_uri": uri,
+	})
+}
+
+// ConfirmMFA enables 2FA and returns the recovery codes. They are only
+// stored hashed, so this is the one time they can be shown.
+func (h *AuthHandler) ConfirmMFA(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	var confirmDTO dtos.ConfirmMFADTO
+	if err := c.ShouldBindJSON(&confirmDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	recoveryCodes, err := h.mfaService.Confirm(userID, confirmDTO.Code)
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Two-factor authentication enabled", gin.H{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	var disableDTO dtos.DisableMFADTO
+	if err := c.ShouldBindJSON(&disableDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	user, err := h.userService.FindUserByID(userID)
+	if err != nil {
+		appErr := errors.ErrUserNotFound
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	// Accounts without a password, such as SSO and magic-link ones, are
+	// confirmed by the code alone.
+	if user.Password != "" {
+		if err := h.passwordService.ComparePasswords(user.Password, disableDTO.Password); err != nil {
+			httputil.HandleError(c, errors.ErrInvalidCredentials)
+			return
+		}
+	}
+
+	if err := h.mfaService.Verify(userID, disableDTO.Code); err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	if err := h.mfaService.Disable(userID); err != nil {
+		appErr := errors.ErrMFAFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// VerifyMFA exchanges the challenge token from Login and a TOTP or
+// recovery code for the session's tokens.
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var verifyDTO dtos.VerifyMFADTO
+	if err := c.ShouldBindJSON(&verifyDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	userID, err := h.jwtService.ParseMFAToken(verifyDTO.MFAToken)
+	if err != nil {
+		appErr := errors.ErrInvalidMFAToken
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	user, err := h.userService.FindUserByID(userID)
+	if err != nil {
+		httputil.HandleError(c, errors.ErrInvalidMFAToken)
+		return
+	}
+	// The account may have been deactivated since the password was checked.
+	if !user.IsActive() {
+		httputil.HandleError(c, errors.ErrAccountDeactivated)
+		return
+	}
+
+	// Codes are guessable too, so they share the password's lockout.
+	if !h.checkLoginAttempts(c, user.Email) {
+		return
+	}
+
+	if err := h.mfaService.Verify(userID, verifyDTO.Code); err != nil {
+		if err == services.ErrInvalidMFACode {
+			h.loginFailed(c, user.Email, errors.ErrInvalidMFACode)
+			return
+		}
+		h.handleMFAError(c, err)
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// reauthenticateWithoutPassword confirms a user who has no password with a
+// passkey assertion, if one is given, or else a TOTP or recovery code.
+func (h *AuthHandler) reauthenticateWithoutPassword(c *gin.Context, userID uuid.UUID, code, ceremony string, credential []byte) bool {
+	switch {
+	case ceremony != "":
+		if err := h.passkeyService.FinishSecondFactor(userID, ceremony, credential); err != nil {
+			h.handlePasskeyError(c, err)
+			return false
+		}
+	case code != "":
+		if err := h.mfaService.Verify(userID, code); err != nil {
+			h.handleMFAError(c, err)
+			return false
+		}
+	default:
+		httputil.HandleError(c, errors.ErrReauthenticationRequired)
+		return false
+	}
+	return true
+}
+
+func (h *AuthHandler) handleMFAError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrInvalidMFACode:
+		httputil.HandleError(c, errors.ErrInvalidMFACode)
+	case services.ErrMFANotEnrolled:
+		httputil.HandleError(c, errors.ErrMFANotEnrolled)
+	case services.ErrMFAAlreadyEnabled:
+		httputil.HandleError(c, errors.ErrMFAAlreadyEnabled)
+	default:
+		appErr := errors.ErrMFAFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+	}
+}
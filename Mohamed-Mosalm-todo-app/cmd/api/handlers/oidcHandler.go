@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/MohamedMosalm/Todo-App/utils/sso"
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie ties a login to the browser that started it, so nobody can
+// get another person signed in to their account by sending them a callback
+// link.
+const (
+	oidcStateCookie     = "oidc_state"
+	oidcStateCookiePath = "/api/auth/oidc"
+)
+
+func (h *AuthHandler) GetOIDCProviders(c *gin.Context) {
+	providers := h.oidcService.Providers()
+	response := make([]gin.H, len(providers))
+	for i, provider := range providers {
+		response[i] = gin.H{
+			"name":         provider.Name,
+			"display_name": provider.DisplayName,
+			"login_url":    fmt.Sprintf("%s/%s/login", oidcStateCookiePath, provider.Name),
+		}
+	}
+	httputil.SendSuccess(c, http.StatusOK, "Identity providers retrieved successfully", response)
+}
+
+// BeginOIDCLogin sends the browser to the identity provider.
+func (h *AuthHandler) BeginOIDCLogin(c *gin.Context) {
+	authURL, state, err := h.oidcService.BeginLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		if err == services.ErrUnknownOIDCProvider {
+			httputil.HandleError(c, errors.ErrUnknownOIDCProvider)
+			return
+		}
+		appErr := errors.ErrOIDCProviderUnavailable
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(h.oidcLoginTTL.Seconds()), oidcStateCookiePath, "", h.secureCookies, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback is where the identity provider sends the browser back to.
+// It responds like Login.
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		appErr := errors.ErrOIDCLoginFailed
+		appErr.Details = fmt.Errorf("%s: %s", providerErr, c.Query("error_description"))
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	state := c.Query("state")
+	cookieState, err := c.Cookie(oidcStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, oidcStateCookiePath, "", h.secureCookies, true)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		httputil.HandleError(c, errors.ErrInvalidOIDCState)
+		return
+	}
+
+	user, err := h.oidcService.CompleteLogin(c.Request.Context(), c.Param("provider"), state, c.Query("code"))
+	if err != nil {
+		switch {
+		case err == services.ErrUnknownOIDCProvider:
+			httputil.HandleError(c, errors.ErrUnknownOIDCProvider)
+		case err == services.ErrInvalidOIDCState:
+			httputil.HandleError(c, errors.ErrInvalidOIDCState)
+		case err == services.ErrOIDCEmailNotVerified:
+			httputil.HandleError(c, errors.ErrOIDCEmailNotVerified)
+		case stderrors.Is(err, sso.ErrInvalidIDToken), stderrors.Is(err, sso.ErrCodeExchangeFailed):
+			appErr := errors.ErrOIDCLoginFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+		default:
+			appErr := errors.ErrOIDCProviderUnavailable
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+		}
+		return
+	}
+
+	h.signIn(c, user)
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BeginPasskeyRegistration returns the options to pass to
+// navigator.credentials.create() and the ceremony to finish it with.
+func (h *AuthHandler) BeginPasskeyRegistration(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	user, err := h.userService.FindUserByID(userID)
+	if err != nil {
+		appErr := errors.ErrUserNotFound
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	creation, ceremony, err := h.passkeyService.BeginRegistration(user)
+	if err != nil {
+		h.handlePasskeyError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Create the passkey with your authenticator, then finish registering it", gin.H{
+		"ceremony":   ceremony,
+		"expires_in": int(h.passkeyCeremonyTTL.Seconds()),
+		"options":    creation,
+	})
+}
+
+func (h *AuthHandler) FinishPasskeyRegistration(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	var finishDTO dtos.FinishPasskeyRegistrationDTO
+	if err := c.ShouldBindJSON(&finishDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	user, err := h.userService.FindUserByID(userID)
+	if err != nil {
+		appErr := errors.ErrUserNotFound
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	passkey, err := h.passkeyService.FinishRegistration(user, finishDTO.Ceremony, finishDTO.Name, finishDTO.Credential)
+	if err != nil {
+		h.handlePasskeyError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusCreated, "Passkey registered", passkey)
+}
+
+func (h *AuthHandler) GetPasskeys(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	passkeys, err := h.passkeyService.GetPasskeys(userID)
+	if err != nil {
+		h.handlePasskeyError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Passkeys retrieved", passkeys)
+}
+
+func (h *AuthHandler) DeletePasskey(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	passkeyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		appErr := errors.ErrInvalidPasskeyID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.passkeyService.DeletePasskey(passkeyID, userID); err != nil {
+		h.handlePasskeyError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Passkey deleted", nil)
+}
+
+// BeginPasskeyLogin returns the options to pass to
+// navigator.credentials.get() for a passwordless login.
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	assertion, ceremony, err := h.passkeyService.BeginLogin()
+	if err != nil {
+		h.handlePasskeyError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Sign in with your passkey", gin.H{
+		"ceremony":   ceremony,
+		"expires_in": int(h.passkeyCeremonyTTL.Seconds()),
+		"options":    assertion,
+	})
+}
+
+// FinishPasskeyLogin issues tokens without asking for a second factor: the
+// authenticator verified the user, so the passkey already counts as two.
+func (h *AuthHandler) FinishPasskeyLogin(c *gin.Context) {
+	var finishDTO dtos.FinishPasskeyLoginDTO
+	if err := c.ShouldBindJSON(&finishDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	user, err := h.passkeyService.FinishLogin(finishDTO.Ceremony, finishDTO.Credential)
+	if err != nil {
+		h.handlePasskeyError(c, err)
+		return
+	}
+	if !user.IsActive() {
+		httputil.HandleError(c, errors.ErrAccountDeactivated)
+		return
+	}
+	if h.requireVerifiedLogin && user.EmailVerifiedAt == nil {
+		httputil.HandleError(c, errors.ErrEmailNotVerified)
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// BeginPasskeyMFA challenges the passkeys of a user whose login asked for
+// a second factor.
+func (h *AuthHandler) BeginPasskeyMFA(c *gin.Context) {
+	var beginDTO dtos.BeginPasskeyMFADTO
+	if err := c.ShouldBindJSON(&beginDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	userID, err := h.jwtService.ParseMFAToken(beginDTO.MFAToken)
+	if err != nil {
+		appErr := errors.ErrInvalidMFAToken
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	assertion, ceremony, err := h.passkeyService.BeginSecondFactor(userID)
+	if err != nil {
+		h.handlePasskeyError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Confirm the login with your passkey", gin.H{
+		"ceremony":   ceremony,
+		"expires_in": int(h.passkeyCeremonyTTL.Seconds()),
+		"options":    assertion,
+	})
+}
+
+// VerifyPasskeyMFA is VerifyMFA with a passkey instead of a code.
+func (h *AuthHandler) VerifyPasskeyMFA(c *gin.Context) {
+	var verifyDTO dtos.VerifyPasskeyMFADTO
+	if err := c.ShouldBindJSON(&verifyDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	userID, err := h.jwtService.ParseMFAToken(verifyDTO.MFAToken)
+	if err != nil {
+		appErr := errors.ErrInvalidMFAToken
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	user, err := h.userService.FindUserByID(userID)
+	if err != nil {
+		httputil.HandleError(c, errors.ErrInvalidMFAToken)
+		return
+	}
+	// The account may have been deactivated since the password was checked.
+	if !user.IsActive() {
+		httputil.HandleError(c, errors.ErrAccountDeactivated)
+		return
+	}
+
+	if err := h.passkeyService.FinishSecondFactor(userID, verifyDTO.Ceremony, verifyDTO.Credential); err != nil {
+		h.handlePasskeyError(c, err)
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// BeginPasskeyReauthentication challenges the passkeys of the signed-in
+// user, for confirming a sensitive change on an account without a password.
+func (h *AuthHandler) BeginPasskeyReauthentication(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	assertion, ceremony, err := h.passkeyService.BeginSecondFactor(userID)
+	if err != nil {
+		h.handlePasskeyError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Confirm it is you with your passkey", gin.H{
+		"ceremony":   ceremony,
+		"expires_in": int(h.passkeyCeremonyTTL.Seconds()),
+		"options":    assertion,
+	})
+}
+
+func (h *AuthHandler) handlePasskeyError(c *gin.Context, err error) {
+	switch {
+	case err == services.ErrInvalidPasskeyCeremony:
+		httputil.HandleError(c, errors.ErrInvalidPasskeyCeremony)
+	case stderrors.Is(err, services.ErrPasskeyRejected):
+		appErr := errors.ErrPasskeyRejected
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+	case err == services.ErrNoPasskeys:
+		httputil.HandleError(c, errors.ErrNoPasskeys)
+	case err == services.ErrPasskeyNotFound:
+		httputil.HandleError(c, errors.ErrPasskeyNotFound)
+	default:
+		appErr := errors.ErrPasskeyFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+	}
+}
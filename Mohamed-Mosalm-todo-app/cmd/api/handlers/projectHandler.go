@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ProjectHandler struct {
+	projectService services.ProjectService
+	taskService    services.TaskService
+}
+
+func NewProjectHandler(projectService services.ProjectService, taskService services.TaskService) *ProjectHandler {
+	return &ProjectHandler{
+		projectService: projectService,
+		taskService:    taskService,
+	}
+}
+
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	var createProjectDTO dtos.CreateProjectDTO
+
+	if err := c.ShouldBindJSON(&createProjectDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	project := models.Project{
+		Name:        createProjectDTO.Name,
+		Description: createProjectDTO.Description,
+		UserID:      userID,
+		Columns:     dtos.ColumnsFromDTO(createProjectDTO.Columns),
+	}
+
+	if err := h.projectService.CreateProject(&project); err != nil {
+		if err == services.ErrDuplicateState {
+			httputil.HandleError(c, errors.ErrInvalidColumns)
+			return
+		}
+		appErr := errors.ErrCreateProjectFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusCreated, "Project created successfully", dtos.NewProjectResponseDTO(&project))
+}
+
+func (h *ProjectHandler) GetProjects(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	projects, err := h.projectService.GetProjectsByUserID(userID)
+	if err != nil {
+		appErr := errors.ErrFetchProjectsFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	projectResponses := make([]dtos.ProjectResponseDTO, len(projects))
+	for i, project := range projects {
+		projectResponses[i] = *dtos.NewProjectResponseDTO(&project)
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Projects retrieved successfully", projectResponses)
+}
+
+func (h *ProjectHandler) GetBoard(c *gin.Context) {
+	project, ok := h.loadProject(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.projectService.GetProjectTasks(project.ID)
+	if err != nil {
+		appErr := errors.ErrFetchTasksFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Board retrieved successfully", dtos.NewBoardResponseDTO(project, tasks))
+}
+
+func (h *ProjectHandler) UpdateColumns(c *gin.Context) {
+	var updateColumnsDTO dtos.UpdateColumnsDTO
+	if err := c.ShouldBindJSON(&updateColumnsDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	project, ok := h.loadProject(c)
+	if !ok {
+		return
+	}
+
+	columns := dtos.ColumnsFromDTO(updateColumnsDTO.Columns)
+	if err := h.projectService.UpdateColumns(project, columns); err != nil {
+		switch err {
+		case services.ErrDuplicateState:
+			httputil.HandleError(c, errors.ErrInvalidColumns)
+		case services.ErrColumnNotEmpty:
+			httputil.HandleError(c, errors.ErrColumnNotEmpty)
+		default:
+			appErr := errors.ErrUpdateColumnsFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+		}
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Board columns updated successfully", dtos.NewProjectResponseDTO(project))
+}
+
+func (h *ProjectHandler) GetFields(c *gin.Context) {
+	project, ok := h.loadProject(c)
+	if !ok {
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Custom fields retrieved successfully", project.Fields)
+}
+
+func (h *ProjectHandler) UpdateFields(c *gin.Context) {
+	var updateFieldsDTO dtos.UpdateFieldsDTO
+	if err := c.ShouldBindJSON(&updateFieldsDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	project, ok := h.loadProject(c)
+	if !ok {
+		return
+	}
+
+	fields := dtos.FieldsFromDTO(updateFieldsDTO.Fields)
+	if err := h.projectService.UpdateFields(project, fields); err != nil {
+		if fieldErr, ok := err.(*services.CustomFieldError); ok {
+			appErr := errors.ErrInvalidCustomField
+			appErr.Details = fieldErr
+			httputil.HandleError(c, appErr)
+			return
+		}
+		appErr := errors.ErrUpdateFieldsFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Custom fields updated successfully", project.Fields)
+}
+
+func (h *ProjectHandler) MoveTask(c *gin.Context) {
+	var moveTaskDTO dtos.MoveTaskDTO
+	if err := c.ShouldBindJSON(&moveTaskDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	project, ok := h.loadProject(c)
+	if !ok {
+		return
+	}
+
+	taskID, err := uuid.Parse(c.Param("task_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidTaskID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	task, err := h.taskService.GetTaskByID(taskID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.HandleError(c, errors.ErrTaskNotFound)
+			return
+		}
+		appErr := errors.ErrFetchTasksFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	position := -1
+	if moveTaskDTO.Position != nil {
+		position = *moveTaskDTO.Position
+	}
+
+	if err := h.projectService.MoveTask(project, task, moveTaskDTO.State, position, moveTaskDTO.Force); err != nil {
+		switch err {
+		case services.ErrTaskNotInProject:
+			httputil.HandleError(c, errors.ErrTaskNotFound)
+		case services.ErrUnknownState:
+			httputil.HandleError(c, errors.ErrUnknownState)
+		case services.ErrWIPLimitExceeded:
+			httputil.HandleError(c, errors.ErrWIPLimitExceeded)
+		default:
+			appErr := errors.ErrMoveTaskFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+		}
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Task moved successfully", dtos.NewTaskResponseDTO(task))
+}
+
+// loadProject resolves the :id path parameter to a project owned by the
+// current user, writing the error response itself when it cannot.
+func (h *ProjectHandler) loadProject(c *gin.Context) (*models.Project, bool) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		appErr := errors.ErrInvalidProjectID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	project, err := h.projectService.GetProjectByID(projectID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.HandleError(c, errors.ErrProjectNotFound)
+			return nil, false
+		}
+		appErr := errors.ErrFetchProjectsFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	if project.UserID != userID {
+		httputil.HandleError(c, errors.ErrProjectNotFound)
+		return nil, false
+	}
+
+	return project, true
+}
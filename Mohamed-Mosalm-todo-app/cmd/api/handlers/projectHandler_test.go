@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProjectService struct {
+	mock.Mock
+}
+
+func (m *MockProjectService) CreateProject(project *models.Project) error {
+	args := m.Called(project)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) GetProjectsByUserID(userID uuid.UUID) ([]models.Project, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Project), args.Error(1)
+}
+
+func (m *MockProjectService) GetProjectByID(projectID uuid.UUID) (*models.Project, error) {
+	args := m.Called(projectID)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectService) UpdateColumns(project *models.Project, columns []models.BoardColumn) error {
+	args := m.Called(project, columns)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) UpdateFields(project *models.Project, fields []models.CustomField) error {
+	args := m.Called(project, fields)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) GetProjectTasks(projectID uuid.UUID) ([]models.Task, error) {
+	args := m.Called(projectID)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+func (m *MockProjectService) MoveTask(project *models.Project, task *models.Task, state string, position int, force bool) error {
+	args := m.Called(project, task, state, position, force)
+	return args.Error(0)
+}
+
+func setupProjectRouter(projectHandler *ProjectHandler, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Next()
+	})
+	router.GET("/api/projects/:id/board", projectHandler.GetBoard)
+	router.PUT("/api/projects/:id/board/tasks/:task_id", projectHandler.MoveTask)
+	return router
+}
+
+func TestGetBoard(t *testing.T) {
+	mockProjectService := new(MockProjectService)
+	projectHandler := NewProjectHandler(mockProjectService, new(MockTaskService))
+
+	userID := uuid.New()
+	project := &models.Project{
+		ID:      uuid.New(),
+		Name:    "Release",
+		UserID:  userID,
+		Columns: models.DefaultBoardColumns(),
+	}
+	tasks := []models.Task{
+		{ID: uuid.New(), Title: "Write notes", State: "done", ProjectID: &project.ID, UserID: userID},
+		{ID: uuid.New(), Title: "Tag build", State: "todo", ProjectID: &project.ID, UserID: userID},
+		{ID: uuid.New(), Title: "Publish", State: "todo", Position: 1, ProjectID: &project.ID, UserID: userID},
+	}
+
+	mockProjectService.On("GetProjectByID", project.ID).Return(project, nil)
+	mockProjectService.On("GetProjectTasks", project.ID).Return(tasks, nil)
+
+	router := setupProjectRouter(projectHandler, userID)
+	req, _ := http.NewRequest(http.MethodGet, "/api/projects/"+project.ID.String()+"/board", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var response struct {
+		Data dtos.BoardResponseDTO `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+
+	columns := response.Data.Columns
+	assert.Len(t, columns, 3)
+	assert.Equal(t, "todo", columns[0].State)
+	assert.Equal(t, 2, columns[0].TaskCount)
+	assert.Equal(t, "Tag build", columns[0].Tasks[0].Title)
+	assert.Equal(t, "Publish", columns[0].Tasks[1].Title)
+	assert.Empty(t, columns[1].Tasks)
+	assert.Equal(t, 1, columns[2].TaskCount)
+
+	mockProjectService.AssertExpectations(t)
+}
+
+func TestMoveTaskWIPLimitExceeded(t *testing.T) {
+	mockProjectService := new(MockProjectService)
+	mockTaskService := new(MockTaskService)
+	projectHandler := NewProjectHandler(mockProjectService, mockTaskService)
+
+	userID := uuid.New()
+	project := &models.Project{ID: uuid.New(), UserID: userID, Columns: models.DefaultBoardColumns()}
+	task := &models.Task{ID: uuid.New(), State: "todo", ProjectID: &project.ID, UserID: userID}
+
+	mockProjectService.On("GetProjectByID", project.ID).Return(project, nil)
+	mockTaskService.On("GetTaskByID", task.ID).Return(task, nil)
+	mockProjectService.On("MoveTask", project, task, "in_progress", -1, false).Return(services.ErrWIPLimitExceeded)
+
+	router := setupProjectRouter(projectHandler, userID)
+	body, _ := json.Marshal(dtos.MoveTaskDTO{State: "in_progress"})
+	req, _ := http.NewRequest(http.MethodPut, "/api/projects/"+project.ID.String()+"/board/tasks/"+task.ID.String(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	mockProjectService.AssertExpectations(t)
+	mockTaskService.AssertExpectations(t)
+}
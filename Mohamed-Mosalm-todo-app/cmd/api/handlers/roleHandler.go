@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *AdminHandler) GetRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		h.handleRoleError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Roles retrieved", gin.H{
+		"roles":       roles,
+		"permissions": models.Permissions,
+	})
+}
+
+func (h *AdminHandler) CreateRole(c *gin.Context) {
+	var roleDTO dtos.CreateRoleDTO
+	if err := c.ShouldBindJSON(&roleDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if !h.mayGrant(c, roleDTO.Permissions) {
+		return
+	}
+	if !h.audit(c, models.AuditRoleCreate, nil, models.AuditDetails{"role": roleDTO.Name, "permissions": roleDTO.Permissions}) {
+		return
+	}
+	role, err := h.roleService.CreateRole(roleDTO.Name, roleDTO.Description, roleDTO.Permissions)
+	if err != nil {
+		h.handleRoleError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusCreated, "Role created", role)
+}
+
+func (h *AdminHandler) UpdateRole(c *gin.Context) {
+	var roleDTO dtos.UpdateRoleDTO
+	if err := c.ShouldBindJSON(&roleDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	name := c.Param("name")
+	if !h.mayEditRole(c, name) || !h.mayGrant(c, roleDTO.Permissions) {
+		return
+	}
+	if !h.audit(c, models.AuditRoleUpdate, nil, models.AuditDetails{"role": name, "permissions": roleDTO.Permissions}) {
+		return
+	}
+	role, err := h.roleService.UpdateRole(name, roleDTO.Description, roleDTO.Permissions)
+	if err != nil {
+		h.handleRoleError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Role updated", role)
+}
+
+func (h *AdminHandler) DeleteRole(c *gin.Context) {
+	name := c.Param("name")
+	if !h.mayEditRole(c, name) {
+		return
+	}
+	if !h.audit(c, models.AuditRoleDelete, nil, models.AuditDetails{"role": name}) {
+		return
+	}
+	if err := h.roleService.DeleteRole(name); err != nil {
+		h.handleRoleError(c, err)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Role deleted", nil)
+}
+
+// mayGrant responds with an error unless the signed-in user's role grants
+// every one of permissions, so that nobody hands out, or gives their own
+// role, a permission they lack. Unknown permissions are left for the role
+// service to refuse.
+func (h *AdminHandler) mayGrant(c *gin.Context, permissions []string) bool {
+	known := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		if models.IsPermission(permission) {
+			known = append(known, permission)
+		}
+	}
+
+	allowed, err := h.roleService.HasPermissions(c.GetString("user_role"), known...)
+	if err != nil {
+		appErr := errors.ErrPermissionCheckFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return false
+	}
+	if !allowed {
+		httputil.HandleError(c, errors.ErrPermissionDenied)
+		return false
+	}
+	return true
+}
+
+// mayEditRole responds with an error unless the signed-in user's role
+// grants every permission of the role named name.
+func (h *AdminHandler) mayEditRole(c *gin.Context, name string) bool {
+	allowed, err := h.outranks(c.GetString("user_role"), name)
+	if err != nil {
+		appErr := errors.ErrPermissionCheckFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return false
+	}
+	if !allowed {
+		httputil.HandleError(c, errors.ErrPermissionDenied)
+		return false
+	}
+	return true
+}
+
+func (h *AdminHandler) handleRoleError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrInvalidRoleName:
+		httputil.HandleError(c, errors.ErrInvalidRoleName)
+	case services.ErrUnknownPermission:
+		httputil.HandleError(c, errors.ErrUnknownPermission)
+	case services.ErrRoleNotFound:
+		httputil.HandleError(c, errors.ErrRoleNotFound)
+	case services.ErrRoleExists:
+		httputil.HandleError(c, errors.ErrRoleExists)
+	case services.ErrBuiltInRole:
+		httputil.HandleError(c, errors.ErrBuiltInRole)
+	case services.ErrRoleInUse:
+		httputil.HandleError(c, errors.ErrRoleInUse)
+	default:
+		appErr := errors.ErrRoleFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+	}
+}
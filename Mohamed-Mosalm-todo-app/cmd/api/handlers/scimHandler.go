@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSCIMPageSize = 100
+	maxSCIMPageSize     = 200
+)
+
+// SCIMHandler serves the SCIM 2.0 Users endpoint provisioning clients such
+// as Okta or Microsoft Entra ID use to create, update and deactivate users.
+type SCIMHandler struct {
+	userService services.UserService
+	baseURL     string
+}
+
+func NewSCIMHandler(userService services.UserService, config config.AppConfig) *SCIMHandler {
+	return &SCIMHandler{userService: userService, baseURL: config.BaseURL}
+}
+
+func (h *SCIMHandler) location(user *models.User) string {
+	return h.baseURL + "/scim/v2/Users/" + user.ID.String()
+}
+
+func (h *SCIMHandler) sendUser(c *gin.Context, status int, user *models.User) {
+	c.Header("Location", h.location(user))
+	httputil.SendSCIM(c, status, dtos.NewSCIMUserDTO(user, h.location(user)))
+}
+
+func scimInternalError(c *gin.Context, err error) {
+	log.Printf("Error details: %v", err)
+	httputil.HandleSCIMError(c, http.StatusInternalServerError, "", "Internal server error")
+}
+
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var userDTO dtos.SCIMUserDTO
+	if err := c.ShouldBindJSON(&userDTO); err != nil {
+		httputil.HandleSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	email, ok := scimEmailAddress(userDTO.UserName)
+	if !ok {
+		httputil.HandleSCIMError(c, http.StatusBadRequest, "invalidValue", "userName must be an email address")
+		return
+	}
+	existing, _ := h.userService.FindUserByEmail(email)
+	if existing != nil && existing.DeprovisionedAt == nil {
+		httputil.HandleSCIMError(c, http.StatusConflict, "uniqueness", "A user with this userName already exists")
+		return
+	}
+
+	// The provisioning client vouches for the address.
+	now := time.Now()
+	if existing != nil {
+		h.reprovisionUser(c, existing, userDTO, now)
+		return
+	}
+	user := models.User{
+		FirstName:       userDTO.Name.GivenName,
+		LastName:        userDTO.Name.FamilyName,
+		Email:           email,
+		ExternalID:      userDTO.ExternalID,
+		EmailVerifiedAt: &now,
+	}
+	if len(userDTO.PhoneNumbers) > 0 {
+		user.Phone = primaryValue(userDTO.PhoneNumbers)
+	}
+	if userDTO.Active != nil && !*userDTO.Active {
+		user.DeactivatedAt = &now
+	}
+
+	if err := h.userService.CreateUser(&user); err != nil {
+		// Another request created the user since the lookup above.
+		if stderrors.Is(err, gorm.ErrDuplicatedKey) {
+			httputil.HandleSCIMError(c, http.StatusConflict, "uniqueness", "A user with this userName already exists")
+			return
+		}
+		scimInternalError(c, err)
+		return
+	}
+	h.sendUser(c, http.StatusCreated, &user)
+}
+
+// reprovisionUser brings back a user the provisioning client deleted
+// earlier, with their data, as if they were created anew.
+func (h *SCIMHandler) reprovisionUser(c *gin.Context, user *models.User, userDTO dtos.SCIMUserDTO, now time.Time) {
+	updates := map[string]interface{}{
+		"first_name":        userDTO.Name.GivenName,
+		"last_name":         userDTO.Name.FamilyName,
+		"external_id":       userDTO.ExternalID,
+		"email_verified_at": now,
+		"deactivated_at":    nil,
+		"deprovisioned_at":  nil,
+	}
+	if len(userDTO.PhoneNumbers) > 0 {
+		updates["phone"] = primaryValue(userDTO.PhoneNumbers)
+	}
+	if userDTO.Active != nil && !*userDTO.Active {
+		updates["deactivated_at"] = now
+	}
+	if err := h.userService.UpdateUser(user.ID, updates); err != nil {
+		scimInternalError(c, err)
+		return
+	}
+
+	user, err := h.userService.FindUserByID(user.ID)
+	if err != nil {
+		scimInternalError(c, err)
+		return
+	}
+	h.sendUser(c, http.StatusCreated, user)
+}
+
+// findUser responds with 404 unless the :id parameter names a user the
+// provisioning client has not deleted.
+func (h *SCIMHandler) findUser(c *gin.Context) (*models.User, bool) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.HandleSCIMError(c, http.StatusNotFound, "", "User not found")
+		return nil, false
+	}
+	user, err := h.userService.FindUserByID(userID)
+	if err == gorm.ErrRecordNotFound || (err == nil && user.DeprovisionedAt != nil) {
+		httputil.HandleSCIMError(c, http.StatusNotFound, "", "User not found")
+		return nil, false
+	}
+	if err != nil {
+		scimInternalError(c, err)
+		return nil, false
+	}
+	return user, true
+}
+
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	h.sendUser(c, http.StatusOK, user)
+}
+
+// ListUsers supports filters of the form `userName eq "jane@example.com"`,
+// on userName, emails, externalId and active, joined with and.
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	options, err := parseSCIMFilter(c.Query("filter"))
+	if err != nil {
+		httputil.HandleSCIMError(c, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+
+	// SCIM counts from 1; out of range values are clamped as RFC 7644 asks.
+	startIndex, _ := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	startIndex = max(startIndex, 1)
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(defaultSCIMPageSize)))
+	if err != nil {
+		count = defaultSCIMPageSize
+	}
+	options.Offset, options.Limit = startIndex-1, min(max(count, 0), maxSCIMPageSize)
+	options.ExcludeDeprovisioned = true
+
+	users, total, err := h.userService.ListUsers(options)
+	if err != nil {
+		scimInternalError(c, err)
+		return
+	}
+
+	resources := make([]dtos.SCIMUserDTO, len(users))
+	for i := range users {
+		resources[i] = dtos.NewSCIMUserDTO(&users[i], h.location(&users[i]))
+	}
+	httputil.SendSCIM(c, http.StatusOK, dtos.SCIMListResponseDTO{
+		Schemas:      []string{dtos.SCIMListResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// PatchUser applies a SCIM PatchOp. Setting active to false deactivates the
+// user and true reactivates them.
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	var patchDTO dtos.SCIMPatchDTO
+	if err := c.ShouldBindJSON(&patchDTO); err != nil {
+		httputil.HandleSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	changes, err := parseSCIMPatch(patchDTO.Operations)
+	if err != nil {
+		requestErr := err.(*scimRequestError)
+		httputil.HandleSCIMError(c, http.StatusBadRequest, requestErr.scimType, requestErr.detail)
+		return
+	}
+
+	if email, ok := changes.updates["email"]; ok && email != user.Email {
+		if existing, _ := h.userService.FindUserByEmail(email.(string)); existing != nil && existing.ID != user.ID {
+			httputil.HandleSCIMError(c, http.StatusConflict, "uniqueness", "A user with this userName already exists")
+			return
+		}
+	}
+	if len(changes.updates) > 0 {
+		if err := h.userService.UpdateUser(user.ID, changes.updates); err != nil {
+			scimInternalError(c, err)
+			return
+		}
+	}
+	if changes.active != nil && *changes.active != user.IsActive() {
+		if *changes.active {
+			err = h.userService.ReactivateUser(user.ID)
+		} else {
+			err = h.userService.DeactivateUser(user.ID)
+		}
+		if err != nil {
+			scimInternalError(c, err)
+			return
+		}
+	}
+
+	if user, ok = h.findUser(c); ok {
+		h.sendUser(c, http.StatusOK, user)
+	}
+}
+
+// DeleteUser deactivates the user rather than deleting their data. As RFC
+// 7644 asks, SCIM answers 404 for them from then on.
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	if err := h.userService.DeprovisionUser(user.ID); err != nil {
+		scimInternalError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
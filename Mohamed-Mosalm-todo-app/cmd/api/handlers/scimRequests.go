@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/services"
+)
+
+// scimRequestError is a SCIM request that cannot be served as asked.
+// scimType is the RFC 7644 error type.
+type scimRequestError struct {
+	scimType string
+	detail   string
+}
+
+func (e *scimRequestError) Error() string {
+	return e.detail
+}
+
+func invalidSCIMValue(format string, args ...interface{}) error {
+	return &scimRequestError{scimType: "invalidValue", detail: fmt.Sprintf(format, args...)}
+}
+
+// scimFilterClause matches one `attribute eq value` comparison and the and
+// that may follow it.
+var scimFilterClause = regexp.MustCompile(`(?i)^\s*([a-z][a-z.]*)\s+eq\s+("(?:[^"\\]|\\.)*"|true|false)\s*(and\s+|$)`)
+
+// parseSCIMFilter supports the filters provisioning clients look users up
+// with: equality on userName, emails, externalId and active, joined by and.
+func parseSCIMFilter(filter string) (services.UserListOptions, error) {
+	var options services.UserListOptions
+	for rest := strings.TrimSpace(filter); rest != ""; {
+		match := scimFilterClause.FindStringSubmatch(rest)
+		if match == nil {
+			return options, fmt.Errorf("unsupported filter %q; use attribute eq \"value\", joined by and", filter)
+		}
+		rest = rest[len(match[0]):]
+		if match[3] != "" && rest == "" {
+			return options, fmt.Errorf("filter %q ends with and", filter)
+		}
+
+		attribute, literal := strings.ToLower(match[1]), match[2]
+		if attribute == "active" {
+			if literal != "true" && literal != "false" {
+				return options, fmt.Errorf("active can only be compared to true or false")
+			}
+			active := literal == "true"
+			options.Active = &active
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal([]byte(literal), &value); err != nil {
+			return options, fmt.Errorf("%s can only be compared to a string", match[1])
+		}
+		switch attribute {
+		case "username", "emails", "emails.value":
+			options.Email = value
+		case "externalid":
+			options.ExternalID = value
+		default:
+			return options, fmt.Errorf("filtering by %s is not supported", match[1])
+		}
+	}
+	return options, nil
+}
+
+// scimChanges are the changes a PatchOp asks for: column updates, and
+// whether the user should be active if that is to change.
+type scimChanges struct {
+	updates map[string]interface{}
+	active  *bool
+}
+
+func parseSCIMPatch(operations []dtos.SCIMPatchOperationDTO) (*scimChanges, error) {
+	changes := &scimChanges{updates: map[string]interface{}{}}
+	for _, operation := range operations {
+		switch strings.ToLower(operation.Op) {
+		case "add", "replace":
+			if operation.Path != "" {
+				if err := changes.set(operation.Path, operation.Value); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			// Without a path, the value holds attributes by name.
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(operation.Value, &values); err != nil {
+				return nil, invalidSCIMValue("%s without a path needs an object value", operation.Op)
+			}
+			for path, value := range values {
+				if err := changes.set(path, value); err != nil {
+					return nil, err
+				}
+			}
+		case "remove":
+			if err := changes.remove(operation.Path); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, &scimRequestError{scimType: "invalidSyntax", detail: fmt.Sprintf("unknown op %q", operation.Op)}
+		}
+	}
+	return changes, nil
+}
+
+// set records the value of the attribute at path. Attributes users do not
+// have here are ignored, so that clients can send their whole schema.
+func (ch *scimChanges) set(path string, value json.RawMessage) error {
+	attribute := strings.ToLower(path)
+	switch {
+	case attribute == "active":
+		active, err := scimBool(value)
+		if err != nil {
+			return err
+		}
+		ch.active = &active
+	case attribute == "username":
+		email, err := scimString(path, value)
+		if err != nil {
+			return err
+		}
+		return ch.setEmail(email)
+	case attribute == "externalid":
+		return ch.setString("external_id", path, value)
+	case attribute == "name":
+		var name map[string]json.RawMessage
+		if err := json.Unmarshal(value, &name); err != nil {
+			return invalidSCIMValue("name must be an object")
+		}
+		for subAttribute, subValue := range name {
+			if err := ch.set("name."+subAttribute, subValue); err != nil {
+				return err
+			}
+		}
+	case attribute == "name.givenname":
+		return ch.setString("first_name", path, value)
+	case attribute == "name.familyname":
+		return ch.setString("last_name", path, value)
+	case strings.HasPrefix(attribute, "emails"):
+		email, err := scimMultiValue(path, value)
+		if err != nil {
+			return err
+		}
+		return ch.setEmail(email)
+	case strings.HasPrefix(attribute, "phonenumbers"):
+		phone, err := scimMultiValue(path, value)
+		if err != nil {
+			return err
+		}
+		ch.updates["phone"] = phone
+	}
+	return nil
+}
+
+func (ch *scimChanges) setString(column, path string, value json.RawMessage) error {
+	s, err := scimString(path, value)
+	if err != nil {
+		return err
+	}
+	ch.updates[column] = s
+	return nil
+}
+
+func (ch *scimChanges) setEmail(value string) error {
+	email, ok := scimEmailAddress(value)
+	if !ok {
+		return invalidSCIMValue("userName must be an email address")
+	}
+	ch.updates["email"] = email
+	return nil
+}
+
+func (ch *scimChanges) remove(path string) error {
+	attribute := strings.ToLower(path)
+	switch {
+	case attribute == "":
+		return &scimRequestError{scimType: "noTarget", detail: "remove needs a path"}
+	case attribute == "username", attribute == "active", strings.HasPrefix(attribute, "emails"):
+		return invalidSCIMValue("%s is required and cannot be removed", path)
+	case attribute == "externalid":
+		ch.updates["external_id"] = ""
+	case attribute == "name":
+		ch.updates["first_name"], ch.updates["last_name"] = "", ""
+	case attribute == "name.givenname":
+		ch.updates["first_name"] = ""
+	case attribute == "name.familyname":
+		ch.updates["last_name"] = ""
+	case strings.HasPrefix(attribute, "phonenumbers"):
+		ch.updates["phone"] = ""
+	}
+	return nil
+}
+
+func scimString(path string, value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return "", invalidSCIMValue("%s must be a string", path)
+	}
+	return s, nil
+}
+
+// scimBool also accepts "True" and "False", which some clients send.
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		switch strings.ToLower(s) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, invalidSCIMValue("active must be a boolean")
+}
+
+// scimMultiValue reads the value set on a multi-valued attribute such as
+// emails: a plain string when the path selects the value, or entries, of
+// which the primary one, or else the first, counts.
+func scimMultiValue(path string, value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s, nil
+	}
+	var entries []dtos.SCIMValueDTO
+	if err := json.Unmarshal(value, &entries); err == nil && len(entries) > 0 {
+		return primaryValue(entries), nil
+	}
+	var entry dtos.SCIMValueDTO
+	if err := json.Unmarshal(value, &entry); err == nil && entry.Value != "" {
+		return entry.Value, nil
+	}
+	return "", invalidSCIMValue("%s must be a string or a list of values", path)
+}
+
+func primaryValue(entries []dtos.SCIMValueDTO) string {
+	for _, entry := range entries {
+		if entry.Primary {
+			return entry.Value
+		}
+	}
+	return entries[0].Value
+}
+
+// scimEmailAddress returns value if it is a bare email address.
+func scimEmailAddress(value string) (string, bool) {
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		return "", false
+	}
+	return value, true
+}
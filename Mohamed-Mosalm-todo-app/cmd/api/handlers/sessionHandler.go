@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SessionHandler struct {
+	sessionService services.SessionService
+}
+
+func NewSessionHandler(sessionService services.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+func (h *SessionHandler) GetSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	sessions, err := h.sessionService.GetSessionsByUserID(userID)
+	if err != nil {
+		appErr := errors.ErrFetchSessionsFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	currentSessionID, _ := uuid.Parse(c.GetString("session_id"))
+	response := make([]*dtos.SessionResponseDTO, len(sessions))
+	for i := range sessions {
+		response[i] = dtos.NewSessionResponseDTO(&sessions[i], currentSessionID)
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Sessions retrieved successfully", response)
+}
+
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		appErr := errors.ErrInvalidSessionID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(sessionID, userID); err != nil {
+		if err == services.ErrSessionNotFound {
+			httputil.HandleError(c, errors.ErrSessionNotFound)
+			return
+		}
+		appErr := errors.ErrRevokeSessionFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Session revoked successfully", nil)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/MohamedMosalm/Todo-App/config"
 	"github.com/MohamedMosalm/Todo-App/dtos"
@@ -42,14 +43,28 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		return
 	}
 
+	// Binding has already restricted the priority to a known name.
+	priority, _ := models.ParsePriority(createTaskDTO.Priority)
+
 	task := models.Task{
 		Title:       createTaskDTO.Title,
 		Description: createTaskDTO.Description,
 		Status:      false,
+		Priority:    priority,
+		Tags:        createTaskDTO.Tags,
+		DueDate:     createTaskDTO.DueDate,
+		ParentID:    createTaskDTO.ParentID,
+		ProjectID:   createTaskDTO.ProjectID,
 		UserID:      userID,
+
+		CustomFields: createTaskDTO.CustomFields,
 	}
 
 	if err := h.taskService.CreateTask(&task); err != nil {
+		if appErr := taskServiceError(err); appErr != nil {
+			httputil.HandleError(c, appErr)
+			return
+		}
 		appErr := errors.ErrCreateTaskFailed
 		appErr.Details = err
 		httputil.HandleError(c, appErr)
@@ -68,12 +83,24 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		return
 	}
 
-	tasks, err := h.taskService.GetTasksByUserID(userID)
+	options, err := taskListOptions(c)
+	if err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	tasks, err := h.taskService.ListTasks(userID, options)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			httputil.SendSuccess(c, http.StatusOK, "No tasks found", []dtos.TaskResponseDTO{})
 			return
 		}
+		if appErr := taskServiceError(err); appErr != nil {
+			httputil.HandleError(c, appErr)
+			return
+		}
 		appErr := errors.ErrFetchTasksFailed
 		appErr.Details = err
 		httputil.HandleError(c, appErr)
@@ -137,9 +164,28 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	if updateDTO.Description != "" {
 		updates["description"] = updateDTO.Description
 	}
-	updates["status"] = updateDTO.Status
+	if updateDTO.Priority != "" {
+		priority, _ := models.ParsePriority(updateDTO.Priority)
+		updates["priority"] = priority
+	}
+	if updateDTO.Tags != nil {
+		updates["tags"] = models.StringList(updateDTO.Tags)
+	}
+	if updateDTO.DueDate != nil {
+		updates["due_date"] = updateDTO.DueDate
+	}
+	if updateDTO.CustomFields != nil {
+		updates["custom_fields"] = updateDTO.CustomFields
+	}
+	if updateDTO.Status != nil {
+		updates["status"] = *updateDTO.Status
+	}
 
 	if err := h.taskService.UpdateTask(taskID, updates); err != nil {
+		if appErr := taskServiceError(err); appErr != nil {
+			httputil.HandleError(c, appErr)
+			return
+		}
 		appErr := errors.ErrUpdateTaskFailed
 		appErr.Details = err
 		httputil.HandleError(c, appErr)
@@ -183,7 +229,7 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 		return
 	}
 
-	if err := h.taskService.DeleteTask(taskID, userID); err != nil {
+	if err := h.taskService.DeleteTask(c.Request.Context(), taskID, userID); err != nil {
 		appErr := errors.ErrDeleteTaskFailed
 		appErr.Details = err
 		httputil.HandleError(c, appErr)
@@ -192,3 +238,54 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 
 	httputil.SendSuccess(c, http.StatusOK, "Task deleted successfully", nil)
 }
+
+// taskListOptions reads the listing query: project_id, sort, order=asc|desc
+// and one cf.<key>=<value> parameter per custom field filter.
+func taskListOptions(c *gin.Context) (services.TaskListOptions, error) {
+	options := services.TaskListOptions{
+		SortBy: c.Query("sort"),
+		Desc:   c.Query("order") == "desc",
+	}
+
+	if projectID := c.Query("project_id"); projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			return options, err
+		}
+		options.ProjectID = &id
+	}
+
+	for key, values := range c.Request.URL.Query() {
+		if field, ok := strings.CutPrefix(key, "cf."); ok && len(values) > 0 {
+			if options.CustomFields == nil {
+				options.CustomFields = make(map[string]string)
+			}
+			options.CustomFields[field] = values[0]
+		}
+	}
+	return options, nil
+}
+
+// taskServiceError maps the domain errors returned by TaskService to API
+// errors, returning nil for anything unexpected.
+func taskServiceError(err error) *errors.AppError {
+	if fieldErr, ok := err.(*services.CustomFieldError); ok {
+		appErr := errors.ErrInvalidCustomField
+		appErr.Details = fieldErr
+		return appErr
+	}
+
+	switch err {
+	case services.ErrProjectNotFound:
+		return errors.ErrProjectNotFound
+	case services.ErrParentTaskNotFound:
+		return errors.ErrParentTaskNotFound
+	case services.ErrInvalidSort:
+		return errors.ErrInvalidSort
+	case services.ErrWIPLimitExceeded:
+		return errors.ErrWIPLimitExceeded
+	case services.ErrNoColumnForStatus:
+		return errors.ErrNoColumnForStatus
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/dtos"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TemplateHandler struct {
+	templateService services.TemplateService
+}
+
+func NewTemplateHandler(templateService services.TemplateService) *TemplateHandler {
+	return &TemplateHandler{templateService: templateService}
+}
+
+func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
+	var templateDTO dtos.TemplateDTO
+	if err := c.ShouldBindJSON(&templateDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	template := models.TaskTemplate{UserID: userID}
+	templateDTO.ApplyTo(&template)
+
+	if err := h.templateService.CreateTemplate(&template); err != nil {
+		appErr := errors.ErrCreateTemplateFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusCreated, "Template created successfully", dtos.NewTemplateResponseDTO(&template))
+}
+
+func (h *TemplateHandler) GetTemplates(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	templates, err := h.templateService.GetTemplatesByUserID(userID)
+	if err != nil {
+		appErr := errors.ErrFetchTemplatesFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	templateResponses := make([]dtos.TemplateResponseDTO, len(templates))
+	for i, template := range templates {
+		templateResponses[i] = *dtos.NewTemplateResponseDTO(&template)
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Templates retrieved successfully", templateResponses)
+}
+
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Template retrieved successfully", dtos.NewTemplateResponseDTO(template))
+}
+
+func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
+	var templateDTO dtos.TemplateDTO
+	if err := c.ShouldBindJSON(&templateDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	templateDTO.ApplyTo(template)
+	if err := h.templateService.UpdateTemplate(template); err != nil {
+		appErr := errors.ErrUpdateTemplateFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Template updated successfully", dtos.NewTemplateResponseDTO(template))
+}
+
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.templateService.DeleteTemplate(template.ID, template.UserID); err != nil {
+		appErr := errors.ErrDeleteTemplateFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Template deleted successfully", nil)
+}
+
+func (h *TemplateHandler) InstantiateTemplate(c *gin.Context) {
+	var instantiateDTO dtos.InstantiateTemplateDTO
+	if err := c.ShouldBindJSON(&instantiateDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	anchor, err := instantiateDTO.Anchor(time.Now().UTC())
+	if err != nil {
+		appErr := errors.ErrInvalidAnchorDate
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.templateService.Instantiate(template, instantiateDTO.Variables, anchor)
+	if err != nil {
+		if missing, ok := err.(*services.MissingVariableError); ok {
+			appErr := errors.ErrMissingTemplateVariable
+			appErr.Details = missing
+			httputil.HandleError(c, appErr)
+			return
+		}
+		if invalid, ok := err.(*services.InvalidTitleError); ok {
+			appErr := errors.ErrInvalidTemplateTitle
+			appErr.Details = invalid
+			httputil.HandleError(c, appErr)
+			return
+		}
+		appErr := errors.ErrInstantiateTemplateFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusCreated, "Template instantiated successfully", dtos.NewTaskResponseDTO(task))
+}
+
+func (h *TemplateHandler) loadTemplate(c *gin.Context) (*models.TaskTemplate, bool) {
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		appErr := errors.ErrInvalidTemplateID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	template, err := h.templateService.GetTemplateByID(templateID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.HandleError(c, errors.ErrTemplateNotFound)
+			return nil, false
+		}
+		appErr := errors.ErrFetchTemplatesFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+
+	if template.UserID != userID {
+		httputil.HandleError(c, errors.ErrTemplateNotFound)
+		return nil, false
+	}
+
+	return template, true
+}
@@ -1,35 +1,94 @@
 package handlers
 
 import (
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/MohamedMosalm/Todo-App/config"
 	"github.com/MohamedMosalm/Todo-App/dtos"
 	"github.com/MohamedMosalm/Todo-App/models"
+	revocationRepository "github.com/MohamedMosalm/Todo-App/repositories/revocationRepository"
 	"github.com/MohamedMosalm/Todo-App/services"
 	"github.com/MohamedMosalm/Todo-App/utils/auth"
 	"github.com/MohamedMosalm/Todo-App/utils/errors"
 	"github.com/MohamedMosalm/Todo-App/utils/httputil"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
-	userService     services.UserService
-	jwtService      *auth.JWTService
-	passwordService *auth.PasswordService
+	userService              services.UserService
+	authenticator            services.Authenticator
+	jwtService               *auth.JWTService
+	passwordService          *auth.PasswordService
+	passwordPolicy           *auth.PasswordPolicy
+	refreshTokenService      services.RefreshTokenService
+	sessionService           services.SessionService
+	passwordResetService     services.PasswordResetService
+	emailVerificationService services.EmailVerificationService
+	mfaService               services.MFAService
+	loginAttemptService      services.LoginAttemptService
+	oidcService              services.OIDCService
+	magicLinkService         services.MagicLinkService
+	passkeyService           services.PasskeyService
+	revocationStore          revocationRepository.RevocationStore
+	requireVerifiedLogin     bool
+	mfaChallengeTTL          time.Duration
+	oidcLoginTTL             time.Duration
+	magicLinkTTL             time.Duration
+	passkeyCeremonyTTL       time.Duration
+	bindMagicLinks           bool
+	secureCookies            bool
 }
 
-func NewAuthHandler(userService services.UserService, config config.AppConfig) (*AuthHandler, error) {
-	jwtService, err := auth.NewJWTService(config.JWTSecret)
-	if err != nil {
-		return nil, err
-	}
+// AuthServices are the collaborators AuthHandler delegates to.
+type AuthServices struct {
+	Users             services.UserService
+	Authenticator     services.Authenticator
+	Tokens            *auth.JWTService
+	Passwords         *auth.PasswordService
+	PasswordPolicy    *auth.PasswordPolicy
+	RefreshTokens     services.RefreshTokenService
+	Sessions          services.SessionService
+	PasswordResets    services.PasswordResetService
+	EmailVerification services.EmailVerificationService
+	MFA               services.MFAService
+	LoginAttempts     services.LoginAttemptService
+	OIDC              services.OIDCService
+	MagicLinks        services.MagicLinkService
+	Passkeys          services.PasskeyService
+	Revocations       revocationRepository.RevocationStore
+}
 
+func NewAuthHandler(authServices AuthServices, config config.AppConfig) *AuthHandler {
 	return &AuthHandler{
-		userService:     userService,
-		jwtService:      jwtService,
-		passwordService: auth.NewPasswordService(),
-	}, nil
+		userService:              authServices.Users,
+		authenticator:            authServices.Authenticator,
+		jwtService:               authServices.Tokens,
+		passwordService:          authServices.Passwords,
+		passwordPolicy:           authServices.PasswordPolicy,
+		refreshTokenService:      authServices.RefreshTokens,
+		sessionService:           authServices.Sessions,
+		passwordResetService:     authServices.PasswordResets,
+		emailVerificationService: authServices.EmailVerification,
+		mfaService:               authServices.MFA,
+		loginAttemptService:      authServices.LoginAttempts,
+		oidcService:              authServices.OIDC,
+		magicLinkService:         authServices.MagicLinks,
+		passkeyService:           authServices.Passkeys,
+		revocationStore:          authServices.Revocations,
+		requireVerifiedLogin:     config.Auth.EmailVerificationPolicy == "block_login",
+		mfaChallengeTTL:          config.Auth.MFAChallengeTTL,
+		oidcLoginTTL:             config.Auth.OIDCLoginTTL,
+		magicLinkTTL:             config.Auth.MagicLinkTTL,
+		passkeyCeremonyTTL:       config.Auth.WebAuthn.CeremonyTTL,
+		bindMagicLinks:           config.Auth.MagicLinkBindDevice,
+		secureCookies:            strings.HasPrefix(config.BaseURL, "https://"),
+	}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -48,7 +107,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if !h.validatePassword(c, "password", registerDTO.Password, registerDTO.Email, registerDTO.FirstName, registerDTO.LastName) {
+		return
+	}
+
 	hashedPassword, err := h.passwordService.HashPassword(registerDTO.Password)
+	if err == auth.ErrPasswordTooLong {
+		httputil.HandleError(c, errors.ErrPasswordTooLong)
+		return
+	}
 	if err != nil {
 		appErr := errors.ErrRegistrationFailed
 		appErr.Details = err
@@ -71,6 +138,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// The account exists either way; the user can ask for a new link.
+	if err := h.emailVerificationService.SendVerification(c.Request.Context(), &user); err != nil {
+		log.Printf("sending verification email failed: %v", err)
+	}
+
 	httputil.SendSuccess(c, http.StatusCreated, "User registered successfully", nil)
 }
 
@@ -84,18 +156,132 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.FindUserByEmail(loginDTO.Email)
+	if !h.checkLoginAttempts(c, loginDTO.Email) {
+		return
+	}
+
+	user, err := h.authenticator.Authenticate(c.Request.Context(), loginDTO.Email, loginDTO.Password)
+	if err == services.ErrInvalidCredentials {
+		h.loginFailed(c, loginDTO.Email, errors.ErrInvalidCredentials)
+		return
+	}
+	if err != nil {
+		appErr := errors.ErrAuthenticationUnavailable
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if h.requireVerifiedLogin && user.EmailVerifiedAt == nil {
+		httputil.HandleError(c, errors.ErrEmailNotVerified)
+		return
+	}
+
+	h.signIn(c, user)
+}
+
+// signIn continues a login whose first factor checked out: users with
+// two-factor authentication or passkeys get a challenge, everyone else
+// their tokens. The challenge lists the methods that can answer it.
+func (h *AuthHandler) signIn(c *gin.Context, user *models.User) {
+	if !user.IsActive() {
+		httputil.HandleError(c, errors.ErrAccountDeactivated)
+		return
+	}
+
+	var mfaMethods []string
+	mfaEnabled, err := h.mfaService.IsEnabled(user.ID)
 	if err != nil {
-		httputil.HandleError(c, errors.ErrInvalidCredentials)
+		appErr := errors.ErrMFAFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
 		return
 	}
+	if mfaEnabled {
+		mfaMethods = append(mfaMethods, "totp")
+	}
+	hasPasskeys, err := h.passkeyService.HasPasskeys(user.ID)
+	if err != nil {
+		appErr := errors.ErrMFAFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+	if hasPasskeys {
+		mfaMethods = append(mfaMethods, "passkey")
+	}
 
-	if err := h.passwordService.ComparePasswords(user.Password, loginDTO.Password); err != nil {
-		httputil.HandleError(c, errors.ErrInvalidCredentials)
+	if len(mfaMethods) > 0 {
+		mfaToken, err := h.jwtService.GenerateMFAToken(user.ID, h.mfaChallengeTTL)
+		if err != nil {
+			appErr := errors.ErrTokenGenerationFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+			return
+		}
+		httputil.SendSuccess(c, http.StatusOK, "Two-factor authentication required", gin.H{
+			"mfa_required": true,
+			"mfa_methods":  mfaMethods,
+			"mfa_token":    mfaToken,
+			"expires_in":   int(h.mfaChallengeTTL.Seconds()),
+		})
 		return
 	}
 
-	token, err := h.jwtService.GenerateToken(user.ID)
+	h.completeLogin(c, user)
+}
+
+// validatePassword applies the password policy to the request field named
+// field, responding with the reasons it fails, and reports whether it passed.
+func (h *AuthHandler) validatePassword(c *gin.Context, field, password string, personalInfo ...string) bool {
+	err := h.passwordPolicy.Validate(password, personalInfo...)
+	if err == nil {
+		return true
+	}
+	if policyErr, ok := err.(*auth.PasswordPolicyError); ok {
+		httputil.HandleError(c, errors.ErrWeakPassword.WithFields(map[string][]string{field: policyErr.Reasons}))
+		return false
+	}
+	appErr := errors.ErrPasswordPolicyCheckFailed
+	appErr.Details = err
+	httputil.HandleError(c, appErr)
+	return false
+}
+
+// checkLoginAttempts refuses the request with Retry-After while email or
+// the client IP is locked out, and reports whether the login may go ahead.
+func (h *AuthHandler) checkLoginAttempts(c *gin.Context, email string) bool {
+	retryAfter, err := h.loginAttemptService.Check(email, c.ClientIP())
+	if err != nil {
+		appErr := errors.ErrLoginAttemptCheckFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return false
+	}
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		httputil.HandleError(c, errors.ErrAccountLocked)
+		return false
+	}
+	return true
+}
+
+// loginFailed counts a failed attempt for email and responds with appErr.
+func (h *AuthHandler) loginFailed(c *gin.Context, email string, appErr *errors.AppError) {
+	if err := h.loginAttemptService.RecordFailure(c.Request.Context(), email, c.ClientIP()); err != nil {
+		log.Printf("recording failed login failed: %v", err)
+	}
+	httputil.HandleError(c, appErr)
+}
+
+// completeLogin starts a session for a fully authenticated user and sends
+// its tokens.
+func (h *AuthHandler) completeLogin(c *gin.Context, user *models.User) {
+	if err := h.loginAttemptService.RecordSuccess(user.Email); err != nil {
+		log.Printf("clearing failed logins failed: %v", err)
+	}
+
+	tokens, err := h.startSession(c, user.ID)
 	if err != nil {
 		appErr := errors.ErrTokenGenerationFailed
 		appErr.Details = err
@@ -103,14 +289,299 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	httputil.SendSuccess(c, http.StatusOK, "Login successful", gin.H{
-		"access_token": token,
-		"token_type":   "Bearer",
-		"user": gin.H{
-			"id":         user.ID,
-			"email":      user.Email,
-			"first_name": user.FirstName,
-			"last_name":  user.LastName,
-		},
-	})
+	tokens["user"] = gin.H{
+		"id":         user.ID,
+		"email":      user.Email,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"role":       user.Role,
+	}
+	httputil.SendSuccess(c, http.StatusOK, "Login successful", tokens)
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var refreshDTO dtos.RefreshTokenDTO
+
+	if err := c.ShouldBindJSON(&refreshDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	refreshToken, record, err := h.refreshTokenService.Rotate(refreshDTO.RefreshToken)
+	switch {
+	case err == services.ErrInvalidRefreshToken:
+		httputil.HandleError(c, errors.ErrInvalidRefreshToken)
+		return
+	case err == services.ErrRefreshTokenReused:
+		httputil.HandleError(c, errors.ErrRefreshTokenReused)
+		return
+	case err != nil:
+		appErr := errors.ErrTokenGenerationFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	tokens, err := h.tokenResponse(record.UserID, record.FamilyID, refreshToken)
+	if err != nil {
+		appErr := errors.ErrTokenGenerationFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Token refreshed successfully", tokens)
+}
+
+// Logout revokes the access token used for the request and ends its
+// session, which also revokes the session's refresh tokens.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.GetString("session_id"))
+	if err != nil {
+		httputil.HandleError(c, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.revocationStore.RevokeToken(c.GetString("jti"), c.GetTime("token_expires_at")); err != nil {
+		appErr := errors.ErrLogoutFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(sessionID, userID); err != nil {
+		appErr := errors.ErrLogoutFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Logged out successfully", nil)
+}
+
+// LogoutAll ends every session of the user and revokes every access token
+// issued to them so far, signing out all devices including this one.
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.sessionService.RevokeAllSessions(userID); err != nil {
+		appErr := errors.ErrLogoutFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	now := time.Now()
+	if err := h.revocationStore.RevokeUserTokens(userID, now, now.Add(h.jwtService.AccessTokenTTL())); err != nil {
+		appErr := errors.ErrLogoutFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Logged out of all sessions", nil)
+}
+
+// ForgotPassword always answers 202 Accepted, whether or not the email
+// belongs to an account, so it cannot be used to discover accounts.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var forgotDTO dtos.ForgotPasswordDTO
+
+	if err := c.ShouldBindJSON(&forgotDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.passwordResetService.RequestReset(c.Request.Context(), forgotDTO.Email); err != nil {
+		log.Printf("password reset request failed: %v", err)
+	}
+
+	httputil.SendSuccess(c, http.StatusAccepted, "If the email belongs to an account, a reset link is on its way", nil)
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var resetDTO dtos.ResetPasswordDTO
+
+	if err := c.ShouldBindJSON(&resetDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.passwordResetService.ResetPassword(resetDTO.Token, resetDTO.Password); err != nil {
+		if err == services.ErrInvalidOneTimeToken {
+			httputil.HandleError(c, errors.ErrInvalidResetToken)
+			return
+		}
+		if err == auth.ErrPasswordTooLong {
+			httputil.HandleError(c, errors.ErrPasswordTooLong)
+			return
+		}
+		if policyErr, ok := err.(*auth.PasswordPolicyError); ok {
+			httputil.HandleError(c, errors.ErrWeakPassword.WithFields(map[string][]string{"password": policyErr.Reasons}))
+			return
+		}
+		appErr := errors.ErrPasswordResetFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Password reset successfully; please log in again", nil)
+}
+
+// ChangePassword replaces the password of the signed-in user, who must
+// confirm the current one. Users without a password, who set one here,
+// confirm with a second factor instead.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		appErr := errors.ErrInvalidUserID
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	var changeDTO dtos.ChangePasswordDTO
+	if err := c.ShouldBindJSON(&changeDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	user, err := h.userService.FindUserByID(userID)
+	if err != nil {
+		appErr := errors.ErrUserNotFound
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if user.Password == "" {
+		if !h.reauthenticateWithoutPassword(c, userID, changeDTO.Code, changeDTO.PasskeyCeremony, changeDTO.PasskeyCredential) {
+			return
+		}
+	} else if err := h.passwordService.ComparePasswords(user.Password, changeDTO.CurrentPassword); err != nil {
+		httputil.HandleError(c, errors.ErrIncorrectPassword)
+		return
+	}
+
+	if !h.validatePassword(c, "new_password", changeDTO.NewPassword, user.Email, user.FirstName, user.LastName) {
+		return
+	}
+
+	hashedPassword, err := h.passwordService.HashPassword(changeDTO.NewPassword)
+	if err == auth.ErrPasswordTooLong {
+		httputil.HandleError(c, errors.ErrPasswordTooLong)
+		return
+	}
+	if err != nil {
+		appErr := errors.ErrChangePasswordFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.userService.UpdatePassword(userID, hashedPassword); err != nil {
+		appErr := errors.ErrChangePasswordFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Password changed successfully", nil)
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var verifyDTO dtos.VerifyEmailDTO
+
+	if err := c.ShouldBindJSON(&verifyDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.emailVerificationService.VerifyEmail(verifyDTO.Token); err != nil {
+		if err == services.ErrInvalidOneTimeToken {
+			httputil.HandleError(c, errors.ErrInvalidVerificationToken)
+			return
+		}
+		appErr := errors.ErrEmailVerificationFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	httputil.SendSuccess(c, http.StatusOK, "Email verified successfully", nil)
+}
+
+// ResendVerification answers 202 Accepted for any address, like
+// ForgotPassword, so it reveals nothing about which accounts exist.
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var resendDTO dtos.ResendVerificationDTO
+
+	if err := c.ShouldBindJSON(&resendDTO); err != nil {
+		appErr := errors.ErrInvalidRequest
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return
+	}
+
+	if err := h.emailVerificationService.ResendVerification(c.Request.Context(), resendDTO.Email); err != nil {
+		log.Printf("resending verification email failed: %v", err)
+	}
+
+	httputil.SendSuccess(c, http.StatusAccepted, "If the email belongs to an unverified account, a new link is on its way", nil)
+}
+
+// startSession records a new login of userID from the requesting device and
+// returns the first tokens of the session.
+func (h *AuthHandler) startSession(c *gin.Context, userID uuid.UUID) (gin.H, error) {
+	session, err := h.sessionService.CreateSession(userID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := h.refreshTokenService.Issue(userID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.tokenResponse(userID, session.ID, refreshToken)
+}
+
+// tokenResponse pairs refreshToken with a new access token for userID.
+func (h *AuthHandler) tokenResponse(userID, sessionID uuid.UUID, refreshToken string) (gin.H, error) {
+	accessToken, err := h.jwtService.GenerateToken(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(h.jwtService.AccessTokenTTL().Seconds()),
+	}, nil
 }
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes takes the JWT-only authMiddleware and refuses
+// impersonation tokens; each route then requires the permissions it needs.
+func SetupAdminRoutes(router *gin.Engine, adminHandler *handlers.AdminHandler, authMiddleware gin.HandlerFunc, roles services.RoleService) {
+	can := func(permissions ...string) gin.HandlerFunc {
+		return middleware.RequirePermission(roles, permissions...)
+	}
+
+	adminRoutes := router.Group("/api/admin")
+	adminRoutes.Use(authMiddleware, middleware.ForbidImpersonation())
+	{
+		adminRoutes.GET("/users", can(models.PermissionUsersRead), adminHandler.ListUsers)
+		adminRoutes.GET("/users/:id", can(models.PermissionUsersRead), adminHandler.GetUser)
+		adminRoutes.POST("/users/:id/deactivate", can(models.PermissionUsersManage), adminHandler.DeactivateUser)
+		adminRoutes.POST("/users/:id/reactivate", can(models.PermissionUsersManage), adminHandler.ReactivateUser)
+		adminRoutes.POST("/users/:id/password-reset", can(models.PermissionUsersManage), adminHandler.ForcePasswordReset)
+		adminRoutes.DELETE("/users/:id/sessions", can(models.PermissionUsersManage), adminHandler.RevokeSessions)
+		adminRoutes.PUT("/users/:id/role", can(models.PermissionUsersManage), adminHandler.ChangeRole)
+		adminRoutes.POST("/users/:id/impersonate", can(models.PermissionUsersImpersonate), adminHandler.ImpersonateUser)
+		adminRoutes.GET("/roles", can(models.PermissionRolesManage), adminHandler.GetRoles)
+		adminRoutes.POST("/roles", can(models.PermissionRolesManage), adminHandler.CreateRole)
+		adminRoutes.PUT("/roles/:name", can(models.PermissionRolesManage), adminHandler.UpdateRole)
+		adminRoutes.DELETE("/roles/:name", can(models.PermissionRolesManage), adminHandler.DeleteRole)
+		adminRoutes.GET("/audit", can(models.PermissionAuditRead), adminHandler.ListAuditEvents)
+	}
+}
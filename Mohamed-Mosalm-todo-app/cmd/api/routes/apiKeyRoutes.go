@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAPIKeyRoutes takes the JWT-only authMiddleware so that an API key can
+// never be used to mint or revoke keys, and neither can an impersonation
+// token.
+func SetupAPIKeyRoutes(router *gin.Engine, apiKeyHandler *handlers.APIKeyHandler, authMiddleware gin.HandlerFunc) {
+	apiKeyRoutes := router.Group("/api/keys")
+	apiKeyRoutes.Use(authMiddleware, middleware.ForbidImpersonation())
+	{
+		apiKeyRoutes.POST("", apiKeyHandler.CreateAPIKey)
+		apiKeyRoutes.GET("", apiKeyHandler.GetAPIKeys)
+		apiKeyRoutes.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+	}
+}
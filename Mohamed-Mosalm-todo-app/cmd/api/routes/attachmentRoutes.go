@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAttachmentRoutes(router *gin.Engine, attachmentHandler *handlers.AttachmentHandler, authMiddleware gin.HandlerFunc) {
+	attachmentRoutes := router.Group("/api/tasks/:id/attachments")
+	attachmentRoutes.Use(authMiddleware)
+	{
+		attachmentRoutes.POST("", middleware.RequireScope(models.ScopeTasksWrite), attachmentHandler.UploadAttachment)
+		attachmentRoutes.GET("", middleware.RequireScope(models.ScopeTasksRead), attachmentHandler.GetAttachments)
+		attachmentRoutes.GET("/:attachment_id", middleware.RequireScope(models.ScopeTasksRead), attachmentHandler.DownloadAttachment)
+		attachmentRoutes.DELETE("/:attachment_id", middleware.RequireScope(models.ScopeTasksWrite), attachmentHandler.DeleteAttachment)
+	}
+}
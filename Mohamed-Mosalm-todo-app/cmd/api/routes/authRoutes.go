@@ -2,13 +2,46 @@ package routes
 
 import (
 	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupAuthRoutes(router *gin.Engine, authHandler *handlers.AuthHandler) {
+// SetupAuthRoutes keeps impersonation tokens away from the routes that
+// change how the user signs in or end the admin's session.
+func SetupAuthRoutes(router *gin.Engine, authHandler *handlers.AuthHandler, sessionHandler *handlers.SessionHandler, authMiddleware gin.HandlerFunc) {
+	notImpersonating := middleware.ForbidImpersonation()
+
 	authRoutes := router.Group("/api/auth")
 	{
 		authRoutes.POST("/register", authHandler.Register)
 		authRoutes.POST("/login", authHandler.Login)
+		authRoutes.POST("/refresh", authHandler.Refresh)
+		authRoutes.POST("/password/forgot", authHandler.ForgotPassword)
+		authRoutes.POST("/password/reset", authHandler.ResetPassword)
+		authRoutes.POST("/magic-link", authHandler.RequestMagicLink)
+		authRoutes.POST("/magic-link/consume", authHandler.ConsumeMagicLink)
+		authRoutes.POST("/verify-email", authHandler.VerifyEmail)
+		authRoutes.POST("/verify-email/resend", authHandler.ResendVerification)
+		authRoutes.POST("/2fa/verify", authHandler.VerifyMFA)
+		authRoutes.POST("/2fa/passkey/begin", authHandler.BeginPasskeyMFA)
+		authRoutes.POST("/2fa/passkey/verify", authHandler.VerifyPasskeyMFA)
+		authRoutes.POST("/passkeys/login/begin", authHandler.BeginPasskeyLogin)
+		authRoutes.POST("/passkeys/login/finish", authHandler.FinishPasskeyLogin)
+		authRoutes.GET("/oidc/providers", authHandler.GetOIDCProviders)
+		authRoutes.GET("/oidc/:provider/login", authHandler.BeginOIDCLogin)
+		authRoutes.GET("/oidc/:provider/callback", authHandler.OIDCCallback)
+		authRoutes.POST("/password/change", authMiddleware, notImpersonating, authHandler.ChangePassword)
+		authRoutes.POST("/2fa/enroll", authMiddleware, notImpersonating, authHandler.EnrollMFA)
+		authRoutes.POST("/2fa/confirm", authMiddleware, notImpersonating, authHandler.ConfirmMFA)
+		authRoutes.POST("/2fa/disable", authMiddleware, notImpersonating, authHandler.DisableMFA)
+		authRoutes.POST("/passkeys/register/begin", authMiddleware, notImpersonating, authHandler.BeginPasskeyRegistration)
+		authRoutes.POST("/passkeys/register/finish", authMiddleware, notImpersonating, authHandler.FinishPasskeyRegistration)
+		authRoutes.POST("/passkeys/reauth/begin", authMiddleware, notImpersonating, authHandler.BeginPasskeyReauthentication)
+		authRoutes.GET("/passkeys", authMiddleware, authHandler.GetPasskeys)
+		authRoutes.DELETE("/passkeys/:id", authMiddleware, notImpersonating, authHandler.DeletePasskey)
+		authRoutes.POST("/logout", authMiddleware, notImpersonating, authHandler.Logout)
+		authRoutes.POST("/logout-all", authMiddleware, notImpersonating, authHandler.LogoutAll)
+		authRoutes.GET("/sessions", authMiddleware, sessionHandler.GetSessions)
+		authRoutes.DELETE("/sessions/:id", authMiddleware, notImpersonating, sessionHandler.RevokeSession)
 	}
 }
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupFilterRoutes(router *gin.Engine, filterHandler *handlers.FilterHandler, authMiddleware gin.HandlerFunc) {
+	filterRoutes := router.Group("/api/filters")
+	filterRoutes.Use(authMiddleware)
+	{
+		filterRoutes.POST("", middleware.RequireScope(models.ScopeFiltersWrite), filterHandler.CreateFilter)
+		filterRoutes.GET("", middleware.RequireScope(models.ScopeFiltersRead), filterHandler.GetFilters)
+		filterRoutes.GET("/:id", middleware.RequireScope(models.ScopeFiltersRead), filterHandler.GetFilter)
+		filterRoutes.PUT("/:id", middleware.RequireScope(models.ScopeFiltersWrite), filterHandler.UpdateFilter)
+		filterRoutes.DELETE("/:id", middleware.RequireScope(models.ScopeFiltersWrite), filterHandler.DeleteFilter)
+		filterRoutes.GET("/:id/tasks", middleware.RequireScope(models.ScopeFiltersRead, models.ScopeTasksRead), filterHandler.GetFilterTasks)
+	}
+}
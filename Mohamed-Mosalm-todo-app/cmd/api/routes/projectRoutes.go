@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupProjectRoutes(router *gin.Engine, projectHandler *handlers.ProjectHandler, authMiddleware gin.HandlerFunc) {
+	projectRoutes := router.Group("/api/projects")
+	projectRoutes.Use(authMiddleware)
+	{
+		projectRoutes.POST("", middleware.RequireScope(models.ScopeProjectsWrite), projectHandler.CreateProject)
+		projectRoutes.GET("", middleware.RequireScope(models.ScopeProjectsRead), projectHandler.GetProjects)
+		projectRoutes.GET("/:id/board", middleware.RequireScope(models.ScopeProjectsRead, models.ScopeTasksRead), projectHandler.GetBoard)
+		projectRoutes.PUT("/:id/board/columns", middleware.RequireScope(models.ScopeProjectsWrite), projectHandler.UpdateColumns)
+		projectRoutes.PUT("/:id/board/tasks/:task_id", middleware.RequireScope(models.ScopeTasksWrite), projectHandler.MoveTask)
+		projectRoutes.GET("/:id/fields", middleware.RequireScope(models.ScopeProjectsRead), projectHandler.GetFields)
+		projectRoutes.PUT("/:id/fields", middleware.RequireScope(models.ScopeProjectsWrite), projectHandler.UpdateFields)
+	}
+}
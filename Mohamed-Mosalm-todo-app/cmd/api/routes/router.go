@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/gin-gonic/gin"
+)
+
+// NewRouter returns the engine the routes are set up on. Only the
+// configured proxies may pass the client IP in X-Forwarded-For.
+func NewRouter(config config.AppConfig) (*gin.Engine, error) {
+	router := gin.Default()
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/MohamedMosalm/Todo-App/models"
+	loginAttemptRepository "github.com/MohamedMosalm/Todo-App/repositories/loginAttemptRepository"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rejectingAuthenticator turns every login down.
+type rejectingAuthenticator struct{}
+
+func (rejectingAuthenticator) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	return nil, services.ErrInvalidCredentials
+}
+
+// loginStatuses sends one failed login per forwarded address, each for a
+// different account so that only the IP counter can lock them out.
+func loginStatuses(t *testing.T, cfg config.AppConfig, forwardedFor ...string) []int {
+	gin.SetMode(gin.TestMode)
+	router, err := NewRouter(cfg)
+	require.NoError(t, err)
+
+	loginAttempts := services.NewLoginAttemptService(loginAttemptRepository.NewMemoryLoginAttemptStore(), nil, nil, services.LockoutPolicy{
+		MaxAccountFailures: 5,
+		MaxIPFailures:      2,
+		BaseLockout:        time.Minute,
+		MaxLockout:         time.Hour,
+		FailureWindow:      time.Hour,
+	})
+	authHandler := handlers.NewAuthHandler(handlers.AuthServices{Authenticator: rejectingAuthenticator{}, LoginAttempts: loginAttempts}, cfg)
+	router.POST("/api/auth/login", authHandler.Login)
+
+	var statuses []int
+	for i, ip := range forwardedFor {
+		body := fmt.Sprintf(`{"email":"user%d@example.com","password":"guess"}`, i)
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", ip)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		statuses = append(statuses, resp.Code)
+	}
+	return statuses
+}
+
+func TestRouterIgnoresSpoofedForwardedFor(t *testing.T) {
+	// Without trusted proxies, a new X-Forwarded-For on every request does
+	// not give the client a fresh IP counter.
+	statuses := loginStatuses(t, config.AppConfig{}, "203.0.113.1", "203.0.113.2", "203.0.113.3")
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}, statuses)
+
+	// Behind a trusted proxy (httptest requests come from 192.0.2.1), the
+	// header does tell clients apart.
+	statuses = loginStatuses(t, config.AppConfig{TrustedProxies: []string{"192.0.2.1"}}, "203.0.113.1", "203.0.113.2", "203.0.113.3")
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized}, statuses)
+
+	_, err := NewRouter(config.AppConfig{TrustedProxies: []string{"not-an-ip"}})
+	assert.Error(t, err)
+}
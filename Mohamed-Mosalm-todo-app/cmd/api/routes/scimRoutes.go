@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupSCIMRoutes mounts the SCIM 2.0 Users endpoint behind scimAuth, which
+// checks the provisioning client's token rather than a user's.
+func SetupSCIMRoutes(router *gin.Engine, scimHandler *handlers.SCIMHandler, scimAuth gin.HandlerFunc) {
+	scimRoutes := router.Group("/scim/v2/Users")
+	scimRoutes.Use(scimAuth)
+	{
+		scimRoutes.POST("", scimHandler.CreateUser)
+		scimRoutes.GET("", scimHandler.ListUsers)
+		scimRoutes.GET("/:id", scimHandler.GetUser)
+		scimRoutes.PATCH("/:id", scimHandler.PatchUser)
+		scimRoutes.DELETE("/:id", scimHandler.DeleteUser)
+	}
+}
@@ -2,17 +2,18 @@ package routes
 
 import (
 	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/MohamedMosalm/Todo-App/models"
 	"github.com/MohamedMosalm/Todo-App/utils/middleware"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupTaskRoutes(router *gin.Engine, taskHandler *handlers.TaskHandler, jwtSecret string) {
+func SetupTaskRoutes(router *gin.Engine, taskHandler *handlers.TaskHandler, authMiddleware, requireVerifiedEmail gin.HandlerFunc) {
 	taskRoutes := router.Group("/api/tasks")
-	taskRoutes.Use(middleware.AuthMiddleware(jwtSecret))
+	taskRoutes.Use(authMiddleware)
 	{
-		taskRoutes.POST("", taskHandler.CreateTask)
-		taskRoutes.GET("", taskHandler.GetTasks)
-		taskRoutes.PUT("/:id", taskHandler.UpdateTask)
-		taskRoutes.DELETE("/:id", taskHandler.DeleteTask)
+		taskRoutes.POST("", middleware.RequireScope(models.ScopeTasksWrite), requireVerifiedEmail, taskHandler.CreateTask)
+		taskRoutes.GET("", middleware.RequireScope(models.ScopeTasksRead), taskHandler.GetTasks)
+		taskRoutes.PUT("/:id", middleware.RequireScope(models.ScopeTasksWrite), taskHandler.UpdateTask)
+		taskRoutes.DELETE("/:id", middleware.RequireScope(models.ScopeTasksWrite), taskHandler.DeleteTask)
 	}
 }
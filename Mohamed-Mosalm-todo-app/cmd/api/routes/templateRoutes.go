@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTemplateRoutes(router *gin.Engine, templateHandler *handlers.TemplateHandler, authMiddleware, requireVerifiedEmail gin.HandlerFunc) {
+	templateRoutes := router.Group("/api/templates")
+	templateRoutes.Use(authMiddleware)
+	{
+		templateRoutes.POST("", middleware.RequireScope(models.ScopeTemplatesWrite), templateHandler.CreateTemplate)
+		templateRoutes.GET("", middleware.RequireScope(models.ScopeTemplatesRead), templateHandler.GetTemplates)
+		templateRoutes.GET("/:id", middleware.RequireScope(models.ScopeTemplatesRead), templateHandler.GetTemplate)
+		templateRoutes.PUT("/:id", middleware.RequireScope(models.ScopeTemplatesWrite), templateHandler.UpdateTemplate)
+		templateRoutes.DELETE("/:id", middleware.RequireScope(models.ScopeTemplatesWrite), templateHandler.DeleteTemplate)
+		templateRoutes.POST("/:id/instantiate", middleware.RequireScope(models.ScopeTemplatesRead, models.ScopeTasksWrite), requireVerifiedEmail, templateHandler.InstantiateTemplate)
+	}
+}
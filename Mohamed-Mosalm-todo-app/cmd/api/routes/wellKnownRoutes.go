@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/MohamedMosalm/Todo-App/cmd/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupWellKnownRoutes(router *gin.Engine, jwksHandler *handlers.JWKSHandler) {
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+}
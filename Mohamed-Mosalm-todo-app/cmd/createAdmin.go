@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/MohamedMosalm/Todo-App/database"
+	"github.com/MohamedMosalm/Todo-App/models"
+	auditRepository "github.com/MohamedMosalm/Todo-App/repositories/auditRepository"
+	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"gorm.io/gorm"
+)
+
+// RunCommand runs the command-line subcommand named by args[0].
+func RunCommand(config config.AppConfig, args []string) error {
+	switch args[0] {
+	case "create-admin":
+		return createAdmin(config, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q; the only command is create-admin", args[0])
+	}
+}
+
+// createAdmin gives an existing user the admin role, or creates a verified
+// admin with a random password that is printed once. It is how the first
+// admin is made, since only admins can grant roles through the API.
+func createAdmin(config config.AppConfig, args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	email := flags.String("email", "", "email address of the admin (required)")
+	firstName := flags.String("first-name", "Admin", "first name, if the user is created")
+	lastName := flags.String("last-name", "User", "last name, if the user is created")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		flags.Usage()
+		return fmt.Errorf("-email is required")
+	}
+
+	db, err := database.ConnectDB(config.DSN)
+	if err != nil {
+		return fmt.Errorf("could not connect to the database: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		return fmt.Errorf("database migration failed: %w", err)
+	}
+	userRepo := userRepository.NewGormUserRepository(db)
+	auditRepo := auditRepository.NewGormAuditRepository(db)
+
+	user, err := userRepo.FindUserByEmail(*email)
+	switch {
+	case err == nil:
+		if user.Role == models.RoleAdmin {
+			fmt.Printf("%s is already an admin\n", user.Email)
+			return nil
+		}
+		if err := userRepo.UpdateUser(user.ID, map[string]interface{}{"role": models.RoleAdmin}); err != nil {
+			return err
+		}
+		fmt.Printf("%s is now an admin\n", user.Email)
+		return auditRepo.CreateEvent(&models.AuditEvent{
+			Action:   models.AuditUserRoleChange,
+			TargetID: &user.ID,
+			Details:  models.AuditDetails{"via": "cli", "from": user.Role, "to": models.RoleAdmin},
+		})
+	case err != gorm.ErrRecordNotFound:
+		return err
+	}
+
+	hasher, err := auth.NewPasswordHasher(config.Password)
+	if err != nil {
+		return err
+	}
+	password, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := auth.NewPasswordService(hasher).HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user = &models.User{
+		FirstName:       *firstName,
+		LastName:        *lastName,
+		Email:           *email,
+		Password:        hashedPassword,
+		Role:            models.RoleAdmin,
+		EmailVerifiedAt: &now,
+	}
+	if err := userRepo.CreateUser(user); err != nil {
+		return err
+	}
+	fmt.Printf("Created admin %s with password %s\nChange the password after logging in; it is not shown again.\n", user.Email, password)
+	return auditRepo.CreateEvent(&models.AuditEvent{
+		Action:   models.AuditUserCreate,
+		TargetID: &user.ID,
+		Details:  models.AuditDetails{"via": "cli", "role": models.RoleAdmin},
+	})
+}
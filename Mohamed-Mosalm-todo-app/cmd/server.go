@@ -8,39 +8,269 @@ import (
 	"github.com/MohamedMosalm/Todo-App/config"
 	"github.com/MohamedMosalm/Todo-App/database"
 	"github.com/MohamedMosalm/Todo-App/models"
+	apiKeyRepository "github.com/MohamedMosalm/Todo-App/repositories/apiKeyRepository"
+	attachmentRepository "github.com/MohamedMosalm/Todo-App/repositories/attachmentRepository"
+	auditRepository "github.com/MohamedMosalm/Todo-App/repositories/auditRepository"
+	filterRepository "github.com/MohamedMosalm/Todo-App/repositories/filterRepository"
+	loginAttemptRepository "github.com/MohamedMosalm/Todo-App/repositories/loginAttemptRepository"
+	mfaRepository "github.com/MohamedMosalm/Todo-App/repositories/mfaRepository"
+	oidcRepository "github.com/MohamedMosalm/Todo-App/repositories/oidcRepository"
+	oneTimeTokenRepository "github.com/MohamedMosalm/Todo-App/repositories/oneTimeTokenRepository"
+	passkeyRepository "github.com/MohamedMosalm/Todo-App/repositories/passkeyRepository"
+	projectRepository "github.com/MohamedMosalm/Todo-App/repositories/projectRepository"
+	refreshTokenRepository "github.com/MohamedMosalm/Todo-App/repositories/refreshTokenRepository"
+	revocationRepository "github.com/MohamedMosalm/Todo-App/repositories/revocationRepository"
+	roleRepository "github.com/MohamedMosalm/Todo-App/repositories/roleRepository"
+	sessionRepository "github.com/MohamedMosalm/Todo-App/repositories/sessionRepository"
 	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+	templateRepository "github.com/MohamedMosalm/Todo-App/repositories/templateRepository"
 	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
 	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/directory"
+	"github.com/MohamedMosalm/Todo-App/utils/mailer"
+	"github.com/MohamedMosalm/Todo-App/utils/middleware"
+	"github.com/MohamedMosalm/Todo-App/utils/passkey"
+	"github.com/MohamedMosalm/Todo-App/utils/sso"
+	"github.com/MohamedMosalm/Todo-App/utils/storage"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func StartServer(config config.AppConfig) {
-	r := gin.Default()
+	r, err := routes.NewRouter(config)
+	if err != nil {
+		log.Fatalf("could not configure trusted proxies: %v\n", err)
+	}
 
 	db, err := database.ConnectDB(config.DSN)
 	if err != nil {
 		log.Fatalf("could not connect to the database: %v\n", err)
 	}
 
-	if err := database.AutoMigrate(db, &models.User{}, &models.Task{}); err != nil {
+	if err := migrate(db); err != nil {
 		log.Fatalf("database migration failed: %v\n", err)
 	}
 
+	var revocationStore revocationRepository.RevocationStore
+	if config.Auth.RevocationStore == "memory" {
+		revocationStore = revocationRepository.NewMemoryRevocationStore()
+	} else {
+		revocationStore = revocationRepository.NewGormRevocationStore(db)
+	}
+	stopCleanup := revocationRepository.StartCleanup(revocationStore, config.Auth.RevocationCleanupInterval)
+	defer stopCleanup()
+
+	sessionRepo := sessionRepository.NewGormSessionRepository(db)
+	sessionService := services.NewSessionService(sessionRepo, config.Auth.SessionTouchInterval)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	signingKeys, err := auth.LoadKeySet(config)
+	if err != nil {
+		log.Fatalf("could not load JWT signing keys: %v\n", err)
+	}
+	jwtService, err := auth.NewJWTService(signingKeys, auth.JWTOptions{
+		Issuer:         config.Auth.TokenIssuer,
+		Audience:       config.Auth.TokenAudience,
+		AccessTokenTTL: config.Auth.AccessTokenTTL,
+		Leeway:         config.Auth.TokenLeeway,
+	})
+	if err != nil {
+		log.Fatalf("could not set up JWT signing: %v\n", err)
+	}
+	jwksHandler := handlers.NewJWKSHandler(signingKeys)
+
+	userRepo := userRepository.NewGormUserRepository(db)
+	userService := services.NewUserService(userRepo, sessionService)
+	roleService := services.NewRoleService(roleRepository.NewGormRoleRepository(db))
+	auditService := services.NewAuditService(auditRepository.NewGormAuditRepository(db))
+	authMiddleware := middleware.AuthMiddleware(jwtService, revocationStore, sessionService, userService, auditService)
+
+	apiKeyRepo := apiKeyRepository.NewGormAPIKeyRepository(db)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, config.Auth.SessionTouchInterval)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	// Resource routes also take API keys; account routes stay JWT-only.
+	resourceAuth := middleware.AcceptAPIKeys(apiKeyService, userService, authMiddleware)
+
+	projectRepo := projectRepository.NewGormProjectRepository(db)
+	projectService := services.NewProjectService(projectRepo)
+
+	blobStore, err := storage.NewBlobStore(config.Storage)
+	if err != nil {
+		log.Fatalf("could not set up attachment storage: %v\n", err)
+	}
+
+	attachmentRepo := attachmentRepository.NewGormAttachmentRepository(db)
+	attachmentService := services.NewAttachmentService(attachmentRepo, blobStore, services.AttachmentLimits{
+		MaxSize:      config.Storage.MaxAttachmentSize,
+		AllowedTypes: config.Storage.AllowedMIMETypes,
+		UserQuota:    config.Storage.UserQuota,
+	})
+
 	taskRepo := taskRepository.NewGormTaskRepository(db)
-	taskService := services.NewTaskService(taskRepo)
+	taskService := services.NewTaskService(taskRepo, projectRepo, attachmentService)
 	taskHandler := handlers.NewTaskHandler(taskService, config)
+	projectHandler := handlers.NewProjectHandler(projectService, taskService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, taskService)
 
-	userRepo := userRepository.NewGormUserRepository(db)
-	userService := services.NewUserService(userRepo)
-	userHandler, err := handlers.NewAuthHandler(userService, config)
+	filterRepo := filterRepository.NewGormFilterRepository(db)
+	filterService := services.NewFilterService(filterRepo, taskService)
+	filterHandler := handlers.NewFilterHandler(filterService)
+
+	templateRepo := templateRepository.NewGormTemplateRepository(db)
+	templateService := services.NewTemplateService(templateRepo, taskRepo)
+	templateHandler := handlers.NewTemplateHandler(templateService)
+
+	refreshTokenRepo := refreshTokenRepository.NewGormRefreshTokenRepository(db)
+	refreshTokenService := services.NewRefreshTokenService(refreshTokenRepo, config.Auth.RefreshTokenTTL)
+
+	passwordHasher, err := auth.NewPasswordHasher(config.Password)
+	if err != nil {
+		log.Fatalf("could not set up password hashing: %v\n", err)
+	}
+	passwordService := auth.NewPasswordService(passwordHasher)
+	passwordPolicy, err := auth.NewPasswordPolicy(config.Password)
+	if err != nil {
+		log.Fatalf("could not set up the password policy: %v\n", err)
+	}
+
+	mail, err := mailer.NewMailer(config.Mail)
 	if err != nil {
-		log.Fatalf("Failed to create auth handler: %v", err)
+		log.Fatalf("could not set up mail delivery: %v\n", err)
 	}
+	mail = mailer.NewAsyncMailer(mail)
 
-	routes.SetupAuthRoutes(r, userHandler)
-	routes.SetupTaskRoutes(r, taskHandler, config.JWTSecret)
+	oneTimeTokenRepo := oneTimeTokenRepository.NewGormOneTimeTokenRepository(db)
+	oneTimeTokenService := services.NewOneTimeTokenService(oneTimeTokenRepo)
+	passwordResetService := services.NewPasswordResetService(userRepo, oneTimeTokenService, sessionService, passwordService, passwordPolicy, mail, config.BaseURL, config.Auth.PasswordResetTTL)
+
+	emailVerificationService := services.NewEmailVerificationService(userRepo, oneTimeTokenService, mail, config.BaseURL, config.Auth.EmailVerificationTTL)
+
+	mfaRepo := mfaRepository.NewGormMFARepository(db)
+	mfaService := services.NewMFAService(mfaRepo, config.Auth.MFAIssuer)
+
+	var loginAttemptStore loginAttemptRepository.LoginAttemptStore
+	if config.Auth.LoginAttemptStore == "memory" {
+		loginAttemptStore = loginAttemptRepository.NewMemoryLoginAttemptStore()
+	} else {
+		loginAttemptStore = loginAttemptRepository.NewGormLoginAttemptStore(db)
+	}
+	stopAttemptCleanup := loginAttemptRepository.StartCleanup(loginAttemptStore, config.Auth.LoginAttemptCleanupInterval)
+	defer stopAttemptCleanup()
+	loginAttemptService := services.NewLoginAttemptService(loginAttemptStore, userRepo, mail, services.LockoutPolicy{
+		MaxAccountFailures: config.Auth.LoginMaxFailures,
+		MaxIPFailures:      config.Auth.LoginIPMaxFailures,
+		BaseLockout:        config.Auth.LoginLockoutBase,
+		MaxLockout:         config.Auth.LoginLockoutMax,
+		FailureWindow:      config.Auth.LoginFailureWindow,
+	})
+
+	oidcRepo := oidcRepository.NewGormOIDCRepository(db)
+	authenticators := make([]services.Authenticator, len(config.Auth.Backends))
+	for i, backend := range config.Auth.Backends {
+		switch backend {
+		case "local":
+			authenticators[i] = services.NewPasswordAuthenticator(userService, passwordService)
+		case "ldap":
+			authenticators[i] = services.NewLDAPAuthenticator(directory.NewDirectory(config.Auth.LDAP), userRepo, oidcRepo, auditService, ldapRoleMapping(config.Auth.LDAP, roleService))
+		}
+	}
+
+	oidcProviders := make([]*sso.Provider, len(config.Auth.OIDCProviders))
+	for i, providerConfig := range config.Auth.OIDCProviders {
+		oidcProviders[i] = sso.NewProvider(providerConfig, config.BaseURL+"/api/auth/oidc/"+providerConfig.Name+"/callback")
+	}
+	oidcService := services.NewOIDCService(oidcProviders, oidcRepo, userRepo, config.Auth.OIDCLoginTTL)
+
+	magicLinkService := services.NewMagicLinkService(userRepo, oneTimeTokenService, loginAttemptStore, mail, config.BaseURL, services.MagicLinkPolicy{
+		TTL:           config.Auth.MagicLinkTTL,
+		MaxRequests:   config.Auth.MagicLinkMaxRequests,
+		RequestWindow: config.Auth.MagicLinkRequestWindow,
+	})
+
+	relyingParty, err := passkey.NewRelyingParty(config.Auth.WebAuthn)
+	if err != nil {
+		log.Fatalf("could not set up WebAuthn: %v\n", err)
+	}
+	passkeyService := services.NewPasskeyService(relyingParty, passkeyRepository.NewGormPasskeyRepository(db), userRepo, config.Auth.WebAuthn.CeremonyTTL)
+
+	requireVerifiedEmail := func(c *gin.Context) { c.Next() }
+	if config.Auth.EmailVerificationPolicy == "block_tasks" {
+		requireVerifiedEmail = middleware.RequireVerifiedEmail(userService)
+	}
+
+	userHandler := handlers.NewAuthHandler(handlers.AuthServices{
+		Users:             userService,
+		Authenticator:     services.NewChainAuthenticator(authenticators...),
+		Tokens:            jwtService,
+		Passwords:         passwordService,
+		PasswordPolicy:    passwordPolicy,
+		RefreshTokens:     refreshTokenService,
+		Sessions:          sessionService,
+		PasswordResets:    passwordResetService,
+		EmailVerification: emailVerificationService,
+		MFA:               mfaService,
+		LoginAttempts:     loginAttemptService,
+		OIDC:              oidcService,
+		MagicLinks:        magicLinkService,
+		Passkeys:          passkeyService,
+		Revocations:       revocationStore,
+	}, config)
+
+	adminHandler := handlers.NewAdminHandler(handlers.AdminServices{
+		Users:          userService,
+		Roles:          roleService,
+		Audit:          auditService,
+		Tasks:          taskService,
+		Sessions:       sessionService,
+		PasswordResets: passwordResetService,
+		LoginAttempts:  loginAttemptService,
+		Tokens:         jwtService,
+		Revocations:    revocationStore,
+	}, config)
+
+	routes.SetupWellKnownRoutes(r, jwksHandler)
+	routes.SetupAuthRoutes(r, userHandler, sessionHandler, authMiddleware)
+	routes.SetupAPIKeyRoutes(r, apiKeyHandler, authMiddleware)
+	routes.SetupAdminRoutes(r, adminHandler, authMiddleware, roleService)
+	routes.SetupTaskRoutes(r, taskHandler, resourceAuth, requireVerifiedEmail)
+	routes.SetupProjectRoutes(r, projectHandler, resourceAuth)
+	routes.SetupTemplateRoutes(r, templateHandler, resourceAuth, requireVerifiedEmail)
+	routes.SetupAttachmentRoutes(r, attachmentHandler, resourceAuth)
+	routes.SetupFilterRoutes(r, filterHandler, resourceAuth)
+	if config.Auth.SCIMToken != "" {
+		routes.SetupSCIMRoutes(r, handlers.NewSCIMHandler(userService, config), middleware.SCIMAuth(config.Auth.SCIMToken))
+	}
 
 	if err := r.Run(config.ServerPort); err != nil {
 		log.Fatalf("could not start server: %v\n", err)
 	}
 }
+
+// migrate creates or updates the tables of every model.
+func migrate(db *gorm.DB) error {
+	return database.AutoMigrate(db, models.All()...)
+}
+
+// ldapRoleMapping turns the configured group roles into the services' role
+// mapping, refusing to start with a role that does not exist.
+func ldapRoleMapping(ldapConfig config.LDAPConfig, roleService services.RoleService) services.RoleMapping {
+	isRole := func(name string) bool {
+		_, err := roleService.GetRole(name)
+		if err != nil && err != services.ErrRoleNotFound {
+			log.Fatalf("could not look up LDAP roles: %v\n", err)
+		}
+		return err == nil
+	}
+
+	roles := services.RoleMapping{DefaultRole: ldapConfig.DefaultRole}
+	if !isRole(roles.DefaultRole) {
+		log.Fatalf("LDAP_DEFAULT_ROLE %q is not a role\n", roles.DefaultRole)
+	}
+	for _, groupRole := range ldapConfig.GroupRoles {
+		if !isRole(groupRole.Role) {
+			log.Fatalf("LDAP_GROUP_ROLES gives %s the unknown role %q\n", groupRole.GroupDN, groupRole.Role)
+		}
+		roles.Groups = append(roles.Groups, services.GroupRole{GroupDN: groupRole.GroupDN, Role: groupRole.Role})
+	}
+	return roles
+}
@@ -3,7 +3,11 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +16,215 @@ type AppConfig struct {
 	ServerPort string
 	JWTSecret  string
 	DSN        string
+	// BaseURL is where users reach the app; emailed links point here.
+	BaseURL  string
+	Auth     AuthConfig
+	Password PasswordConfig
+	Mail     MailConfig
+	Storage  StorageConfig
+
+	// TrustedProxies are the addresses or CIDRs of the reverse proxies whose
+	// X-Forwarded-For header gives the client IP. With none, the client IP
+	// is the address the request came from.
+	TrustedProxies []string
+}
+
+type AuthConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// SigningKeys are the RS256/EdDSA keys tokens are signed with. When
+	// none are configured, tokens are signed with JWTSecret using HS256.
+	SigningKeys []SigningKeyConfig
+	// TokenIssuer and TokenAudience are put into the iss and aud claims and
+	// required of every token. TokenLeeway absorbs clock skew.
+	TokenIssuer   string
+	TokenAudience string
+	TokenLeeway   time.Duration
+
+	// RevocationStore is "postgres" or "memory". The in-memory store only
+	// suits a single instance, since other instances never see revocations.
+	RevocationStore           string
+	RevocationCleanupInterval time.Duration
+
+	// SessionTouchInterval throttles how often a session's last-seen time
+	// is written while it is in use.
+	SessionTouchInterval time.Duration
+
+	PasswordResetTTL time.Duration
+
+	EmailVerificationTTL time.Duration
+	// EmailVerificationPolicy decides what unverified users may not do:
+	// nothing ("none"), create tasks ("block_tasks") or log in at all
+	// ("block_login").
+	EmailVerificationPolicy string
+
+	// MFAIssuer labels the account in authenticator apps.
+	MFAIssuer string
+	// MFAChallengeTTL bounds how long a password-verified login may wait
+	// for its second factor.
+	MFAChallengeTTL time.Duration
+
+	// LoginAttemptStore is "postgres" or "memory" and holds the failed
+	// login counters behind lockout.
+	LoginAttemptStore           string
+	LoginAttemptCleanupInterval time.Duration
+	// An account, or a client IP, is locked out after this many
+	// consecutive failures. Each further failure doubles the lockout,
+	// starting at LoginLockoutBase and capped at LoginLockoutMax.
+	LoginMaxFailures   int
+	LoginIPMaxFailures int
+	LoginLockoutBase   time.Duration
+	LoginLockoutMax    time.Duration
+	// LoginFailureWindow is how long without a failure it takes for the
+	// count to start over.
+	LoginFailureWindow time.Duration
+
+	// MagicLinkTTL bounds how long an emailed sign-in link works. Each email
+	// address may ask for MagicLinkMaxRequests links until
+	// MagicLinkRequestWindow passes without another request.
+	MagicLinkTTL           time.Duration
+	MagicLinkMaxRequests   int
+	MagicLinkRequestWindow time.Duration
+	// MagicLinkBindDevice makes links work only in the browser that asked
+	// for them.
+	MagicLinkBindDevice bool
+
+	// OIDCProviders are the identity providers users may sign in with.
+	OIDCProviders []OIDCProviderConfig
+	// OIDCLoginTTL bounds how long a user may take at the provider.
+	OIDCLoginTTL time.Duration
+
+	// Backends are where login passwords are checked, tried in order:
+	// "local" for the hashes stored with users, "ldap" for the directory.
+	Backends []string
+	LDAP     LDAPConfig
+
+	// WebAuthn identifies the app to passkey authenticators.
+	WebAuthn WebAuthnConfig
+
+	// SCIMToken is the bearer token provisioning clients use for the SCIM
+	// endpoints, which are disabled without one.
+	SCIMToken string
+
+	// ImpersonationTTL bounds how long an admin may act as another user
+	// with one token. Impersonation tokens cannot be refreshed.
+	ImpersonationTTL time.Duration
+}
+
+// WebAuthnConfig describes the relying party passkeys are registered with.
+type WebAuthnConfig struct {
+	// RPID is the domain passkeys are bound to. They work on it and its
+	// subdomains, and stop working if it changes.
+	RPID          string
+	RPDisplayName string
+	// Origins are the web origins pages using passkeys may be served from.
+	Origins []string
+	// CeremonyTTL bounds how long the authenticator may take to answer.
+	CeremonyTTL time.Duration
+}
+
+// LDAPConfig describes the directory behind the "ldap" backend. Users are
+// looked up with the service account and then bound as to check their
+// password.
+type LDAPConfig struct {
+	// URL is ldap:// or ldaps://. StartTLS upgrades ldap:// connections.
+	URL      string
+	StartTLS bool
+	Timeout  time.Duration
+
+	BindDN       string
+	BindPassword string
+
+	UserBaseDN string
+	// UserFilter finds the entry of the email address logged in with,
+	// which replaces %s.
+	UserFilter string
+	// GroupBaseDN, when set, is searched with GroupFilter, in which %s is
+	// the user's DN, for the user's groups. Otherwise the memberOf
+	// attribute of the user's entry lists them.
+	GroupBaseDN string
+	GroupFilter string
+
+	// GroupRoles give members of a group a role; the first group listed
+	// that the user is in decides. Everyone else gets DefaultRole.
+	GroupRoles  []LDAPGroupRole
+	DefaultRole string
+}
+
+type LDAPGroupRole struct {
+	Role    string
+	GroupDN string
+}
+
+// OIDCProviderConfig describes one OpenID Connect identity provider. Name
+// appears in the login and callback URLs.
+type OIDCProviderConfig struct {
+	Name         string
+	DisplayName  string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// SigningKeyConfig names a PEM key file. The key signs tokens from
+// ActiveFrom until a later key becomes active, and verifies them for as long
+// as it stays configured.
+type SigningKeyConfig struct {
+	ID         string
+	Path       string
+	ActiveFrom time.Time
+}
+
+type PasswordConfig struct {
+	// Algorithm is "argon2id" or "bcrypt" and applies to new hashes.
+	// Hashes of either kind keep verifying and are redone at login when
+	// they do not match the current algorithm and parameters.
+	Algorithm  string
+	BcryptCost int
+
+	// Argon2Memory is in KiB.
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+
+	// MinLength and MaxLength count characters. MinStrength is the lowest
+	// accepted zxcvbn score, from 0 (anything goes) to 4.
+	MinLength   int
+	MaxLength   int
+	MinStrength int
+	// BreachListPath names a local copy of the Pwned Passwords SHA-1 list:
+	// either one file sorted by hash or a directory of range files.
+	// Empty disables the breached password check.
+	BreachListPath string
+}
+
+type MailConfig struct {
+	// Driver is "smtp", "file" or "log".
+	Driver   string
+	From     string
+	FilePath string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+type StorageConfig struct {
+	Driver string
+	Path   string
+
+	S3Endpoint  string
+	S3AccessKey string
+	S3SecretKey string
+	S3Bucket    string
+	S3UseSSL    bool
+
+	MaxAttachmentSize int64
+	AllowedMIMETypes  []string
+	UserQuota         int64
 }
 
 func SetupEnv() (AppConfig, error) {
@@ -29,14 +242,31 @@ func SetupEnv() (AppConfig, error) {
 }
 
 func loadEnv(config *AppConfig) error {
-	if port := os.Getenv("HTTP_PORT"); port != "" {
-		config.ServerPort = ":" + port
+	// The default links and issuer below name the port actually listened on.
+	config.ServerPort = ":" + getEnv("HTTP_PORT", "8080")
+
+	config.BaseURL = strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost"+config.ServerPort), "/")
+	config.TrustedProxies = getEnvList("TRUSTED_PROXIES", nil)
+
+	if err := loadAuthEnv(&config.Auth); err != nil {
+		return err
+	}
+
+	config.Auth.TokenIssuer = getEnv("JWT_ISSUER", config.BaseURL)
+	if err := loadWebAuthnEnv(&config.Auth.WebAuthn, config.BaseURL, config.Auth.MFAIssuer); err != nil {
+		return err
 	}
 
 	config.JWTSecret = os.Getenv("JWT_SECRET")
-	if config.JWTSecret == "" {
+	if config.JWTSecret == "" && len(config.Auth.SigningKeys) == 0 {
 		return errors.New("JWT_SECRET environment variable not set")
 	}
+	if err := loadPasswordEnv(&config.Password); err != nil {
+		return err
+	}
+	if err := loadMailEnv(&config.Mail); err != nil {
+		return err
+	}
 
 	dbHost := os.Getenv("DB_HOST")
 	if dbHost == "" {
@@ -78,5 +308,451 @@ func loadEnv(config *AppConfig) error {
 		dbSSLMode,
 	)
 
+	return loadStorageEnv(&config.Storage)
+}
+
+func loadAuthEnv(auth *AuthConfig) error {
+	var err error
+	if auth.AccessTokenTTL, err = getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute); err != nil {
+		return err
+	}
+	if auth.RefreshTokenTTL, err = getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour); err != nil {
+		return err
+	}
+
+	if auth.SigningKeys, err = parseSigningKeys(getEnvList("JWT_SIGNING_KEYS", nil)); err != nil {
+		return err
+	}
+
+	auth.TokenAudience = getEnv("JWT_AUDIENCE", "todo-api")
+	if auth.TokenLeeway, err = getEnvDuration("JWT_LEEWAY", 30*time.Second); err != nil {
+		return err
+	}
+	if auth.TokenLeeway < 0 {
+		return errors.New("JWT_LEEWAY must not be negative")
+	}
+
+	auth.RevocationStore = getEnv("REVOCATION_STORE", "postgres")
+	if auth.RevocationStore != "postgres" && auth.RevocationStore != "memory" {
+		return fmt.Errorf("unknown REVOCATION_STORE %q", auth.RevocationStore)
+	}
+	if auth.RevocationCleanupInterval, err = getEnvDuration("REVOCATION_CLEANUP_INTERVAL", 10*time.Minute); err != nil {
+		return err
+	}
+	if auth.SessionTouchInterval, err = getEnvDuration("SESSION_TOUCH_INTERVAL", time.Minute); err != nil {
+		return err
+	}
+	if auth.PasswordResetTTL, err = getEnvDuration("PASSWORD_RESET_TTL", time.Hour); err != nil {
+		return err
+	}
+
+	if auth.EmailVerificationTTL, err = getEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour); err != nil {
+		return err
+	}
+	auth.EmailVerificationPolicy = getEnv("EMAIL_VERIFICATION_POLICY", "none")
+	switch auth.EmailVerificationPolicy {
+	case "none", "block_tasks", "block_login":
+	default:
+		return fmt.Errorf("unknown EMAIL_VERIFICATION_POLICY %q", auth.EmailVerificationPolicy)
+	}
+
+	auth.MFAIssuer = getEnv("MFA_ISSUER", "Todo App")
+	if auth.MFAChallengeTTL, err = getEnvDuration("MFA_CHALLENGE_TTL", 5*time.Minute); err != nil {
+		return err
+	}
+
+	auth.LoginAttemptStore = getEnv("LOGIN_ATTEMPT_STORE", "postgres")
+	if auth.LoginAttemptStore != "postgres" && auth.LoginAttemptStore != "memory" {
+		return fmt.Errorf("unknown LOGIN_ATTEMPT_STORE %q", auth.LoginAttemptStore)
+	}
+	if auth.LoginAttemptCleanupInterval, err = getEnvDuration("LOGIN_ATTEMPT_CLEANUP_INTERVAL", 10*time.Minute); err != nil {
+		return err
+	}
+	maxFailures, err := getEnvInt64("LOGIN_MAX_FAILURES", 5)
+	if err != nil {
+		return err
+	}
+	ipMaxFailures, err := getEnvInt64("LOGIN_IP_MAX_FAILURES", 20)
+	if err != nil {
+		return err
+	}
+	if maxFailures < 1 || ipMaxFailures < 1 {
+		return errors.New("LOGIN_MAX_FAILURES and LOGIN_IP_MAX_FAILURES must be at least 1")
+	}
+	auth.LoginMaxFailures, auth.LoginIPMaxFailures = int(maxFailures), int(ipMaxFailures)
+	if auth.LoginLockoutBase, err = getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute); err != nil {
+		return err
+	}
+	if auth.LoginLockoutMax, err = getEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour); err != nil {
+		return err
+	}
+	if auth.LoginFailureWindow, err = getEnvDuration("LOGIN_FAILURE_WINDOW", 24*time.Hour); err != nil {
+		return err
+	}
+	if auth.LoginLockoutBase <= 0 || auth.LoginLockoutMax < auth.LoginLockoutBase {
+		return errors.New("LOGIN_LOCKOUT_BASE must be positive and no longer than LOGIN_LOCKOUT_MAX")
+	}
+	// A counter that expired mid-lockout would end the lockout early.
+	if auth.LoginFailureWindow < auth.LoginLockoutMax {
+		return errors.New("LOGIN_FAILURE_WINDOW must be at least LOGIN_LOCKOUT_MAX")
+	}
+
+	if auth.MagicLinkTTL, err = getEnvDuration("MAGIC_LINK_TTL", 15*time.Minute); err != nil {
+		return err
+	}
+	maxMagicLinks, err := getEnvInt64("MAGIC_LINK_MAX_REQUESTS", 3)
+	if err != nil {
+		return err
+	}
+	if maxMagicLinks < 1 {
+		return errors.New("MAGIC_LINK_MAX_REQUESTS must be at least 1")
+	}
+	auth.MagicLinkMaxRequests = int(maxMagicLinks)
+	if auth.MagicLinkRequestWindow, err = getEnvDuration("MAGIC_LINK_REQUEST_WINDOW", time.Hour); err != nil {
+		return err
+	}
+	auth.MagicLinkBindDevice = getEnv("MAGIC_LINK_BIND_DEVICE", "true") == "true"
+
+	if auth.OIDCProviders, err = loadOIDCProviders(getEnvList("OIDC_PROVIDERS", nil)); err != nil {
+		return err
+	}
+	if auth.OIDCLoginTTL, err = getEnvDuration("OIDC_LOGIN_TTL", 10*time.Minute); err != nil {
+		return err
+	}
+
+	auth.Backends = getEnvList("AUTH_BACKENDS", []string{"local"})
+	if len(auth.Backends) == 0 {
+		return errors.New("AUTH_BACKENDS must name at least one backend")
+	}
+	for _, backend := range auth.Backends {
+		switch backend {
+		case "local":
+		case "ldap":
+			if err := loadLDAPEnv(&auth.LDAP); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown AUTH_BACKENDS entry %q", backend)
+		}
+	}
+
+	auth.SCIMToken = os.Getenv("SCIM_TOKEN")
+	if auth.SCIMToken != "" && len(auth.SCIMToken) < 32 {
+		return errors.New("SCIM_TOKEN must be at least 32 characters long")
+	}
+
+	if auth.ImpersonationTTL, err = getEnvDuration("IMPERSONATION_TTL", 15*time.Minute); err != nil {
+		return err
+	}
+	if auth.ImpersonationTTL <= 0 {
+		return errors.New("IMPERSONATION_TTL must be positive")
+	}
+
+	return nil
+}
+
+// loadWebAuthnEnv defaults the relying party to the app's own address and
+// the name authenticator apps show for it.
+func loadWebAuthnEnv(webAuthn *WebAuthnConfig, baseURL, displayName string) error {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("APP_BASE_URL is not a URL: %w", err)
+	}
+	webAuthn.RPID = getEnv("WEBAUTHN_RP_ID", parsed.Hostname())
+	webAuthn.RPDisplayName = getEnv("WEBAUTHN_RP_NAME", displayName)
+	webAuthn.Origins = getEnvList("WEBAUTHN_ORIGINS", []string{parsed.Scheme + "://" + parsed.Host})
+	if webAuthn.RPID == "" || len(webAuthn.Origins) == 0 {
+		return errors.New("WEBAUTHN_RP_ID and WEBAUTHN_ORIGINS must not be empty")
+	}
+	webAuthn.CeremonyTTL, err = getEnvDuration("WEBAUTHN_CEREMONY_TTL", 5*time.Minute)
+	return err
+}
+
+func loadLDAPEnv(ldap *LDAPConfig) error {
+	var err error
+	ldap.URL = os.Getenv("LDAP_URL")
+	ldap.BindDN = os.Getenv("LDAP_BIND_DN")
+	ldap.BindPassword = os.Getenv("LDAP_BIND_PASSWORD")
+	ldap.UserBaseDN = os.Getenv("LDAP_USER_BASE_DN")
+	if ldap.URL == "" || ldap.BindDN == "" || ldap.UserBaseDN == "" {
+		return errors.New("LDAP_URL, LDAP_BIND_DN and LDAP_USER_BASE_DN must be set for the ldap backend")
+	}
+	if !strings.HasPrefix(ldap.URL, "ldap://") && !strings.HasPrefix(ldap.URL, "ldaps://") {
+		return fmt.Errorf("LDAP_URL %q must start with ldap:// or ldaps://", ldap.URL)
+	}
+	ldap.StartTLS = getEnv("LDAP_START_TLS", "false") == "true"
+	if ldap.Timeout, err = getEnvDuration("LDAP_TIMEOUT", 10*time.Second); err != nil {
+		return err
+	}
+
+	ldap.UserFilter = getEnv("LDAP_USER_FILTER", "(mail=%s)")
+	ldap.GroupBaseDN = os.Getenv("LDAP_GROUP_BASE_DN")
+	ldap.GroupFilter = getEnv("LDAP_GROUP_FILTER", "(member=%s)")
+	if strings.Count(ldap.UserFilter, "%s") != 1 || strings.Count(ldap.GroupFilter, "%s") != 1 {
+		return errors.New("LDAP_USER_FILTER and LDAP_GROUP_FILTER must contain %s once")
+	}
+
+	if ldap.GroupRoles, err = parseLDAPGroupRoles(os.Getenv("LDAP_GROUP_ROLES")); err != nil {
+		return err
+	}
+	ldap.DefaultRole = getEnv("LDAP_DEFAULT_ROLE", "user")
 	return nil
 }
+
+// parseLDAPGroupRoles reads entries of the form role=group DN, separated by
+// semicolons since DNs contain commas.
+func parseLDAPGroupRoles(value string) ([]LDAPGroupRole, error) {
+	var groupRoles []LDAPGroupRole
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, groupDN, ok := strings.Cut(entry, "=")
+		role, groupDN = strings.TrimSpace(role), strings.TrimSpace(groupDN)
+		if !ok || role == "" || groupDN == "" {
+			return nil, fmt.Errorf("LDAP_GROUP_ROLES entry %q must look like role=group DN", entry)
+		}
+		groupRoles = append(groupRoles, LDAPGroupRole{Role: role, GroupDN: groupDN})
+	}
+	return groupRoles, nil
+}
+
+// loadOIDCProviders reads the settings of each named provider from
+// OIDC_<NAME>_* variables, e.g. OIDC_GOOGLE_ISSUER for "google".
+func loadOIDCProviders(names []string) ([]OIDCProviderConfig, error) {
+	providers := make([]OIDCProviderConfig, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		for _, r := range name {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return nil, fmt.Errorf("OIDC provider name %q may only contain lowercase letters, digits and dashes", name)
+			}
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("OIDC provider %q is listed twice", name)
+		}
+		seen[name] = true
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			IssuerURL:    os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       getEnvList(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+		}
+		if provider.IssuerURL == "" || provider.ClientID == "" {
+			return nil, fmt.Errorf("%sISSUER and %sCLIENT_ID must be set", prefix, prefix)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// parseSigningKeys reads entries of the form kid=path or
+// kid=path@2024-06-01T00:00:00Z, the latter only signing from that time on.
+func parseSigningKeys(entries []string) ([]SigningKeyConfig, error) {
+	keys := make([]SigningKeyConfig, 0, len(entries))
+	for _, entry := range entries {
+		id, path, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || path == "" {
+			return nil, fmt.Errorf("JWT_SIGNING_KEYS entry %q must look like kid=path[@activation time]", entry)
+		}
+
+		key := SigningKeyConfig{ID: id, Path: path}
+		if at := strings.LastIndex(path, "@"); at != -1 {
+			activeFrom, err := time.Parse(time.RFC3339, path[at+1:])
+			if err != nil {
+				return nil, fmt.Errorf("JWT_SIGNING_KEYS entry %q has an invalid activation time: %w", entry, err)
+			}
+			key.Path, key.ActiveFrom = path[:at], activeFrom
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func loadPasswordEnv(password *PasswordConfig) error {
+	password.Algorithm = getEnv("PASSWORD_HASH_ALGORITHM", "argon2id")
+	if password.Algorithm != "argon2id" && password.Algorithm != "bcrypt" {
+		return fmt.Errorf("unknown PASSWORD_HASH_ALGORITHM %q", password.Algorithm)
+	}
+
+	// bcrypt.MinCost and bcrypt.MaxCost.
+	cost, err := getEnvInt64("BCRYPT_COST", 10)
+	if err != nil {
+		return err
+	}
+	if cost < 4 || cost > 31 {
+		return errors.New("BCRYPT_COST must be between 4 and 31")
+	}
+	password.BcryptCost = int(cost)
+
+	// The defaults follow the OWASP recommendation of 19 MiB, two passes
+	// and one lane.
+	memory, err := getEnvInt64("ARGON2_MEMORY", 19*1024)
+	if err != nil {
+		return err
+	}
+	iterations, err := getEnvInt64("ARGON2_ITERATIONS", 2)
+	if err != nil {
+		return err
+	}
+	parallelism, err := getEnvInt64("ARGON2_PARALLELISM", 1)
+	if err != nil {
+		return err
+	}
+	if memory < 8 || memory > 1<<22 || iterations < 1 || iterations > 100 || parallelism < 1 || parallelism > 255 {
+		return errors.New("ARGON2_MEMORY (KiB), ARGON2_ITERATIONS or ARGON2_PARALLELISM out of range")
+	}
+	if memory < 8*parallelism {
+		return errors.New("ARGON2_MEMORY must be at least 8 KiB per lane of ARGON2_PARALLELISM")
+	}
+	password.Argon2Memory = uint32(memory)
+	password.Argon2Iterations = uint32(iterations)
+	password.Argon2Parallelism = uint8(parallelism)
+
+	// bcrypt cannot use more than 72 bytes.
+	defaultMaxLength := int64(128)
+	if password.Algorithm == "bcrypt" {
+		defaultMaxLength = 72
+	}
+	minLength, err := getEnvInt64("PASSWORD_MIN_LENGTH", 8)
+	if err != nil {
+		return err
+	}
+	maxLength, err := getEnvInt64("PASSWORD_MAX_LENGTH", defaultMaxLength)
+	if err != nil {
+		return err
+	}
+	if minLength < 1 || maxLength < minLength {
+		return errors.New("PASSWORD_MIN_LENGTH must be positive and no greater than PASSWORD_MAX_LENGTH")
+	}
+	// The request bodies refuse longer passwords outright.
+	if maxLength > 1024 {
+		return errors.New("PASSWORD_MAX_LENGTH must be at most 1024")
+	}
+	minStrength, err := getEnvInt64("PASSWORD_MIN_STRENGTH", 2)
+	if err != nil {
+		return err
+	}
+	if minStrength < 0 || minStrength > 4 {
+		return errors.New("PASSWORD_MIN_STRENGTH must be between 0 and 4")
+	}
+	password.MinLength, password.MaxLength, password.MinStrength = int(minLength), int(maxLength), int(minStrength)
+	password.BreachListPath = os.Getenv("PASSWORD_BREACH_LIST")
+
+	return nil
+}
+
+func loadMailEnv(mail *MailConfig) error {
+	mail.Driver = getEnv("MAIL_DRIVER", "log")
+	mail.From = getEnv("MAIL_FROM", "Todo App <no-reply@localhost>")
+
+	switch mail.Driver {
+	case "log":
+	case "file":
+		mail.FilePath = getEnv("MAIL_FILE", "./mail.log")
+	case "smtp":
+		mail.SMTPHost = os.Getenv("SMTP_HOST")
+		if mail.SMTPHost == "" {
+			return errors.New("SMTP_HOST environment variable not set")
+		}
+		mail.SMTPPort = getEnv("SMTP_PORT", "587")
+		mail.SMTPUsername = os.Getenv("SMTP_USERNAME")
+		mail.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	default:
+		return fmt.Errorf("unknown MAIL_DRIVER %q", mail.Driver)
+	}
+
+	return nil
+}
+
+func loadStorageEnv(storage *StorageConfig) error {
+	storage.Driver = getEnv("STORAGE_DRIVER", "local")
+	storage.Path = getEnv("STORAGE_PATH", "./uploads")
+
+	var err error
+	if storage.MaxAttachmentSize, err = getEnvInt64("ATTACHMENT_MAX_SIZE", 10<<20); err != nil {
+		return err
+	}
+	if storage.UserQuota, err = getEnvInt64("ATTACHMENT_USER_QUOTA", 100<<20); err != nil {
+		return err
+	}
+	storage.AllowedMIMETypes = getEnvList("ATTACHMENT_ALLOWED_TYPES", []string{
+		"image/png",
+		"image/jpeg",
+		"image/gif",
+		"application/pdf",
+		"text/plain",
+		"text/csv",
+		"application/zip",
+	})
+
+	switch storage.Driver {
+	case "local":
+	case "s3":
+		storage.S3Endpoint = os.Getenv("S3_ENDPOINT")
+		if storage.S3Endpoint == "" {
+			return errors.New("S3_ENDPOINT environment variable not set")
+		}
+		storage.S3Bucket = os.Getenv("S3_BUCKET")
+		if storage.S3Bucket == "" {
+			return errors.New("S3_BUCKET environment variable not set")
+		}
+		storage.S3AccessKey = os.Getenv("S3_ACCESS_KEY")
+		storage.S3SecretKey = os.Getenv("S3_SECRET_KEY")
+		storage.S3UseSSL = getEnv("S3_USE_SSL", "true") == "true"
+	default:
+		return fmt.Errorf("unknown STORAGE_DRIVER %q", storage.Driver)
+	}
+
+	return nil
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt64(key string, fallback int64) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 15m or 720h: %w", key, err)
+	}
+	return d, nil
+}
+
+func getEnvList(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,42 @@
+// Package databasetest gives repository tests a migrated Postgres database.
+package databasetest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/MohamedMosalm/Todo-App/database"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Open migrates the database named by TEST_DATABASE_URL and returns a
+// transaction that is rolled back once the test ends, e.g.
+//
+//	docker compose up -d postgres
+//	TEST_DATABASE_URL="host=localhost user=postgres password=postgres dbname=todo_test sslmode=disable" go test ./repositories/...
+//
+// The test is skipped when TEST_DATABASE_URL is not set.
+func Open(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := database.ConnectDB(dsn)
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(db, models.All()...))
+
+	tx := db.Begin()
+	require.NoError(t, tx.Error)
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// CreateUser stores a user with a unique email address.
+func CreateUser(t *testing.T, db *gorm.DB) *models.User {
+	user := &models.User{FirstName: "Jane", LastName: "Doe", Email: uuid.NewString() + "@example.com"}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
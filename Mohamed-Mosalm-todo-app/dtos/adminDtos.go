@@ -0,0 +1,54 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+// AdminUserDTO is a user as admins see them, without the password hash.
+type AdminUserDTO struct {
+	ID              uuid.UUID  `json:"id"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Email           string     `json:"email"`
+	Phone           string     `json:"phone"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	DeactivatedAt   *time.Time `json:"deactivated_at"`
+	ExternalID      string     `json:"external_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func NewAdminUserDTO(user *models.User) *AdminUserDTO {
+	return &AdminUserDTO{
+		ID:              user.ID,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Email:           user.Email,
+		Phone:           user.Phone,
+		Role:            user.Role,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		DeactivatedAt:   user.DeactivatedAt,
+		ExternalID:      user.ExternalID,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+}
+
+type ChangeRoleDTO struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type CreateRoleDTO struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type UpdateRoleDTO struct {
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
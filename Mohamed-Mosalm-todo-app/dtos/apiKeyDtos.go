@@ -0,0 +1,38 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type CreateAPIKeyDTO struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyResponseDTO struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Key is only set in the response to creating the key.
+	Key string `json:"key,omitempty"`
+}
+
+func NewAPIKeyResponseDTO(key *models.APIKey) *APIKeyResponseDTO {
+	return &APIKeyResponseDTO{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
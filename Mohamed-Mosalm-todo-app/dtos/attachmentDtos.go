@@ -0,0 +1,28 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type AttachmentResponseDTO struct {
+	ID          uuid.UUID `json:"id"`
+	TaskID      uuid.UUID `json:"task_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewAttachmentResponseDTO(attachment *models.Attachment) *AttachmentResponseDTO {
+	return &AttachmentResponseDTO{
+		ID:          attachment.ID,
+		TaskID:      attachment.TaskID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
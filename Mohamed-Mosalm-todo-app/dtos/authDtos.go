@@ -1,14 +1,124 @@
 package dtos
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
 type RegisterDTO struct {
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 	Email     string `json:"email" binding:"required,email"`
 	Phone     string `json:"phone" binding:"required"`
-	Password  string `json:"password" binding:"required,min=8"`
+	Password  string `json:"password" binding:"required,max=1024"`
 }
 
 type LoginDTO struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
+
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type ForgotPasswordDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MagicLinkRequestDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MagicLinkConsumeDTO struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResetPasswordDTO struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,max=1024"`
+}
+
+// ChangePasswordDTO confirms the user with the current password or, for
+// accounts without one, a TOTP or recovery code or a passkey assertion
+// started at /api/auth/passkeys/reauth/begin.
+type ChangePasswordDTO struct {
+	CurrentPassword   string          `json:"current_password"`
+	NewPassword       string          `json:"new_password" binding:"required,max=1024"`
+	Code              string          `json:"code"`
+	PasskeyCeremony   string          `json:"passkey_ceremony"`
+	PasskeyCredential json.RawMessage `json:"passkey_credential"`
+}
+
+type VerifyEmailDTO struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ConfirmMFADTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableMFADTO asks for the password and a current code, so a stolen
+// access token alone cannot turn off the second factor. Accounts without a
+// password give only the code.
+type DisableMFADTO struct {
+	Password string `json:"password"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type VerifyMFADTO struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// FinishPasskeyRegistrationDTO carries the authenticator's answer.
+// Credential is the PublicKeyCredential the browser returned, as JSON with
+// its binary fields base64url encoded, and likewise in the other passkey
+// DTOs.
+type FinishPasskeyRegistrationDTO struct {
+	Ceremony   string          `json:"ceremony" binding:"required"`
+	Name       string          `json:"name" binding:"max=100"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type FinishPasskeyLoginDTO struct {
+	Ceremony   string          `json:"ceremony" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type BeginPasskeyMFADTO struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+type VerifyPasskeyMFADTO struct {
+	MFAToken   string          `json:"mfa_token" binding:"required"`
+	Ceremony   string          `json:"ceremony" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type SessionResponseDTO struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+func NewSessionResponseDTO(session *models.Session, currentSessionID uuid.UUID) *SessionResponseDTO {
+	return &SessionResponseDTO{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		Current:    session.ID == currentSessionID,
+	}
+}
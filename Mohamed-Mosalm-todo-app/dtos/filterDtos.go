@@ -0,0 +1,50 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type FilterDTO struct {
+	Name   string `json:"name" binding:"required,max=100"`
+	Query  string `json:"query" binding:"required,max=1000"`
+	SortBy string `json:"sort_by"`
+	Order  string `json:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+type FilterResponseDTO struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	SortBy    string    `json:"sort_by,omitempty"`
+	Order     string    `json:"order"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (d *FilterDTO) ApplyTo(filter *models.SavedFilter) {
+	filter.Name = d.Name
+	filter.Query = d.Query
+	filter.SortBy = d.SortBy
+	filter.SortDesc = d.Order == "desc"
+}
+
+func NewFilterResponseDTO(filter *models.SavedFilter) *FilterResponseDTO {
+	order := "asc"
+	if filter.SortDesc {
+		order = "desc"
+	}
+	return &FilterResponseDTO{
+		ID:        filter.ID,
+		Name:      filter.Name,
+		Query:     filter.Query,
+		SortBy:    filter.SortBy,
+		Order:     order,
+		UserID:    filter.UserID,
+		CreatedAt: filter.CreatedAt,
+		UpdatedAt: filter.UpdatedAt,
+	}
+}
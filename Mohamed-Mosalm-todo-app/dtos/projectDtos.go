@@ -0,0 +1,154 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type CreateProjectDTO struct {
+	Name        string      `json:"name" binding:"required,max=100"`
+	Description string      `json:"description" binding:"max=500"`
+	Columns     []ColumnDTO `json:"columns" binding:"omitempty,dive"`
+}
+
+type ColumnDTO struct {
+	State    string `json:"state" binding:"required,max=50"`
+	Name     string `json:"name" binding:"required,max=100"`
+	WIPLimit int    `json:"wip_limit" binding:"min=0"`
+	IsDone   bool   `json:"is_done"`
+}
+
+type UpdateColumnsDTO struct {
+	Columns []ColumnDTO `json:"columns" binding:"required,min=1,dive"`
+}
+
+type FieldDTO struct {
+	Key      string   `json:"key" binding:"required,max=50"`
+	Name     string   `json:"name" binding:"required,max=100"`
+	Type     string   `json:"type" binding:"required,oneof=text number date select multi_select"`
+	Options  []string `json:"options" binding:"omitempty,dive,required,max=100"`
+	Required bool     `json:"required"`
+}
+
+type UpdateFieldsDTO struct {
+	Fields []FieldDTO `json:"fields" binding:"dive"`
+}
+
+type MoveTaskDTO struct {
+	State    string `json:"state" binding:"required"`
+	Position *int   `json:"position" binding:"omitempty,min=0"`
+	Force    bool   `json:"force"`
+}
+
+type ProjectResponseDTO struct {
+	ID          uuid.UUID            `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	UserID      uuid.UUID            `json:"user_id"`
+	Columns     []models.BoardColumn `json:"columns"`
+	Fields      []models.CustomField `json:"fields"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type BoardColumnResponseDTO struct {
+	State     string            `json:"state"`
+	Name      string            `json:"name"`
+	Position  int               `json:"position"`
+	WIPLimit  int               `json:"wip_limit"`
+	IsDone    bool              `json:"is_done"`
+	TaskCount int               `json:"task_count"`
+	OverLimit bool              `json:"over_limit"`
+	Tasks     []TaskResponseDTO `json:"tasks"`
+}
+
+type BoardResponseDTO struct {
+	ProjectID uuid.UUID                `json:"project_id"`
+	Name      string                   `json:"name"`
+	Columns   []BoardColumnResponseDTO `json:"columns"`
+}
+
+func ColumnsFromDTO(columnDTOs []ColumnDTO) []models.BoardColumn {
+	columns := make([]models.BoardColumn, len(columnDTOs))
+	for i, column := range columnDTOs {
+		columns[i] = models.BoardColumn{
+			State:    column.State,
+			Name:     column.Name,
+			Position: i,
+			WIPLimit: column.WIPLimit,
+			IsDone:   column.IsDone,
+		}
+	}
+	return columns
+}
+
+func FieldsFromDTO(fieldDTOs []FieldDTO) []models.CustomField {
+	fields := make([]models.CustomField, len(fieldDTOs))
+	for i, field := range fieldDTOs {
+		fields[i] = models.CustomField{
+			Key:      field.Key,
+			Name:     field.Name,
+			Type:     models.CustomFieldType(field.Type),
+			Options:  field.Options,
+			Required: field.Required,
+			Position: i,
+		}
+	}
+	return fields
+}
+
+func NewProjectResponseDTO(project *models.Project) *ProjectResponseDTO {
+	return &ProjectResponseDTO{
+		ID:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		UserID:      project.UserID,
+		Columns:     project.Columns,
+		Fields:      project.Fields,
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+	}
+}
+
+// NewBoardResponseDTO groups tasks into the project's columns in column order.
+// Tasks are expected to be sorted by position already; any task whose state
+// no longer matches a column is shown in the first column.
+func NewBoardResponseDTO(project *models.Project, tasks []models.Task) *BoardResponseDTO {
+	board := &BoardResponseDTO{
+		ProjectID: project.ID,
+		Name:      project.Name,
+		Columns:   make([]BoardColumnResponseDTO, len(project.Columns)),
+	}
+
+	index := make(map[string]int, len(project.Columns))
+	for i, column := range project.Columns {
+		index[column.State] = i
+		board.Columns[i] = BoardColumnResponseDTO{
+			State:    column.State,
+			Name:     column.Name,
+			Position: column.Position,
+			WIPLimit: column.WIPLimit,
+			IsDone:   column.IsDone,
+			Tasks:    []TaskResponseDTO{},
+		}
+	}
+
+	if len(board.Columns) == 0 {
+		return board
+	}
+
+	for i := range tasks {
+		col := &board.Columns[index[tasks[i].State]]
+		col.Tasks = append(col.Tasks, *NewTaskResponseDTO(&tasks[i]))
+	}
+
+	for i := range board.Columns {
+		col := &board.Columns[i]
+		col.TaskCount = len(col.Tasks)
+		col.OverLimit = col.WIPLimit > 0 && col.TaskCount > col.WIPLimit
+	}
+
+	return board
+}
@@ -0,0 +1,90 @@
+package dtos
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+)
+
+const (
+	SCIMUserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+)
+
+// SCIMUserDTO is the SCIM core User resource (RFC 7643, section 4.1),
+// limited to the attributes users have here. userName is the email address.
+type SCIMUserDTO struct {
+	Schemas      []string       `json:"schemas"`
+	ID           string         `json:"id,omitempty"`
+	ExternalID   string         `json:"externalId,omitempty"`
+	UserName     string         `json:"userName" binding:"required"`
+	Name         SCIMNameDTO    `json:"name"`
+	Emails       []SCIMValueDTO `json:"emails,omitempty"`
+	PhoneNumbers []SCIMValueDTO `json:"phoneNumbers,omitempty"`
+	Active       *bool          `json:"active,omitempty"`
+	Meta         *SCIMMetaDTO   `json:"meta,omitempty"`
+}
+
+type SCIMNameDTO struct {
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMValueDTO is an entry of a multi-valued attribute such as emails.
+type SCIMValueDTO struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type SCIMMetaDTO struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type SCIMListResponseDTO struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int64         `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []SCIMUserDTO `json:"Resources"`
+}
+
+type SCIMPatchDTO struct {
+	Schemas    []string                `json:"schemas"`
+	Operations []SCIMPatchOperationDTO `json:"Operations" binding:"required,min=1"`
+}
+
+// SCIMPatchOperationDTO keeps Value raw, since its shape depends on Path.
+type SCIMPatchOperationDTO struct {
+	Op    string          `json:"op" binding:"required"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// NewSCIMUserDTO represents user as a SCIM resource found at location.
+func NewSCIMUserDTO(user *models.User, location string) SCIMUserDTO {
+	active := user.IsActive()
+	resource := SCIMUserDTO{
+		Schemas:    []string{SCIMUserSchema},
+		ID:         user.ID.String(),
+		ExternalID: user.ExternalID,
+		UserName:   user.Email,
+		Name:       SCIMNameDTO{GivenName: user.FirstName, FamilyName: user.LastName},
+		Emails:     []SCIMValueDTO{{Value: user.Email, Type: "work", Primary: true}},
+		Active:     &active,
+		Meta: &SCIMMetaDTO{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     location,
+		},
+	}
+	if user.Phone != "" {
+		resource.PhoneNumbers = []SCIMValueDTO{{Value: user.Phone, Type: "work"}}
+	}
+	return resource
+}
@@ -8,34 +8,76 @@ import (
 )
 
 type CreateTaskDTO struct {
-	Title       string `json:"title" binding:"required,max=100"`
-	Description string `json:"description" binding:"max=500"`
+	Title       string     `json:"title" binding:"required,max=100"`
+	Description string     `json:"description" binding:"max=500"`
+	Priority    string     `json:"priority" binding:"omitempty,oneof=none low medium high urgent"`
+	Tags        []string   `json:"tags" binding:"omitempty,dive,required,max=50"`
+	DueDate     *time.Time `json:"due_date"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	ProjectID   *uuid.UUID `json:"project_id"`
+
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
 type UpdateTaskDTO struct {
-	Title       string `json:"title" binding:"omitempty,max=100"`
-	Description string `json:"description" binding:"omitempty,max=500"`
-	Status      bool   `json:"status"`
+	Title       string     `json:"title" binding:"omitempty,max=100"`
+	Description string     `json:"description" binding:"omitempty,max=500"`
+	Status      *bool      `json:"status"`
+	Priority    string     `json:"priority" binding:"omitempty,oneof=none low medium high urgent"`
+	Tags        []string   `json:"tags" binding:"omitempty,dive,required,max=50"`
+	DueDate     *time.Time `json:"due_date"`
+
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
 type TaskResponseDTO struct {
-	ID          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      bool      `json:"status"`
-	UserID      uuid.UUID `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uuid.UUID              `json:"id"`
+	Title        string                 `json:"title"`
+	Description  string                 `json:"description"`
+	Status       bool                   `json:"status"`
+	Priority     string                 `json:"priority"`
+	Tags         []string               `json:"tags"`
+	DueDate      *time.Time             `json:"due_date,omitempty"`
+	ParentID     *uuid.UUID             `json:"parent_id,omitempty"`
+	Subtasks     []TaskResponseDTO      `json:"subtasks,omitempty"`
+	ProjectID    *uuid.UUID             `json:"project_id,omitempty"`
+	State        string                 `json:"state,omitempty"`
+	Position     int                    `json:"position"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+	UserID       uuid.UUID              `json:"user_id"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
 }
 
 func NewTaskResponseDTO(task *models.Task) *TaskResponseDTO {
-	return &TaskResponseDTO{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		UserID:      task.UserID,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
+	tags := []string(task.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	customFields := map[string]interface{}(task.CustomFields)
+	if customFields == nil {
+		customFields = map[string]interface{}{}
+	}
+
+	response := &TaskResponseDTO{
+		ID:           task.ID,
+		Title:        task.Title,
+		Description:  task.Description,
+		Status:       task.Status,
+		Priority:     task.Priority.String(),
+		Tags:         tags,
+		DueDate:      task.DueDate,
+		ParentID:     task.ParentID,
+		ProjectID:    task.ProjectID,
+		State:        task.State,
+		Position:     task.Position,
+		CustomFields: customFields,
+		UserID:       task.UserID,
+		CreatedAt:    task.CreatedAt,
+		UpdatedAt:    task.UpdatedAt,
+	}
+	for i := range task.Subtasks {
+		response.Subtasks = append(response.Subtasks, *NewTaskResponseDTO(&task.Subtasks[i]))
 	}
+	return response
 }
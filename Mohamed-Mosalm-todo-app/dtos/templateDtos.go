@@ -0,0 +1,95 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type TemplateItemDTO struct {
+	TitlePattern  string            `json:"title_pattern" binding:"required,max=100"`
+	Description   string            `json:"description" binding:"max=500"`
+	Tags          []string          `json:"tags" binding:"omitempty,dive,required,max=50"`
+	DueOffsetDays *int              `json:"due_offset_days"`
+	Subtasks      []TemplateItemDTO `json:"subtasks" binding:"omitempty,dive"`
+}
+
+type TemplateDTO struct {
+	Name          string            `json:"name" binding:"required,max=100"`
+	TitlePattern  string            `json:"title_pattern" binding:"required,max=100"`
+	Description   string            `json:"description" binding:"max=500"`
+	Tags          []string          `json:"tags" binding:"omitempty,dive,required,max=50"`
+	DueOffsetDays *int              `json:"due_offset_days"`
+	Subtasks      []TemplateItemDTO `json:"subtasks" binding:"omitempty,dive"`
+}
+
+// InstantiateTemplateDTO carries the variables substituted into the
+// template and the anchor date that due offsets are relative to. The anchor
+// accepts either a date (2006-01-02) or an RFC 3339 timestamp and defaults
+// to today.
+type InstantiateTemplateDTO struct {
+	Variables  map[string]string `json:"variables"`
+	AnchorDate string            `json:"anchor_date"`
+}
+
+type TemplateResponseDTO struct {
+	ID            uuid.UUID            `json:"id"`
+	Name          string               `json:"name"`
+	TitlePattern  string               `json:"title_pattern"`
+	Description   string               `json:"description"`
+	Tags          []string             `json:"tags"`
+	DueOffsetDays *int                 `json:"due_offset_days"`
+	Subtasks      models.TemplateItems `json:"subtasks"`
+	UserID        uuid.UUID            `json:"user_id"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+func (d *TemplateDTO) ApplyTo(template *models.TaskTemplate) {
+	template.Name = d.Name
+	template.TitlePattern = d.TitlePattern
+	template.Description = d.Description
+	template.Tags = append(models.StringList{}, d.Tags...)
+	template.DueOffsetDays = d.DueOffsetDays
+	template.Subtasks = templateItemsFromDTO(d.Subtasks)
+}
+
+func templateItemsFromDTO(itemDTOs []TemplateItemDTO) models.TemplateItems {
+	items := make(models.TemplateItems, len(itemDTOs))
+	for i, item := range itemDTOs {
+		items[i] = models.TemplateItem{
+			TitlePattern:  item.TitlePattern,
+			Description:   item.Description,
+			Tags:          item.Tags,
+			DueOffsetDays: item.DueOffsetDays,
+			Subtasks:      templateItemsFromDTO(item.Subtasks),
+		}
+	}
+	return items
+}
+
+func (d *InstantiateTemplateDTO) Anchor(now time.Time) (time.Time, error) {
+	if d.AnchorDate == "" {
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	if anchor, err := time.Parse(time.DateOnly, d.AnchorDate); err == nil {
+		return anchor, nil
+	}
+	return time.Parse(time.RFC3339, d.AnchorDate)
+}
+
+func NewTemplateResponseDTO(template *models.TaskTemplate) *TemplateResponseDTO {
+	return &TemplateResponseDTO{
+		ID:            template.ID,
+		Name:          template.Name,
+		TitlePattern:  template.TitlePattern,
+		Description:   template.Description,
+		Tags:          template.Tags,
+		DueOffsetDays: template.DueOffsetDays,
+		Subtasks:      template.Subtasks,
+		UserID:        template.UserID,
+		CreatedAt:     template.CreatedAt,
+		UpdatedAt:     template.UpdatedAt,
+	}
+}
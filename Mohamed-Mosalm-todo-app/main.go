@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/MohamedMosalm/Todo-App/cmd"
 	"github.com/MohamedMosalm/Todo-App/config"
@@ -12,5 +13,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("config file setup failed, err: %v", err)
 	}
+	if len(os.Args) > 1 {
+		if err := cmd.RunCommand(cfg, os.Args[1:]); err != nil {
+			log.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
 	cmd.StartServer(cfg)
 }
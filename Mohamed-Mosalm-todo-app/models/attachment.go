@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Attachment struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TaskID      uuid.UUID `json:"task_id" gorm:"type:uuid;not null;index"`
+	Task        Task      `json:"-" gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE"`
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	FileName    string    `json:"file_name" gorm:"not null"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	StorageKey  string    `json:"-" gorm:"not null;uniqueIndex"`
+	CreatedAt   time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audited actions.
+const (
+	AuditUsersList          = "users.list"
+	AuditUserCreate         = "user.create"
+	AuditUserView           = "user.view"
+	AuditUserDeactivate     = "user.deactivate"
+	AuditUserReactivate     = "user.reactivate"
+	AuditUserPasswordReset  = "user.password_reset"
+	AuditUserSessionsRevoke = "user.sessions_revoke"
+	AuditUserImpersonate    = "user.impersonate"
+	AuditUserRoleChange     = "user.role_change"
+	AuditRoleCreate         = "role.create"
+	AuditRoleUpdate         = "role.update"
+	AuditRoleDelete         = "role.delete"
+	AuditLogRead            = "audit.read"
+
+	// AuditImpersonatedRequest is a request made with an impersonation
+	// token; the actor is the admin and the target the impersonated user.
+	AuditImpersonatedRequest = "impersonation.request"
+)
+
+// AuditEvent records an administrative action. ActorID is who took it, and
+// is nil for actions run from the command line. TargetID is the user acted
+// on, if any.
+type AuditEvent struct {
+	ID        uuid.UUID    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ActorID   *uuid.UUID   `json:"actor_id" gorm:"type:uuid;index"`
+	Action    string       `json:"action" gorm:"not null;index"`
+	TargetID  *uuid.UUID   `json:"target_id" gorm:"type:uuid;index"`
+	Details   AuditDetails `json:"details" gorm:"type:jsonb;not null;default:'{}'"`
+	IP        string       `json:"ip"`
+	UserAgent string       `json:"user_agent"`
+	CreatedAt time.Time    `json:"created_at" gorm:"default:CURRENT_TIMESTAMP;index"`
+}
+
+// AuditDetails describe an audited action, stored as a JSONB object.
+type AuditDetails map[string]interface{}
+
+func (AuditDetails) GormDataType() string {
+	return "jsonb"
+}
+
+func (d AuditDetails) Value() (driver.Value, error) {
+	if d == nil {
+		return "{}", nil
+	}
+	return jsonValue(d)
+}
+
+func (d *AuditDetails) Scan(value interface{}) error {
+	return jsonScan(value, d)
+}
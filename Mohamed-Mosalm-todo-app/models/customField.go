@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CustomFieldType string
+
+const (
+	CustomFieldText        CustomFieldType = "text"
+	CustomFieldNumber      CustomFieldType = "number"
+	CustomFieldDate        CustomFieldType = "date"
+	CustomFieldSelect      CustomFieldType = "select"
+	CustomFieldMultiSelect CustomFieldType = "multi_select"
+)
+
+// CustomField is one entry of a project's custom field schema. Options only
+// apply to select and multi_select fields.
+type CustomField struct {
+	ID        uuid.UUID       `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ProjectID uuid.UUID       `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_custom_fields_project_key"`
+	Key       string          `json:"key" gorm:"not null;uniqueIndex:idx_custom_fields_project_key"`
+	Name      string          `json:"name" gorm:"not null"`
+	Type      CustomFieldType `json:"type" gorm:"not null"`
+	Options   StringList      `json:"options" gorm:"type:jsonb;not null;default:'[]'"`
+	Required  bool            `json:"required" gorm:"not null;default:false"`
+	Position  int             `json:"position" gorm:"not null"`
+	CreatedAt time.Time       `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time       `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// CustomFieldValues holds a task's custom field values keyed by field key,
+// stored as a JSONB object.
+type CustomFieldValues map[string]interface{}
+
+func (CustomFieldValues) GormDataType() string {
+	return "jsonb"
+}
+
+func (v CustomFieldValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	return jsonValue(v)
+}
+
+func (v *CustomFieldValues) Scan(value interface{}) error {
+	return jsonScan(value, v)
+}
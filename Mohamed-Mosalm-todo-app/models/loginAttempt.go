@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// LoginAttempt counts consecutive failed logins for one key, either an
+// account ("account:<email>") or a client address ("ip:<addr>"). The count
+// starts over once ExpiresAt passes without another failure. Magic link
+// requests per email are counted the same way, as "magic_link:<email>".
+type LoginAttempt struct {
+	Key           string    `gorm:"primaryKey"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTPCredential is a user's authenticator app enrolment. It only takes
+// effect once ConfirmedAt is set, which happens after the user proves the
+// app works by entering a first code. LastCounter is the time step of the
+// last accepted code, so no code is accepted twice.
+type TOTPCredential struct {
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	User        User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Secret      string    `gorm:"not null"`
+	ConfirmedAt *time.Time
+	LastCounter int64     `gorm:"not null;default:0"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// RecoveryCode is a single-use fallback for a lost authenticator. Only a
+// SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	User     User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CodeHash string    `gorm:"not null"`
+	UsedAt   *time.Time
+}
@@ -0,0 +1,13 @@
+package models
+
+// All lists every model with a table of its own, in migration order. Has-many
+// children must be listed too: GORM only migrates the tables a model belongs
+// to along with it.
+func All() []interface{} {
+	return []interface{}{
+		&User{}, &Project{}, &CustomField{}, &BoardColumn{}, &Task{}, &TaskTemplate{}, &Attachment{}, &SavedFilter{},
+		&Session{}, &RefreshToken{}, &RevokedToken{}, &OneTimeToken{}, &TOTPCredential{}, &RecoveryCode{},
+		&LoginAttempt{}, &APIKey{}, &UserIdentity{}, &OIDCLoginState{}, &Passkey{}, &PasskeyCeremony{},
+		&Role{}, &AuditEvent{},
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+)
+
+// TestAllCoversRelations fails when a model refers to one whose table is
+// never migrated, which only shows up against a fresh database.
+func TestAllCoversRelations(t *testing.T) {
+	cache := &sync.Map{}
+	migrated := map[string]bool{}
+	var schemas []*schema.Schema
+	for _, model := range All() {
+		s, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		require.NoError(t, err)
+		migrated[s.Table] = true
+		schemas = append(schemas, s)
+	}
+
+	for _, s := range schemas {
+		for name, relation := range s.Relationships.Relations {
+			if relation.JoinTable != nil {
+				continue
+			}
+			assert.True(t, migrated[relation.FieldSchema.Table], "%s.%s refers to %s, which is not migrated", s.Name, name, relation.FieldSchema.Table)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TokenPurpose string
+
+const (
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposeMagicLink         TokenPurpose = "magic_link"
+)
+
+// OneTimeToken is a short-lived, single-use secret sent to a user out of
+// band, such as a password reset link. Only a SHA-256 hash of it is stored,
+// and a token is only ever accepted for the purpose it was issued for.
+type OneTimeToken struct {
+	ID        uuid.UUID    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID    uuid.UUID    `gorm:"type:uuid;not null;index"`
+	User      User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Purpose   TokenPurpose `gorm:"not null"`
+	TokenHash string       `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time    `gorm:"not null"`
+	UsedAt    *time.Time
+	// DeviceHash, if set, is the hash of a secret kept by the device that
+	// asked for the token, which must present it to use the token.
+	DeviceHash string
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
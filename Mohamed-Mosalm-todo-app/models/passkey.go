@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Passkey is a WebAuthn credential a user registered: the public half of a
+// key pair their authenticator keeps. Users may register several, and each
+// one works both for passwordless login and as a second factor.
+// SignCount is the authenticator's last signature counter, which helps
+// notice cloned authenticators.
+type Passkey struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User            User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Name            string     `json:"name" gorm:"not null"`
+	CredentialID    []byte     `json:"-" gorm:"not null;uniqueIndex"`
+	PublicKey       []byte     `json:"-" gorm:"not null"`
+	AttestationType string     `json:"-"`
+	Transports      StringList `json:"transports" gorm:"type:jsonb;not null;default:'[]'"`
+	AAGUID          []byte     `json:"-"`
+	SignCount       uint32     `json:"-" gorm:"not null;default:0"`
+	BackupEligible  bool       `json:"backup_eligible"`
+	BackupState     bool       `json:"backed_up"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type PasskeyCeremonyPurpose string
+
+const (
+	PasskeyCeremonyRegistration PasskeyCeremonyPurpose = "registration"
+	PasskeyCeremonyLogin        PasskeyCeremonyPurpose = "login"
+	PasskeyCeremonySecondFactor PasskeyCeremonyPurpose = "second_factor"
+)
+
+// PasskeyCeremony remembers the challenge sent to an authenticator until
+// its answer comes back. Only a hash of the ceremony token handed to the
+// client is stored. UserID is unset for passwordless logins, where the
+// authenticator tells who is signing in.
+type PasskeyCeremony struct {
+	ID          uuid.UUID              `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TokenHash   string                 `gorm:"not null;uniqueIndex"`
+	Purpose     PasskeyCeremonyPurpose `gorm:"not null"`
+	UserID      *uuid.UUID             `gorm:"type:uuid"`
+	SessionData string                 `gorm:"type:jsonb;not null"`
+	ExpiresAt   time.Time              `gorm:"not null;index"`
+	CreatedAt   time.Time              `gorm:"default:CURRENT_TIMESTAMP"`
+}
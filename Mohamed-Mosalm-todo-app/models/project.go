@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Project struct {
+	ID          uuid.UUID     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name        string        `json:"name" validate:"required"`
+	Description string        `json:"description"`
+	UserID      uuid.UUID     `json:"user_id" gorm:"type:uuid;not null;index"`
+	User        User          `json:"user" gorm:"foreignKey:UserID"`
+	Columns     []BoardColumn `json:"columns" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+	Fields      []CustomField `json:"fields" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time     `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// BoardColumn maps a workflow state of a project to a column on its board.
+// A WIPLimit of zero means the column is unlimited.
+type BoardColumn struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ProjectID uuid.UUID `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_board_columns_project_state"`
+	State     string    `json:"state" gorm:"not null;uniqueIndex:idx_board_columns_project_state"`
+	Name      string    `json:"name" gorm:"not null"`
+	Position  int       `json:"position" gorm:"not null"`
+	WIPLimit  int       `json:"wip_limit" gorm:"not null;default:0"`
+	IsDone    bool      `json:"is_done" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func DefaultBoardColumns() []BoardColumn {
+	return []BoardColumn{
+		{State: "todo", Name: "To Do", Position: 0},
+		{State: "in_progress", Name: "In Progress", Position: 1},
+		{State: "done", Name: "Done", Position: 2, IsDone: true},
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokedToken denylists access tokens until they would have expired anyway.
+// A row either names a single token by JTI, or covers every token issued to
+// UserID before IssuedBefore, which is how "log out everywhere" is recorded.
+type RevokedToken struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	JTI          *string    `gorm:"uniqueIndex"`
+	UserID       *uuid.UUID `gorm:"type:uuid;index"`
+	IssuedBefore *time.Time
+	ExpiresAt    time.Time `gorm:"not null;index"`
+}
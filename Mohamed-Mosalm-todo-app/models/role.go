@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Permissions a role can grant. RoleAdmin holds all of them, RoleUser none.
+const (
+	PermissionUsersRead        = "users:read"
+	PermissionUsersManage      = "users:manage"
+	PermissionUsersImpersonate = "users:impersonate"
+	PermissionRolesManage      = "roles:manage"
+	PermissionAuditRead        = "audit:read"
+)
+
+var Permissions = []string{
+	PermissionUsersRead, PermissionUsersManage, PermissionUsersImpersonate,
+	PermissionRolesManage,
+	PermissionAuditRead,
+}
+
+// IsPermission reports whether permission is one of the Permission
+// constants.
+func IsPermission(permission string) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Role is a named set of permissions. Custom roles are stored; the built-in
+// roles RoleUser and RoleAdmin are not and cannot be changed.
+type Role struct {
+	Name        string     `json:"name" gorm:"primaryKey"`
+	Description string     `json:"description"`
+	Permissions StringList `json:"permissions" gorm:"type:jsonb;not null;default:'[]'"`
+	BuiltIn     bool       `json:"built_in" gorm:"-"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// BuiltInRoles returns the roles every installation has.
+func BuiltInRoles() []Role {
+	return []Role{
+		{Name: RoleUser, Description: "Manages their own tasks and projects", Permissions: StringList{}, BuiltIn: true},
+		{Name: RoleAdmin, Description: "Has every permission", Permissions: append(StringList{}, Permissions...), BuiltIn: true},
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedFilter is a named task query written in the filter query language.
+// The query is stored as text and parsed whenever the filter is evaluated,
+// so relative dates such as "due<7d" always count from the current time.
+type SavedFilter struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name      string    `json:"name" gorm:"not null"`
+	Query     string    `json:"query" gorm:"not null"`
+	SortBy    string    `json:"sort_by"`
+	SortDesc  bool      `json:"sort_desc" gorm:"not null;default:false"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	User      User      `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
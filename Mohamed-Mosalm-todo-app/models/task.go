@@ -7,12 +7,21 @@ import (
 )
 
 type Task struct {
-	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	Title       string    `json:"title" validate:"required"`
-	Description string    `json:"description"`
-	Status      bool      `json:"status"`
-	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
-	User        User      `json:"user" gorm:"foreignKey:UserID"`
-	CreatedAt   time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+	ID           uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Title        string            `json:"title" validate:"required"`
+	Description  string            `json:"description"`
+	Status       bool              `json:"status"`
+	Priority     Priority          `json:"priority" gorm:"not null;default:0;index"`
+	Tags         StringList        `json:"tags" gorm:"type:jsonb;not null;default:'[]'"`
+	DueDate      *time.Time        `json:"due_date"`
+	ParentID     *uuid.UUID        `json:"parent_id" gorm:"type:uuid;index"`
+	Subtasks     []Task            `json:"subtasks,omitempty" gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE"`
+	ProjectID    *uuid.UUID        `json:"project_id" gorm:"type:uuid;index"`
+	State        string            `json:"state" gorm:"index"`
+	Position     int               `json:"position" gorm:"not null;default:0"`
+	CustomFields CustomFieldValues `json:"custom_fields" gorm:"type:jsonb;not null;default:'{}'"`
+	UserID       uuid.UUID         `json:"user_id" gorm:"type:uuid;not null"`
+	User         User              `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt    time.Time         `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt    time.Time         `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskTemplate describes a reusable tree of tasks. Titles and descriptions
+// may reference variables as {{name}}, and due dates are stored as day
+// offsets that are resolved against an anchor date when instantiated.
+type TaskTemplate struct {
+	ID            uuid.UUID     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name          string        `json:"name" gorm:"not null"`
+	TitlePattern  string        `json:"title_pattern" gorm:"not null"`
+	Description   string        `json:"description"`
+	Tags          StringList    `json:"tags" gorm:"type:jsonb;not null;default:'[]'"`
+	DueOffsetDays *int          `json:"due_offset_days"`
+	Subtasks      TemplateItems `json:"subtasks" gorm:"type:jsonb;not null;default:'[]'"`
+	UserID        uuid.UUID     `json:"user_id" gorm:"type:uuid;not null;index"`
+	User          User          `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt     time.Time     `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time     `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type TemplateItem struct {
+	TitlePattern  string        `json:"title_pattern"`
+	Description   string        `json:"description,omitempty"`
+	Tags          []string      `json:"tags,omitempty"`
+	DueOffsetDays *int          `json:"due_offset_days,omitempty"`
+	Subtasks      TemplateItems `json:"subtasks,omitempty"`
+}
+
+// TemplateItems is a list of template subtasks stored as JSONB.
+type TemplateItems []TemplateItem
+
+func (TemplateItems) GormDataType() string {
+	return "jsonb"
+}
+
+func (t TemplateItems) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	return jsonValue(t)
+}
+
+func (t *TemplateItems) Scan(value interface{}) error {
+	return jsonScan(value, t)
+}
@@ -6,6 +6,18 @@ import (
 	"github.com/google/uuid"
 )
 
+// The built-in roles. RoleUser is the default; custom roles are stored as
+// Role records.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsBuiltInRole reports whether role is one of the Role constants.
+func IsBuiltInRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
 type User struct {
 	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	FirstName string    `json:"first_name" validate:"required"`
@@ -13,7 +25,24 @@ type User struct {
 	Email     string    `json:"email" gorm:"uniqueIndex;not null" validate:"required,email"`
 	Phone     string    `json:"phone" validate:"required,phone"`
 	Password  string    `json:"password" validate:"required,min=8"`
-	CreatedAt time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
-	Tasks     []Task    `json:"tasks" gorm:"foreignKey:UserID"`
+	Role      string    `json:"role" gorm:"not null;default:user"`
+	// EmailVerifiedAt is set once the user follows the link emailed at
+	// registration; nil means the address is unconfirmed. DeactivatedAt is
+	// set while the account is deactivated, which keeps the user from
+	// logging in or using their tokens and API keys. DeprovisionedAt is set,
+	// along with DeactivatedAt, once the provisioning client deletes the
+	// user; SCIM then treats them as gone, though their data is kept.
+	// ExternalID is the provisioning client's identifier for the user.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	DeactivatedAt   *time.Time `json:"deactivated_at"`
+	DeprovisionedAt *time.Time `json:"deprovisioned_at"`
+	ExternalID      string     `json:"external_id,omitempty" gorm:"index"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+	Tasks           []Task     `json:"tasks" gorm:"foreignKey:UserID"`
+}
+
+// IsActive reports whether the account is not deactivated.
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
 }
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a user to their account at an external OpenID Connect
+// provider, or to the LDAP directory entry that provisioned them. Subject is
+// the provider's stable ID for the account, or the entry's DN; email
+// addresses can change, so they are only used to link the first login.
+type UserIdentity struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Provider  string    `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject   string    `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email     string
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// OIDCLoginState remembers a login that was sent to a provider until the
+// provider sends the user back. Only a hash of the state parameter is
+// stored; the nonce and PKCE verifier never leave the server.
+type OIDCLoginState struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	StateHash    string    `gorm:"not null;uniqueIndex"`
+	Provider     string    `gorm:"not null"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type APIKeyRepository interface {
+	CreateKey(key *models.APIKey) error
+	FindKeyByHash(keyHash string) (*models.APIKey, error)
+	// GetKeysByUserID returns the user's unrevoked keys, newest first.
+	GetKeysByUserID(userID uuid.UUID) ([]models.APIKey, error)
+	// RevokeKey revokes the key if it belongs to userID and reports whether
+	// it did.
+	RevokeKey(keyID, userID uuid.UUID, now time.Time) (bool, error)
+	TouchKey(keyID uuid.UUID, now time.Time) error
+}
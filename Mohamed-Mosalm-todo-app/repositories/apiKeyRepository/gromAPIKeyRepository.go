@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &gormAPIKeyRepository{db: db}
+}
+
+func (r *gormAPIKeyRepository) CreateKey(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *gormAPIKeyRepository) FindKeyByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *gormAPIKeyRepository) GetKeysByUserID(userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *gormAPIKeyRepository) RevokeKey(keyID, userID uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormAPIKeyRepository) TouchKey(keyID uuid.UUID, now time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", keyID).Update("last_used_at", now).Error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type AttachmentRepository interface {
+	CreateAttachment(attachment *models.Attachment) error
+	// CreateAttachmentWithinQuota creates the attachment unless it would
+	// take its user's attachments past quota bytes, and reports whether it
+	// did. Concurrent calls for the same user are serialized.
+	CreateAttachmentWithinQuota(attachment *models.Attachment, quota int64) (bool, error)
+	GetAttachmentsByTaskID(taskID uuid.UUID) ([]models.Attachment, error)
+	GetAttachmentByID(attachmentID uuid.UUID) (*models.Attachment, error)
+	DeleteAttachment(attachmentID uuid.UUID) error
+	TotalSizeByUserID(userID uuid.UUID) (int64, error)
+}
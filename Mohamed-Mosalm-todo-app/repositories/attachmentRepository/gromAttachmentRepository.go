@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormAttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &gormAttachmentRepository{db: db}
+}
+
+func (r *gormAttachmentRepository) CreateAttachment(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+func (r *gormAttachmentRepository) CreateAttachmentWithinQuota(attachment *models.Attachment, quota int64) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the user's row makes uploads of the same user take turns.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", attachment.UserID).
+			First(&models.User{}).Error
+		if err != nil {
+			return err
+		}
+
+		var used int64
+		err = tx.Model(&models.Attachment{}).
+			Where("user_id = ?", attachment.UserID).
+			Select("COALESCE(SUM(size), 0)").
+			Scan(&used).Error
+		if err != nil || used+attachment.Size > quota {
+			return err
+		}
+		if err := tx.Create(attachment).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (r *gormAttachmentRepository) GetAttachmentsByTaskID(taskID uuid.UUID) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	if err := r.db.Where("task_id = ?", taskID).Order("created_at").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *gormAttachmentRepository) GetAttachmentByID(attachmentID uuid.UUID) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := r.db.Where("id = ?", attachmentID).First(&attachment).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *gormAttachmentRepository) DeleteAttachment(attachmentID uuid.UUID) error {
+	return r.db.Where("id = ?", attachmentID).Delete(&models.Attachment{}).Error
+}
+
+func (r *gormAttachmentRepository) TotalSizeByUserID(userID uuid.UUID) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.Attachment{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+// AuditRepository appends to the audit log; events are never changed.
+type AuditRepository interface {
+	CreateEvent(event *models.AuditEvent) error
+	FindEvents(criteria AuditCriteria) ([]models.AuditEvent, int64, error)
+}
+
+// AuditCriteria narrows an audit log listing; zero fields match every
+// event. Offset and Limit page through the matches, newest first.
+type AuditCriteria struct {
+	ActorID  *uuid.UUID
+	TargetID *uuid.UUID
+	Action   string
+	Since    *time.Time
+	Offset   int
+	Limit    int
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"gorm.io/gorm"
+)
+
+type gormAuditRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAuditRepository(db *gorm.DB) AuditRepository {
+	return &gormAuditRepository{db: db}
+}
+
+func (r *gormAuditRepository) CreateEvent(event *models.AuditEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *gormAuditRepository) FindEvents(criteria AuditCriteria) ([]models.AuditEvent, int64, error) {
+	query := r.db.Model(&models.AuditEvent{})
+	if criteria.ActorID != nil {
+		query = query.Where("actor_id = ?", *criteria.ActorID)
+	}
+	if criteria.TargetID != nil {
+		query = query.Where("target_id = ?", *criteria.TargetID)
+	}
+	if criteria.Action != "" {
+		query = query.Where("action = ?", criteria.Action)
+	}
+	if criteria.Since != nil {
+		query = query.Where("created_at >= ?", *criteria.Since)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	if err := query.Order("created_at DESC, id").Offset(criteria.Offset).Limit(criteria.Limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
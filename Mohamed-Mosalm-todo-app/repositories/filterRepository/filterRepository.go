@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type FilterRepository interface {
+	CreateFilter(filter *models.SavedFilter) error
+	GetFiltersByUserID(userID uuid.UUID) ([]models.SavedFilter, error)
+	GetFilterByID(filterID uuid.UUID) (*models.SavedFilter, error)
+	UpdateFilter(filter *models.SavedFilter) error
+	DeleteFilter(filterID, userID uuid.UUID) error
+}
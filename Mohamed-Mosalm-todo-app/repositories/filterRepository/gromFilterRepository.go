@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormFilterRepository struct {
+	db *gorm.DB
+}
+
+func NewGormFilterRepository(db *gorm.DB) FilterRepository {
+	return &gormFilterRepository{db: db}
+}
+
+func (r *gormFilterRepository) CreateFilter(filter *models.SavedFilter) error {
+	return r.db.Create(filter).Error
+}
+
+func (r *gormFilterRepository) GetFiltersByUserID(userID uuid.UUID) ([]models.SavedFilter, error) {
+	var filters []models.SavedFilter
+	if err := r.db.Where("user_id = ?", userID).Order("name").Find(&filters).Error; err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+func (r *gormFilterRepository) GetFilterByID(filterID uuid.UUID) (*models.SavedFilter, error) {
+	var filter models.SavedFilter
+	if err := r.db.Where("id = ?", filterID).First(&filter).Error; err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
+
+func (r *gormFilterRepository) UpdateFilter(filter *models.SavedFilter) error {
+	return r.db.Model(filter).Select("name", "query", "sort_by", "sort_desc").Updates(filter).Error
+}
+
+func (r *gormFilterRepository) DeleteFilter(filterID, userID uuid.UUID) error {
+	return r.db.Where("id = ? AND user_id = ?", filterID, userID).Delete(&models.SavedFilter{}).Error
+}
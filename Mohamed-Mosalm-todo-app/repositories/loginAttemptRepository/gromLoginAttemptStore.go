@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormLoginAttemptStore struct {
+	db *gorm.DB
+}
+
+func NewGormLoginAttemptStore(db *gorm.DB) LoginAttemptStore {
+	return &gormLoginAttemptStore{db: db}
+}
+
+// RecordFailure upserts the counter in one statement so concurrent
+// failures cannot overwrite each other's increments.
+func (r *gormLoginAttemptStore) RecordFailure(key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	attempt := models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now, ExpiresAt: now.Add(window)}
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":        gorm.Expr("CASE WHEN login_attempts.expires_at <= excluded.last_failure_at THEN 1 ELSE login_attempts.failures + 1 END"),
+				"last_failure_at": gorm.Expr("excluded.last_failure_at"),
+				"expires_at":      gorm.Expr("excluded.expires_at"),
+			}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "failures"}}},
+	).Create(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *gormLoginAttemptStore) GetAttempt(key string, now time.Time) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.db.Where("key = ? AND expires_at > ?", key, now).First(&attempt).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *gormLoginAttemptStore) Reset(key string) error {
+	return r.db.Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+func (r *gormLoginAttemptStore) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.LoginAttempt{}).Error
+}
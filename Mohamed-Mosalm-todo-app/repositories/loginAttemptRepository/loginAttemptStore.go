@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"log"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+)
+
+// LoginAttemptStore keeps the failed-login counters behind account lockout.
+type LoginAttemptStore interface {
+	// RecordFailure counts a failure for key at now and returns the updated
+	// counter. A counter whose window has passed starts again from one.
+	RecordFailure(key string, now time.Time, window time.Duration) (*models.LoginAttempt, error)
+	// GetAttempt returns the live counter for key, or nil if there is none.
+	GetAttempt(key string, now time.Time) (*models.LoginAttempt, error)
+	Reset(key string) error
+	DeleteExpired(now time.Time) error
+}
+
+// StartCleanup calls DeleteExpired on store every interval until the
+// returned stop function is called.
+func StartCleanup(store LoginAttemptStore, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				if err := store.DeleteExpired(now); err != nil {
+					log.Printf("login attempt cleanup failed: %v", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+)
+
+type memoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+// NewMemoryLoginAttemptStore keeps counters in process memory. Each instance
+// counts on its own, so behind a load balancer an attacker gets the
+// allowance once per instance.
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &memoryLoginAttemptStore{attempts: make(map[string]models.LoginAttempt)}
+}
+
+func (s *memoryLoginAttemptStore) RecordFailure(key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempt, ok := s.attempts[key]
+	if !ok || !now.Before(attempt.ExpiresAt) {
+		attempt = models.LoginAttempt{Key: key}
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	attempt.ExpiresAt = now.Add(window)
+	s.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (s *memoryLoginAttemptStore) GetAttempt(key string, now time.Time) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempt, ok := s.attempts[key]
+	if !ok || !now.Before(attempt.ExpiresAt) {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (s *memoryLoginAttemptStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *memoryLoginAttemptStore) DeleteExpired(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, attempt := range s.attempts {
+		if !now.Before(attempt.ExpiresAt) {
+			delete(s.attempts, key)
+		}
+	}
+	return nil
+}
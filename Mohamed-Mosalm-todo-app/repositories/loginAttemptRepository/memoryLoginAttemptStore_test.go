@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLoginAttemptStore(t *testing.T) {
+	store := NewMemoryLoginAttemptStore()
+	now := time.Now()
+
+	attempt, err := store.RecordFailure("ip:203.0.113.7", now, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+	attempt, _ = store.RecordFailure("ip:203.0.113.7", now.Add(time.Minute), time.Hour)
+	assert.Equal(t, 2, attempt.Failures)
+
+	attempt, _ = store.GetAttempt("ip:203.0.113.7", now.Add(time.Minute))
+	assert.Equal(t, 2, attempt.Failures)
+	attempt, _ = store.GetAttempt("ip:203.0.113.7", now.Add(2*time.Hour))
+	assert.Nil(t, attempt, "counters expire a window after the last failure")
+
+	attempt, _ = store.RecordFailure("ip:203.0.113.7", now.Add(2*time.Hour), time.Hour)
+	assert.Equal(t, 1, attempt.Failures, "an expired counter starts over")
+
+	assert.NoError(t, store.Reset("ip:203.0.113.7"))
+	attempt, _ = store.GetAttempt("ip:203.0.113.7", now.Add(2*time.Hour))
+	assert.Nil(t, attempt)
+
+	store.RecordFailure("account:a@example.com", now, time.Hour)
+	assert.NoError(t, store.DeleteExpired(now.Add(time.Hour)))
+	attempt, _ = store.GetAttempt("account:a@example.com", now)
+	assert.Nil(t, attempt)
+}
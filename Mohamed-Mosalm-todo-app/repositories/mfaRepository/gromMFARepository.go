@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormMFARepository struct {
+	db *gorm.DB
+}
+
+func NewGormMFARepository(db *gorm.DB) MFARepository {
+	return &gormMFARepository{db: db}
+}
+
+func (r *gormMFARepository) GetCredential(userID uuid.UUID) (*models.TOTPCredential, error) {
+	var credential models.TOTPCredential
+	if err := r.db.Where("user_id = ?", userID).First(&credential).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *gormMFARepository) SaveCredential(credential *models.TOTPCredential) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_counter", "created_at"}),
+	}).Create(credential).Error
+}
+
+func (r *gormMFARepository) ConfirmCredential(userID uuid.UUID, counter int64, now time.Time, recoveryCodes []models.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.TOTPCredential{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"confirmed_at": now, "last_counter": counter}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&recoveryCodes).Error
+	})
+}
+
+func (r *gormMFARepository) AdvanceCounter(userID uuid.UUID, counter int64) (bool, error) {
+	result := r.db.Model(&models.TOTPCredential{}).
+		Where("user_id = ? AND last_counter < ?", userID, counter).
+		Update("last_counter", counter)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormMFARepository) ConsumeRecoveryCode(userID uuid.UUID, codeHash string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormMFARepository) DeleteCredential(userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.TOTPCredential{}).Error
+	})
+}
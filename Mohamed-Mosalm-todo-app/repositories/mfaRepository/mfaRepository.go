@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type MFARepository interface {
+	GetCredential(userID uuid.UUID) (*models.TOTPCredential, error)
+	// SaveCredential replaces any credential the user already has.
+	SaveCredential(credential *models.TOTPCredential) error
+	// ConfirmCredential enables the credential and replaces the user's
+	// recovery codes in one transaction.
+	ConfirmCredential(userID uuid.UUID, counter int64, now time.Time, recoveryCodes []models.RecoveryCode) error
+	// AdvanceCounter records counter as the last accepted time step unless
+	// a code at or after it was accepted already, reporting which happened.
+	AdvanceCounter(userID uuid.UUID, counter int64) (bool, error)
+	ConsumeRecoveryCode(userID uuid.UUID, codeHash string, now time.Time) (bool, error)
+	// DeleteCredential removes the credential and all recovery codes.
+	DeleteCredential(userID uuid.UUID) error
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormOIDCRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOIDCRepository(db *gorm.DB) OIDCRepository {
+	return &gormOIDCRepository{db: db}
+}
+
+func (r *gormOIDCRepository) CreateLoginState(state *models.OIDCLoginState) error {
+	return r.db.Create(state).Error
+}
+
+func (r *gormOIDCRepository) ConsumeLoginState(stateHash string) (*models.OIDCLoginState, error) {
+	var states []models.OIDCLoginState
+	result := r.db.Clauses(clause.Returning{}).Where("state_hash = ?", stateHash).Delete(&states)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &states[0], nil
+}
+
+func (r *gormOIDCRepository) DeleteExpiredLoginStates(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.OIDCLoginState{}).Error
+}
+
+func (r *gormOIDCRepository) FindIdentity(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *gormOIDCRepository) FindUserIdentity(userID uuid.UUID, provider string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.Where("user_id = ? AND provider = ?", userID, provider).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *gormOIDCRepository) CreateIdentity(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *gormOIDCRepository) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
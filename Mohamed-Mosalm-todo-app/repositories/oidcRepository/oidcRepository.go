@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type OIDCRepository interface {
+	CreateLoginState(state *models.OIDCLoginState) error
+	// ConsumeLoginState deletes the login state with stateHash and returns
+	// it, so that each state is only accepted once.
+	ConsumeLoginState(stateHash string) (*models.OIDCLoginState, error)
+	DeleteExpiredLoginStates(now time.Time) error
+
+	FindIdentity(provider, subject string) (*models.UserIdentity, error)
+	// FindUserIdentity returns the identity linking the user to provider.
+	FindUserIdentity(userID uuid.UUID, provider string) (*models.UserIdentity, error)
+	CreateIdentity(identity *models.UserIdentity) error
+	// CreateUserWithIdentity creates a user signing in for the first time
+	// together with the identity they signed in with.
+	CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormOneTimeTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOneTimeTokenRepository(db *gorm.DB) OneTimeTokenRepository {
+	return &gormOneTimeTokenRepository{db: db}
+}
+
+func (r *gormOneTimeTokenRepository) CreateToken(token *models.OneTimeToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *gormOneTimeTokenRepository) FindTokenByHash(tokenHash string, purpose models.TokenPurpose) (*models.OneTimeToken, error) {
+	var token models.OneTimeToken
+	if err := r.db.Where("token_hash = ? AND purpose = ?", tokenHash, purpose).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *gormOneTimeTokenRepository) ConsumeToken(tokenID uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormOneTimeTokenRepository) InvalidateUserTokens(userID uuid.UUID, purpose models.TokenPurpose, now time.Time) error {
+	return r.db.Model(&models.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type OneTimeTokenRepository interface {
+	CreateToken(token *models.OneTimeToken) error
+	FindTokenByHash(tokenHash string, purpose models.TokenPurpose) (*models.OneTimeToken, error)
+	// ConsumeToken marks the token used unless it already was, reporting
+	// whether this call was the one that used it.
+	ConsumeToken(tokenID uuid.UUID, now time.Time) (bool, error)
+	// InvalidateUserTokens marks every unused token of userID for purpose
+	// as used.
+	InvalidateUserTokens(userID uuid.UUID, purpose models.TokenPurpose, now time.Time) error
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormPasskeyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormPasskeyRepository(db *gorm.DB) PasskeyRepository {
+	return &gormPasskeyRepository{db: db}
+}
+
+func (r *gormPasskeyRepository) CreatePasskey(passkey *models.Passkey) error {
+	return r.db.Create(passkey).Error
+}
+
+func (r *gormPasskeyRepository) GetPasskeysByUserID(userID uuid.UUID) ([]models.Passkey, error) {
+	var passkeys []models.Passkey
+	if err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&passkeys).Error; err != nil {
+		return nil, err
+	}
+	return passkeys, nil
+}
+
+func (r *gormPasskeyRepository) CountPasskeys(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Passkey{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *gormPasskeyRepository) RecordPasskeyUse(passkeyID uuid.UUID, signCount uint32, backupState bool, now time.Time) error {
+	return r.db.Model(&models.Passkey{}).Where("id = ?", passkeyID).Updates(map[string]interface{}{
+		"sign_count":   signCount,
+		"backup_state": backupState,
+		"last_used_at": now,
+	}).Error
+}
+
+func (r *gormPasskeyRepository) DeletePasskey(passkeyID, userID uuid.UUID) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", passkeyID, userID).Delete(&models.Passkey{})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormPasskeyRepository) CreateCeremony(ceremony *models.PasskeyCeremony) error {
+	return r.db.Create(ceremony).Error
+}
+
+func (r *gormPasskeyRepository) ConsumeCeremony(tokenHash string, purpose models.PasskeyCeremonyPurpose) (*models.PasskeyCeremony, error) {
+	var ceremonies []models.PasskeyCeremony
+	result := r.db.Clauses(clause.Returning{}).Where("token_hash = ? AND purpose = ?", tokenHash, purpose).Delete(&ceremonies)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(ceremonies) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &ceremonies[0], nil
+}
+
+func (r *gormPasskeyRepository) DeleteExpiredCeremonies(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.PasskeyCeremony{}).Error
+}
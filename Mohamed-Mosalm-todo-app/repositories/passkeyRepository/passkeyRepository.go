@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type PasskeyRepository interface {
+	CreatePasskey(passkey *models.Passkey) error
+	// GetPasskeysByUserID returns the user's passkeys, oldest first.
+	GetPasskeysByUserID(userID uuid.UUID) ([]models.Passkey, error)
+	CountPasskeys(userID uuid.UUID) (int64, error)
+	// RecordPasskeyUse stores the signature counter and backup state of a
+	// successful login with the passkey.
+	RecordPasskeyUse(passkeyID uuid.UUID, signCount uint32, backupState bool, now time.Time) error
+	// DeletePasskey deletes the passkey if it belongs to userID and reports
+	// whether it did.
+	DeletePasskey(passkeyID, userID uuid.UUID) (bool, error)
+
+	CreateCeremony(ceremony *models.PasskeyCeremony) error
+	// ConsumeCeremony deletes the ceremony with tokenHash and purpose and
+	// returns it, so that each challenge is only answered once.
+	ConsumeCeremony(tokenHash string, purpose models.PasskeyCeremonyPurpose) (*models.PasskeyCeremony, error)
+	DeleteExpiredCeremonies(now time.Time) error
+}
@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormProjectRepository struct {
+	db *gorm.DB
+}
+
+func NewGormProjectRepository(db *gorm.DB) ProjectRepository {
+	return &gormProjectRepository{db: db}
+}
+
+func (r *gormProjectRepository) CreateProject(project *models.Project) error {
+	return r.db.Create(project).Error
+}
+
+func (r *gormProjectRepository) GetProjectsByUserID(userID uuid.UUID) ([]models.Project, error) {
+	var projects []models.Project
+	err := r.db.Where("user_id = ?", userID).
+		Preload("Columns", orderByPosition).
+		Preload("Fields", orderByPosition).
+		Order("created_at").
+		Find(&projects).Error
+	if err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (r *gormProjectRepository) GetProjectByID(projectID uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	err := r.db.Where("id = ?", projectID).
+		Preload("Columns", orderByPosition).
+		Preload("Fields", orderByPosition).
+		First(&project).Error
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *gormProjectRepository) ReplaceColumns(projectID uuid.UUID, columns []models.BoardColumn) (bool, error) {
+	replaced := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBoard(tx, projectID); err != nil {
+			return err
+		}
+
+		stranded := tx.Model(&models.Task{}).Where("project_id = ?", projectID)
+		if len(columns) > 0 {
+			states := make([]string, len(columns))
+			for i := range columns {
+				states[i] = columns[i].State
+			}
+			stranded = stranded.Where("state NOT IN ?", states)
+		}
+		var count int64
+		err := stranded.Count(&count).Error
+		if err != nil || count > 0 {
+			return err
+		}
+
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.BoardColumn{}).Error; err != nil {
+			return err
+		}
+		for i := range columns {
+			columns[i].ID = uuid.Nil
+			columns[i].ProjectID = projectID
+		}
+		if err := tx.Create(&columns).Error; err != nil {
+			return err
+		}
+		replaced = true
+		return nil
+	})
+	return replaced, err
+}
+
+func (r *gormProjectRepository) ReplaceFields(projectID uuid.UUID, fields []models.CustomField) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.CustomField{}).Error; err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			return nil
+		}
+		for i := range fields {
+			fields[i].ID = uuid.Nil
+			fields[i].ProjectID = projectID
+		}
+		return tx.Create(&fields).Error
+	})
+}
+
+func (r *gormProjectRepository) GetProjectTasks(projectID uuid.UUID) ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.db.Where("project_id = ?", projectID).
+		Order("position").
+		Order("created_at").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *gormProjectRepository) CreateTaskInColumn(task *models.Task, wipLimit int) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		count, hasRoom, err := columnHasRoom(tx, *task.ProjectID, task.State, wipLimit)
+		if err != nil || !hasRoom {
+			return err
+		}
+		task.Position = int(count)
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// MoveTask places the task in the given state at position, closing the gap
+// it leaves and shifting the tasks that follow it in the new column. A
+// negative position appends it to the column.
+func (r *gormProjectRepository) MoveTask(task *models.Task, state string, position int, done bool, wipLimit int, updates map[string]interface{}) (bool, error) {
+	if task.State == state {
+		wipLimit = 0
+	}
+
+	moved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, hasRoom, err := columnHasRoom(tx, *task.ProjectID, state, wipLimit); err != nil || !hasRoom {
+			return err
+		}
+
+		var current models.Task
+		if err := tx.Select("state", "position").Where("id = ?", task.ID).First(&current).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.Task{}).
+			Where("project_id = ? AND state = ? AND position > ?", task.ProjectID, current.State, current.Position).
+			UpdateColumn("position", gorm.Expr("position - 1")).Error
+		if err != nil {
+			return err
+		}
+
+		column := tx.Model(&models.Task{}).
+			Where("project_id = ? AND state = ? AND id <> ?", task.ProjectID, state, task.ID)
+
+		if position < 0 {
+			var last struct{ Max *int }
+			if err := column.Select("MAX(position) AS max").Scan(&last).Error; err != nil {
+				return err
+			}
+			position = 0
+			if last.Max != nil {
+				position = *last.Max + 1
+			}
+		} else {
+			err := column.Where("position >= ?", position).
+				UpdateColumn("position", gorm.Expr("position + 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		moveUpdates := map[string]interface{}{}
+		for key, value := range updates {
+			moveUpdates[key] = value
+		}
+		moveUpdates["state"] = state
+		moveUpdates["position"] = position
+		moveUpdates["status"] = done
+		if err := tx.Model(task).Updates(moveUpdates).Error; err != nil {
+			return err
+		}
+		task.State = state
+		task.Position = position
+		task.Status = done
+		moved = true
+		return nil
+	})
+	return moved, err
+}
+
+// columnHasRoom counts the tasks in the column for state and reports
+// whether it holds fewer than wipLimit. It first locks the board, so that
+// concurrent writers count one after the other.
+func columnHasRoom(tx *gorm.DB, projectID uuid.UUID, state string, wipLimit int) (int64, bool, error) {
+	if err := lockBoard(tx, projectID); err != nil {
+		return 0, false, err
+	}
+
+	var count int64
+	err := tx.Model(&models.Task{}).
+		Where("project_id = ? AND state = ?", projectID, state).
+		Count(&count).Error
+	if err != nil {
+		return 0, false, err
+	}
+	return count, wipLimit == 0 || count < int64(wipLimit), nil
+}
+
+// lockBoard locks the project's row until the transaction ends. Every write
+// that places tasks in columns or changes the columns takes it first, so
+// they take turns. The lock still lets tasks reference the project.
+func lockBoard(tx *gorm.DB, projectID uuid.UUID) error {
+	return tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).
+		Select("id").
+		Where("id = ?", projectID).
+		First(&models.Project{}).Error
+}
+
+func orderByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
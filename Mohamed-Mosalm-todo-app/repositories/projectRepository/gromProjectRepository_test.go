@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/MohamedMosalm/Todo-App/database/databasetest"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newTestProject creates a project with the default board and one custom
+// field, owned by a new user.
+func newTestProject(t *testing.T, db *gorm.DB) *models.Project {
+	user := databasetest.CreateUser(t, db)
+
+	project := &models.Project{
+		Name:    "Launch",
+		UserID:  user.ID,
+		Columns: models.DefaultBoardColumns(),
+		Fields: []models.CustomField{
+			{Key: "estimate", Name: "Estimate", Type: models.CustomFieldNumber, Options: models.StringList{}},
+		},
+	}
+	require.NoError(t, NewGormProjectRepository(db).CreateProject(project))
+	return project
+}
+
+func TestGormProjectRepositoryLoadsBoard(t *testing.T) {
+	db := databasetest.Open(t)
+	repo := NewGormProjectRepository(db)
+	project := newTestProject(t, db)
+
+	loaded, err := repo.GetProjectByID(project.ID)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Columns, 3)
+	require.Len(t, loaded.Fields, 1)
+	assert.Equal(t, "estimate", loaded.Fields[0].Key)
+
+	projects, err := repo.GetProjectsByUserID(project.UserID)
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+	assert.Len(t, projects[0].Fields, 1)
+}
+
+// boardPositions returns the titles of the tasks in each state, in order.
+func boardPositions(t *testing.T, repo ProjectRepository, projectID uuid.UUID) map[string][]string {
+	tasks, err := repo.GetProjectTasks(projectID)
+	require.NoError(t, err)
+	board := map[string][]string{}
+	for _, task := range tasks {
+		require.Equal(t, len(board[task.State]), task.Position, "%s is not packed", task.Title)
+		board[task.State] = append(board[task.State], task.Title)
+	}
+	return board
+}
+
+func TestGormProjectRepositoryMoveTaskKeepsPositionsPacked(t *testing.T) {
+	db := databasetest.Open(t)
+	repo := NewGormProjectRepository(db)
+	project := newTestProject(t, db)
+
+	tasks := map[string]*models.Task{}
+	for _, title := range []string{"a", "b", "c"} {
+		task := &models.Task{Title: title, UserID: project.UserID, ProjectID: &project.ID, State: "todo"}
+		created, err := repo.CreateTaskInColumn(task, 0)
+		require.NoError(t, err)
+		require.True(t, created)
+		tasks[title] = task
+	}
+
+	moved, err := repo.MoveTask(tasks["a"], "in_progress", -1, false, 0, map[string]interface{}{"title": "a2"})
+	require.NoError(t, err)
+	require.True(t, moved)
+	assert.Equal(t, map[string][]string{"todo": {"b", "c"}, "in_progress": {"a2"}}, boardPositions(t, repo, project.ID))
+
+	moved, err = repo.MoveTask(tasks["c"], "todo", 0, false, 0, nil)
+	require.NoError(t, err)
+	require.True(t, moved)
+	assert.Equal(t, map[string][]string{"todo": {"c", "b"}, "in_progress": {"a2"}}, boardPositions(t, repo, project.ID))
+
+	// Dropping a column that still holds tasks is refused.
+	replaced, err := repo.ReplaceColumns(project.ID, []models.BoardColumn{{State: "todo", Name: "To Do"}, {State: "done", Name: "Done", Position: 1, IsDone: true}})
+	require.NoError(t, err)
+	assert.False(t, replaced)
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type ProjectRepository interface {
+	CreateProject(project *models.Project) error
+	GetProjectsByUserID(userID uuid.UUID) ([]models.Project, error)
+	GetProjectByID(projectID uuid.UUID) (*models.Project, error)
+	// ReplaceColumns replaces the project's board columns unless a state
+	// the new columns drop still holds tasks, and reports whether it did.
+	ReplaceColumns(projectID uuid.UUID, columns []models.BoardColumn) (bool, error)
+	ReplaceFields(projectID uuid.UUID, fields []models.CustomField) error
+	GetProjectTasks(projectID uuid.UUID) ([]models.Task, error)
+	// CreateTaskInColumn creates the task at the end of the column for its
+	// state, unless the column already holds wipLimit tasks; it reports
+	// whether the task was created. A wipLimit of 0 means no limit.
+	CreateTaskInColumn(task *models.Task, wipLimit int) (bool, error)
+	// MoveTask is CreateTaskInColumn for an existing task; the limit does not
+	// apply to reordering within the task's own column. Any other updates
+	// are applied to the task along with the move.
+	MoveTask(task *models.Task, state string, position int, done bool, wipLimit int, updates map[string]interface{}) (bool, error)
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRepository interface {
+	CreateToken(token *models.RefreshToken) error
+	FindTokenByHash(tokenHash string) (*models.RefreshToken, error)
+	// RotateToken marks current as used and stores next in one transaction.
+	// It reports false without storing next when current was already used
+	// or revoked by a concurrent request.
+	RotateToken(current, next *models.RefreshToken, now time.Time) (bool, error)
+	RevokeFamily(familyID uuid.UUID, now time.Time) error
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormRevocationStore struct {
+	db *gorm.DB
+}
+
+func NewGormRevocationStore(db *gorm.DB) RevocationStore {
+	return &gormRevocationStore{db: db}
+}
+
+func (r *gormRevocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{JTI: &jti, ExpiresAt: expiresAt}).Error
+}
+
+func (r *gormRevocationStore) RevokeUserTokens(userID uuid.UUID, issuedBefore, expiresAt time.Time) error {
+	return r.db.Create(&models.RevokedToken{UserID: &userID, IssuedBefore: &issuedBefore, ExpiresAt: expiresAt}).Error
+}
+
+func (r *gormRevocationStore) IsRevoked(jti string, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).
+		Where("expires_at > ?", time.Now()).
+		Where(r.db.Where("jti = ?", jti).Or("user_id = ? AND issued_before > ?", userID, issuedAt)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormRevocationStore) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.RevokedToken{}).Error
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type userRevocation struct {
+	issuedBefore time.Time
+	expiresAt    time.Time
+}
+
+type memoryRevocationStore struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[uuid.UUID]userRevocation
+}
+
+// NewMemoryRevocationStore keeps revocations in process memory. They are lost
+// on restart and invisible to other instances.
+func NewMemoryRevocationStore() RevocationStore {
+	return &memoryRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[uuid.UUID]userRevocation),
+	}
+}
+
+func (s *memoryRevocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.tokens[jti]; !ok || expiresAt.After(current) {
+		s.tokens[jti] = expiresAt
+	}
+	return nil
+}
+
+func (s *memoryRevocationStore) RevokeUserTokens(userID uuid.UUID, issuedBefore, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.users[userID]
+	if issuedBefore.After(current.issuedBefore) {
+		current.issuedBefore = issuedBefore
+	}
+	if expiresAt.After(current.expiresAt) {
+		current.expiresAt = expiresAt
+	}
+	s.users[userID] = current
+	return nil
+}
+
+func (s *memoryRevocationStore) IsRevoked(jti string, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if expiresAt, ok := s.tokens[jti]; ok && now.Before(expiresAt) {
+		return true, nil
+	}
+	if revocation, ok := s.users[userID]; ok && now.Before(revocation.expiresAt) {
+		return issuedAt.Before(revocation.issuedBefore), nil
+	}
+	return false, nil
+}
+
+func (s *memoryRevocationStore) DeleteExpired(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti, expiresAt := range s.tokens {
+		if !now.Before(expiresAt) {
+			delete(s.tokens, jti)
+		}
+	}
+	for userID, revocation := range s.users {
+		if !now.Before(revocation.expiresAt) {
+			delete(s.users, userID)
+		}
+	}
+	return nil
+}
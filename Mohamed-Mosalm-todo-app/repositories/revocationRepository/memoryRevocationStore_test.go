@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRevocationStore(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	now := time.Now()
+	userID := uuid.New()
+
+	assert.NoError(t, store.RevokeToken("revoked", now.Add(time.Minute)))
+	assert.NoError(t, store.RevokeUserTokens(userID, now, now.Add(time.Minute)))
+
+	revoked, _ := store.IsRevoked("revoked", uuid.New(), now)
+	assert.True(t, revoked)
+	revoked, _ = store.IsRevoked("other", userID, now.Add(-time.Second))
+	assert.True(t, revoked, "tokens issued before the cutoff are revoked")
+	revoked, _ = store.IsRevoked("other", userID, now.Add(time.Second))
+	assert.False(t, revoked, "tokens issued after the cutoff stay valid")
+
+	assert.NoError(t, store.DeleteExpired(now.Add(2*time.Minute)))
+	revoked, _ = store.IsRevoked("revoked", uuid.New(), now)
+	assert.False(t, revoked)
+	revoked, _ = store.IsRevoked("other", userID, now.Add(-time.Second))
+	assert.False(t, revoked)
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevocationStore is the denylist AuthMiddleware consults for access tokens
+// that were logged out before their expiry. Entries only need to outlive the
+// tokens they cover, after which DeleteExpired drops them.
+type RevocationStore interface {
+	RevokeToken(jti string, expiresAt time.Time) error
+	RevokeUserTokens(userID uuid.UUID, issuedBefore, expiresAt time.Time) error
+	IsRevoked(jti string, userID uuid.UUID, issuedAt time.Time) (bool, error)
+	DeleteExpired(now time.Time) error
+}
+
+// StartCleanup calls DeleteExpired on store every interval until the
+// returned stop function is called.
+func StartCleanup(store RevocationStore, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				if err := store.DeleteExpired(now); err != nil {
+					log.Printf("revocation cleanup failed: %v", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"gorm.io/gorm"
+)
+
+type gormRoleRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRoleRepository(db *gorm.DB) RoleRepository {
+	return &gormRoleRepository{db: db}
+}
+
+func (r *gormRoleRepository) CreateRole(role *models.Role) error {
+	return r.db.Create(role).Error
+}
+
+func (r *gormRoleRepository) GetRoles() ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *gormRoleRepository) FindRoleByName(name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *gormRoleRepository) UpdateRole(role *models.Role) error {
+	return r.db.Model(role).Select("description", "permissions", "updated_at").Updates(role).Error
+}
+
+func (r *gormRoleRepository) DeleteRole(name string) error {
+	return r.db.Where("name = ?", name).Delete(&models.Role{}).Error
+}
+
+func (r *gormRoleRepository) CountUsersWithRole(name string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import "github.com/MohamedMosalm/Todo-App/models"
+
+// RoleRepository stores custom roles.
+type RoleRepository interface {
+	CreateRole(role *models.Role) error
+	// GetRoles returns the custom roles by name.
+	GetRoles() ([]models.Role, error)
+	FindRoleByName(name string) (*models.Role, error)
+	UpdateRole(role *models.Role) error
+	DeleteRole(name string) error
+	// CountUsersWithRole counts the users, active or not, who have role.
+	CountUsersWithRole(name string) (int64, error)
+}
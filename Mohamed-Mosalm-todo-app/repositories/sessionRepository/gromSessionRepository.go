@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormSessionRepository(db *gorm.DB) SessionRepository {
+	return &gormSessionRepository{db: db}
+}
+
+func (r *gormSessionRepository) CreateSession(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *gormSessionRepository) GetSessionByID(sessionID uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *gormSessionRepository) GetActiveSessionsByUserID(userID uuid.UUID, now time.Time) ([]models.Session, error) {
+	liveToken := r.db.Model(&models.RefreshToken{}).Select("1").
+		Where("refresh_tokens.family_id = sessions.id AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND EXISTS (?)", userID, liveToken).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *gormSessionRepository) TouchSession(sessionID uuid.UUID, now time.Time) error {
+	return r.db.Model(&models.Session{}).Where("id = ?", sessionID).Update("last_seen_at", now).Error
+}
+
+func (r *gormSessionRepository) RevokeSession(sessionID uuid.UUID, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error
+	})
+}
+
+func (r *gormSessionRepository) RevokeUserSessions(userID uuid.UUID, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type SessionRepository interface {
+	CreateSession(session *models.Session) error
+	GetSessionByID(sessionID uuid.UUID) (*models.Session, error)
+	// GetActiveSessionsByUserID returns unrevoked sessions that still hold
+	// a usable refresh token, most recently seen first.
+	GetActiveSessionsByUserID(userID uuid.UUID, now time.Time) ([]models.Session, error)
+	TouchSession(sessionID uuid.UUID, now time.Time) error
+	// RevokeSession and RevokeUserSessions also revoke the refresh tokens
+	// of the sessions they end.
+	RevokeSession(sessionID uuid.UUID, now time.Time) error
+	RevokeUserSessions(userID uuid.UUID, now time.Time) error
+}
@@ -1,11 +1,45 @@
 package repositories
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/MohamedMosalm/Todo-App/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// SortableColumns lists the task columns FindTasks accepts in a TaskSort.
+var SortableColumns = map[string]bool{
+	"title":      true,
+	"status":     true,
+	"priority":   true,
+	"due_date":   true,
+	"position":   true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// FilterableColumns lists the task columns a Compare condition may test.
+var FilterableColumns = map[string]bool{
+	"status":     true,
+	"state":      true,
+	"priority":   true,
+	"project_id": true,
+	"due_date":   true,
+	"tags":       true,
+}
+
+var comparisonOperators = map[Operator]string{
+	OpEquals:       "=",
+	OpGreater:      ">",
+	OpGreaterEqual: ">=",
+	OpLess:         "<",
+	OpLessEqual:    "<=",
+}
+
 type gormTaskRepository struct {
 	db *gorm.DB
 }
@@ -21,6 +55,30 @@ func (r *gormTaskRepository) CreateTask(task *models.Task) error {
 	return nil
 }
 
+// CreateTaskTree inserts a task and all of its nested subtasks in a single
+// transaction, linking every subtask to the ID generated for its parent.
+func (r *gormTaskRepository) CreateTaskTree(root *models.Task) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return createTaskTree(tx, root)
+	})
+}
+
+func createTaskTree(tx *gorm.DB, task *models.Task) error {
+	subtasks := task.Subtasks
+	task.Subtasks = nil
+	if err := tx.Create(task).Error; err != nil {
+		return err
+	}
+	for i := range subtasks {
+		subtasks[i].ParentID = &task.ID
+		if err := createTaskTree(tx, &subtasks[i]); err != nil {
+			return err
+		}
+	}
+	task.Subtasks = subtasks
+	return nil
+}
+
 func (r *gormTaskRepository) GetTasksByUserID(userID uuid.UUID) ([]models.Task, error) {
 	var tasks []models.Task
 	if err := r.db.Where("user_id = ?", userID).Find(&tasks).Error; err != nil {
@@ -29,15 +87,85 @@ func (r *gormTaskRepository) GetTasksByUserID(userID uuid.UUID) ([]models.Task,
 	return tasks, nil
 }
 
+func (r *gormTaskRepository) CountTasks(userID uuid.UUID, now time.Time) (TaskCounts, error) {
+	var counts TaskCounts
+	err := r.db.Model(&models.Task{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE status) AS completed, COUNT(*) FILTER (WHERE NOT status AND due_date < ?) AS overdue", now).
+		Where("user_id = ?", userID).
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *gormTaskRepository) FindTasks(criteria TaskCriteria) ([]models.Task, error) {
+	query := r.db.Where("user_id = ?", criteria.UserID)
+	if criteria.ProjectID != nil {
+		query = query.Where("project_id = ?", *criteria.ProjectID)
+	}
+
+	if criteria.Condition != nil {
+		condition, err := conditionExpr(criteria.Condition)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition)
+	}
+
+	for _, criterion := range criteria.CustomFields {
+		condition, err := customFieldCondition(criterion)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition)
+	}
+
+	if len(criteria.Sort) > 0 {
+		order, err := orderByClause(criteria.Sort)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Order(order)
+	}
+
+	var tasks []models.Task
+	if err := query.Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
 func (r *gormTaskRepository) UpdateTask(taskID uuid.UUID, updates map[string]interface{}) error {
 	return r.db.Model(&models.Task{}).Where("id = ?", taskID).Updates(updates).Error
 }
 
-func (r *gormTaskRepository) DeleteTask(taskID, userID uuid.UUID) error {
-	if err := r.db.Where("id = ? AND user_id = ?", taskID, userID).Delete(&models.Task{}).Error; err != nil {
-		return err
+func (r *gormTaskRepository) DeleteTask(taskID, userID uuid.UUID) ([]string, error) {
+	var storageKeys []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the task tree keeps attachments from being added to it
+		// between reading their keys and deleting the rows.
+		var taskIDs []uuid.UUID
+		err := tx.Raw(`WITH RECURSIVE tree AS (
+				SELECT id FROM tasks WHERE id = ? AND user_id = ?
+				UNION ALL
+				SELECT tasks.id FROM tasks JOIN tree ON tasks.parent_id = tree.id
+			)
+			SELECT tasks.id FROM tasks JOIN tree ON tasks.id = tree.id FOR UPDATE OF tasks`, taskID, userID).
+			Scan(&taskIDs).Error
+		if err != nil || len(taskIDs) == 0 {
+			return err
+		}
+
+		err = tx.Model(&models.Attachment{}).
+			Where("task_id IN ?", taskIDs).
+			Pluck("storage_key", &storageKeys).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND user_id = ?", taskID, userID).Delete(&models.Task{}).Error
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return storageKeys, nil
 }
 
 func (r *gormTaskRepository) GetTaskByID(taskID uuid.UUID) (*models.Task, error) {
@@ -48,3 +176,128 @@ func (r *gormTaskRepository) GetTaskByID(taskID uuid.UUID) (*models.Task, error)
 	}
 	return &task, nil
 }
+
+// customFieldExpr returns the SQL expression reading a custom field as its
+// declared type. The key is always bound as a parameter.
+func customFieldExpr(fieldType models.CustomFieldType) string {
+	switch fieldType {
+	case models.CustomFieldNumber:
+		return "(custom_fields->>?)::numeric"
+	case models.CustomFieldDate:
+		return "(custom_fields->>?)::date"
+	default:
+		return "custom_fields->>?"
+	}
+}
+
+func customFieldCondition(criterion CustomFieldCriterion) (clause.Expr, error) {
+	if criterion.Type == models.CustomFieldMultiSelect {
+		if criterion.Operator != OpContains {
+			return clause.Expr{}, fmt.Errorf("operator %q is not supported for multi_select fields", criterion.Operator)
+		}
+		return clause.Expr{
+			SQL:  "custom_fields->? @> to_jsonb(?::text)",
+			Vars: []interface{}{criterion.Key, criterion.Value},
+		}, nil
+	}
+
+	op, ok := comparisonOperators[criterion.Operator]
+	if !ok {
+		return clause.Expr{}, fmt.Errorf("operator %q is not supported for %s fields", criterion.Operator, criterion.Type)
+	}
+
+	return clause.Expr{
+		SQL:  customFieldExpr(criterion.Type) + " " + op + " ?",
+		Vars: []interface{}{criterion.Key, criterion.Value},
+	}, nil
+}
+
+// conditionExpr renders a condition tree as a parenthesized SQL expression
+// with every value bound as a parameter.
+func conditionExpr(condition Condition) (clause.Expr, error) {
+	switch c := condition.(type) {
+	case And:
+		return joinConditions([]Condition(c), " AND ")
+	case Or:
+		return joinConditions([]Condition(c), " OR ")
+	case Not:
+		inner, err := conditionExpr(c.Condition)
+		if err != nil {
+			return clause.Expr{}, err
+		}
+		return clause.Expr{SQL: "NOT ?", Vars: []interface{}{inner}}, nil
+	case Compare:
+		return compareExpr(c)
+	}
+	return clause.Expr{}, fmt.Errorf("unsupported condition %T", condition)
+}
+
+func joinConditions(conditions []Condition, separator string) (clause.Expr, error) {
+	if len(conditions) == 0 {
+		return clause.Expr{}, fmt.Errorf("empty condition group")
+	}
+	terms := make([]string, len(conditions))
+	vars := make([]interface{}, len(conditions))
+	for i, condition := range conditions {
+		expr, err := conditionExpr(condition)
+		if err != nil {
+			return clause.Expr{}, err
+		}
+		terms[i] = "?"
+		vars[i] = expr
+	}
+	return clause.Expr{SQL: "(" + strings.Join(terms, separator) + ")", Vars: vars}, nil
+}
+
+func compareExpr(compare Compare) (clause.Expr, error) {
+	if !FilterableColumns[compare.Field] {
+		return clause.Expr{}, fmt.Errorf("cannot filter tasks by %q", compare.Field)
+	}
+
+	switch compare.Operator {
+	case OpIsNull:
+		return clause.Expr{SQL: "(" + compare.Field + " IS NULL)"}, nil
+	case OpContains:
+		if compare.Field != "tags" {
+			return clause.Expr{}, fmt.Errorf("operator %q is not supported for %q", compare.Operator, compare.Field)
+		}
+		return clause.Expr{SQL: "(tags @> to_jsonb(?::text))", Vars: []interface{}{compare.Value}}, nil
+	}
+
+	op, ok := comparisonOperators[compare.Operator]
+	if !ok || compare.Field == "tags" {
+		return clause.Expr{}, fmt.Errorf("operator %q is not supported for %q", compare.Operator, compare.Field)
+	}
+	return clause.Expr{SQL: "(" + compare.Field + " " + op + " ?)", Vars: []interface{}{compare.Value}}, nil
+}
+
+// orderByClause builds a single ORDER BY expression, since gorm keeps only
+// the last expression-based ordering when several are added.
+func orderByClause(sorts []TaskSort) (clause.OrderBy, error) {
+	terms := make([]string, 0, len(sorts))
+	var vars []interface{}
+
+	for _, sort := range sorts {
+		direction := " ASC NULLS LAST"
+		if sort.Desc {
+			direction = " DESC NULLS LAST"
+		}
+
+		if sort.CustomField != "" {
+			terms = append(terms, customFieldExpr(sort.Type)+direction)
+			vars = append(vars, sort.CustomField)
+			continue
+		}
+
+		if !SortableColumns[sort.Column] {
+			return clause.OrderBy{}, fmt.Errorf("cannot sort tasks by %q", sort.Column)
+		}
+		terms = append(terms, sort.Column+direction)
+	}
+
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                strings.Join(terms, ", "),
+		Vars:               vars,
+		WithoutParentheses: true,
+	}}, nil
+}
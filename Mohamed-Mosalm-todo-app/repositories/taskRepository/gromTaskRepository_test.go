@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/MohamedMosalm/Todo-App/database/databasetest"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestGormTaskRepositoryDeleteTaskReturnsStorageKeys(t *testing.T) {
+	db := databasetest.Open(t)
+	repo := NewGormTaskRepository(db)
+	user := databasetest.CreateUser(t, db)
+
+	root := &models.Task{Title: "Release", UserID: user.ID, Subtasks: []models.Task{
+		{Title: "Changelog", UserID: user.ID, Subtasks: []models.Task{{Title: "Screenshots", UserID: user.ID}}},
+	}}
+	require.NoError(t, repo.CreateTaskTree(root))
+	other := &models.Task{Title: "Unrelated", UserID: user.ID}
+	require.NoError(t, repo.CreateTask(other))
+
+	attach := func(taskID uuid.UUID, key string) {
+		attachment := &models.Attachment{TaskID: taskID, UserID: user.ID, FileName: key, ContentType: "text/plain", StorageKey: key}
+		require.NoError(t, db.Create(attachment).Error)
+	}
+	attach(root.ID, "root")
+	attach(root.Subtasks[0].Subtasks[0].ID, "grandchild")
+	attach(other.ID, "other")
+
+	// Someone else's task is left alone.
+	keys, err := repo.DeleteTask(root.ID, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	keys, err = repo.DeleteTask(root.ID, user.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"root", "grandchild"}, keys)
+
+	_, err = repo.GetTaskByID(root.Subtasks[0].Subtasks[0].ID)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+	_, err = repo.GetTaskByID(other.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type Operator string
+
+const (
+	OpEquals       Operator = "eq"
+	OpGreater      Operator = "gt"
+	OpGreaterEqual Operator = "gte"
+	OpLess         Operator = "lt"
+	OpLessEqual    Operator = "lte"
+	OpContains     Operator = "contains"
+	OpIsNull       Operator = "null"
+)
+
+// TaskCriteria describes a filtered, sorted task listing for one user.
+type TaskCriteria struct {
+	UserID       uuid.UUID
+	ProjectID    *uuid.UUID
+	CustomFields []CustomFieldCriterion
+	Condition    Condition
+	Sort         []TaskSort
+}
+
+// CustomFieldCriterion compares the custom field Key, interpreted as Type,
+// against Value. OpContains only applies to multi_select fields.
+type CustomFieldCriterion struct {
+	Key      string
+	Type     models.CustomFieldType
+	Operator Operator
+	Value    interface{}
+}
+
+// Condition is a boolean expression over task columns, built from And, Or,
+// Not and Compare.
+type Condition interface {
+	isCondition()
+}
+
+type And []Condition
+
+type Or []Condition
+
+type Not struct {
+	Condition Condition
+}
+
+// Compare tests one task column. Field is one of the FilterableColumns;
+// OpContains applies to "tags" and OpIsNull ignores Value.
+type Compare struct {
+	Field    string
+	Operator Operator
+	Value    interface{}
+}
+
+func (And) isCondition()     {}
+func (Or) isCondition()      {}
+func (Not) isCondition()     {}
+func (Compare) isCondition() {}
+
+// TaskSort orders by a task column, or by a custom field when CustomField is
+// set.
+type TaskSort struct {
+	Column      string
+	CustomField string
+	Type        models.CustomFieldType
+	Desc        bool
+}
@@ -1,14 +1,30 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/MohamedMosalm/Todo-App/models"
 	"github.com/google/uuid"
 )
 
 type TaskRepository interface {
 	CreateTask(task *models.Task) error
+	CreateTaskTree(root *models.Task) error
 	GetTasksByUserID(userID uuid.UUID) ([]models.Task, error)
+	FindTasks(criteria TaskCriteria) ([]models.Task, error)
 	UpdateTask(taskID uuid.UUID, updates map[string]interface{}) error
-	DeleteTask(taskID, userID uuid.UUID) error
+	// DeleteTask deletes the task along with its subtasks and their
+	// attachments, and returns the storage keys of those attachments, whose
+	// blobs are left to the caller.
+	DeleteTask(taskID, userID uuid.UUID) ([]string, error)
 	GetTaskByID(taskID uuid.UUID) (*models.Task, error)
+	// CountTasks counts the user's tasks, subtasks included. Overdue tasks
+	// are open tasks due before now.
+	CountTasks(userID uuid.UUID, now time.Time) (TaskCounts, error)
+}
+
+type TaskCounts struct {
+	Total     int64 `json:"total"`
+	Completed int64 `json:"completed"`
+	Overdue   int64 `json:"overdue"`
 }
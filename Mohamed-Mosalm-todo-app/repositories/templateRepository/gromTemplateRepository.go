@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTemplateRepository(db *gorm.DB) TemplateRepository {
+	return &gormTemplateRepository{db: db}
+}
+
+func (r *gormTemplateRepository) CreateTemplate(template *models.TaskTemplate) error {
+	return r.db.Create(template).Error
+}
+
+func (r *gormTemplateRepository) GetTemplatesByUserID(userID uuid.UUID) ([]models.TaskTemplate, error) {
+	var templates []models.TaskTemplate
+	if err := r.db.Where("user_id = ?", userID).Order("name").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *gormTemplateRepository) GetTemplateByID(templateID uuid.UUID) (*models.TaskTemplate, error) {
+	var template models.TaskTemplate
+	if err := r.db.Where("id = ?", templateID).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *gormTemplateRepository) UpdateTemplate(template *models.TaskTemplate) error {
+	return r.db.Model(template).Select("name", "title_pattern", "description", "tags", "due_offset_days", "subtasks").Updates(template).Error
+}
+
+func (r *gormTemplateRepository) DeleteTemplate(templateID, userID uuid.UUID) error {
+	return r.db.Where("id = ? AND user_id = ?", templateID, userID).Delete(&models.TaskTemplate{}).Error
+}
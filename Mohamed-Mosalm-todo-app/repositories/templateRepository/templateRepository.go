@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+)
+
+type TemplateRepository interface {
+	CreateTemplate(template *models.TaskTemplate) error
+	GetTemplatesByUserID(userID uuid.UUID) ([]models.TaskTemplate, error)
+	GetTemplateByID(templateID uuid.UUID) (*models.TaskTemplate, error)
+	UpdateTemplate(template *models.TaskTemplate) error
+	DeleteTemplate(templateID, userID uuid.UUID) error
+}
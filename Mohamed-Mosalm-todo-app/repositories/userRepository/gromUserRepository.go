@@ -1,11 +1,17 @@
 package repositories
 
 import (
+	"strings"
+	"time"
+
 	"github.com/MohamedMosalm/Todo-App/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// likeEscaper makes user input match literally in a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type gormUserRepository struct {
 	db *gorm.DB
 }
@@ -39,3 +45,56 @@ func (r *gormUserRepository) FindUserByID(id uuid.UUID) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+func (r *gormUserRepository) UpdatePassword(id uuid.UUID, hashedPassword string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("password", hashedPassword).Error
+}
+
+func (r *gormUserRepository) MarkEmailVerified(id uuid.UUID, verifiedAt time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", id).
+		Update("email_verified_at", verifiedAt).Error
+}
+
+func (r *gormUserRepository) UpdateUser(id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// FindUsers also returns how many users match in total.
+func (r *gormUserRepository) FindUsers(criteria UserCriteria) ([]models.User, int64, error) {
+	query := r.db.Model(&models.User{})
+	if criteria.Email != "" {
+		query = query.Where("LOWER(email) = LOWER(?)", criteria.Email)
+	}
+	if criteria.ExternalID != "" {
+		query = query.Where("external_id = ?", criteria.ExternalID)
+	}
+	if criteria.Search != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(criteria.Search)) + "%"
+		query = query.Where("LOWER(email) LIKE ? OR LOWER(first_name) LIKE ? OR LOWER(last_name) LIKE ?", pattern, pattern, pattern)
+	}
+	if criteria.Role != "" {
+		query = query.Where("role = ?", criteria.Role)
+	}
+	if criteria.ExcludeDeprovisioned {
+		query = query.Where("deprovisioned_at IS NULL")
+	}
+	if criteria.Active != nil {
+		if *criteria.Active {
+			query = query.Where("deactivated_at IS NULL")
+		} else {
+			query = query.Where("deactivated_at IS NOT NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := query.Order("created_at, id").Offset(criteria.Offset).Limit(criteria.Limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/MohamedMosalm/Todo-App/models"
 	"github.com/google/uuid"
 )
@@ -9,4 +11,23 @@ type UserRepository interface {
 	CreateUser(user *models.User) error
 	FindUserByEmail(email string) (*models.User, error)
 	FindUserByID(id uuid.UUID) (*models.User, error)
+	UpdatePassword(id uuid.UUID, hashedPassword string) error
+	MarkEmailVerified(id uuid.UUID, verifiedAt time.Time) error
+	UpdateUser(id uuid.UUID, updates map[string]interface{}) error
+	FindUsers(criteria UserCriteria) ([]models.User, int64, error)
+}
+
+// UserCriteria narrows a user listing; zero fields match every user. Offset
+// and Limit page through the matches, oldest account first.
+type UserCriteria struct {
+	Email      string
+	ExternalID string
+	Active     *bool
+	// Search matches part of the email or name, ignoring case.
+	Search string
+	Role   string
+	Offset int
+	Limit  int
+	// ExcludeDeprovisioned leaves out users the provisioning client deleted.
+	ExcludeDeprovisioned bool
 }
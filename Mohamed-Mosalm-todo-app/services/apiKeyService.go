@@ -0,0 +1,141 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	apiKeyRepository "github.com/MohamedMosalm/Todo-App/repositories/apiKeyRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix starts every API key, which is how AuthMiddleware tells keys
+// from JWTs.
+const APIKeyPrefix = "todo_"
+
+// apiKeyDisplayLength is how much of a key is kept readable for listings.
+const apiKeyDisplayLength = len(APIKeyPrefix) + 6
+
+var (
+	ErrInvalidAPIKey      = errors.New("API key is invalid, expired or revoked")
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrInvalidAPIKeyScope = errors.New("unknown API key scope")
+	ErrAPIKeyExpiryPassed = errors.New("API key expiry must be in the future")
+)
+
+type APIKeyService interface {
+	// CreateKey returns the new key's record and the key itself, which is
+	// not stored and cannot be shown again.
+	CreateKey(userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error)
+	GetKeysByUserID(userID uuid.UUID) ([]models.APIKey, error)
+	RevokeKey(keyID, userID uuid.UUID) error
+	// Authenticate returns the live key matching key and records its use.
+	Authenticate(key string) (*models.APIKey, error)
+}
+
+type apiKeyService struct {
+	keyRepo       apiKeyRepository.APIKeyRepository
+	touchInterval time.Duration
+}
+
+// NewAPIKeyService writes a key's last-used time at most once per
+// touchInterval.
+func NewAPIKeyService(keyRepo apiKeyRepository.APIKeyRepository, touchInterval time.Duration) APIKeyService {
+	return &apiKeyService{keyRepo: keyRepo, touchInterval: touchInterval}
+}
+
+func (s *apiKeyService) CreateKey(userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	for _, scope := range scopes {
+		if !isAPIKeyScope(scope) {
+			return nil, "", ErrInvalidAPIKeyScope
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrAPIKeyExpiryPassed
+	}
+
+	token, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	key := APIKeyPrefix + token
+
+	record := &models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   auth.HashOpaqueToken(key),
+		Scopes:    dedupeScopes(scopes),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.keyRepo.CreateKey(record); err != nil {
+		return nil, "", err
+	}
+	return record, key, nil
+}
+
+func (s *apiKeyService) GetKeysByUserID(userID uuid.UUID) ([]models.APIKey, error) {
+	return s.keyRepo.GetKeysByUserID(userID)
+}
+
+func (s *apiKeyService) RevokeKey(keyID, userID uuid.UUID) error {
+	revoked, err := s.keyRepo.RevokeKey(keyID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	record, err := s.keyRepo.FindKeyByHash(auth.HashOpaqueToken(key))
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if record.RevokedAt != nil || (record.ExpiresAt != nil && !now.Before(*record.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= s.touchInterval {
+		if err := s.keyRepo.TouchKey(record.ID, now); err != nil {
+			return nil, err
+		}
+		record.LastUsedAt = &now
+	}
+	return record, nil
+}
+
+func isAPIKeyScope(scope string) bool {
+	for _, known := range models.APIKeyScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+func dedupeScopes(scopes []string) models.StringList {
+	seen := make(map[string]bool, len(scopes))
+	deduped := models.StringList{}
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			deduped = append(deduped, scope)
+		}
+	}
+	return deduped
+}
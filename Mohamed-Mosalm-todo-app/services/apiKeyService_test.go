@@ -0,0 +1,115 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) CreateKey(key *models.APIKey) error {
+	return m.Called(key).Error(0)
+}
+
+func (m *MockAPIKeyRepository) FindKeyByHash(keyHash string) (*models.APIKey, error) {
+	args := m.Called(keyHash)
+	key := args.Get(0)
+	if key == nil {
+		return nil, args.Error(1)
+	}
+	return key.(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetKeysByUserID(userID uuid.UUID) ([]models.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) RevokeKey(keyID, userID uuid.UUID, now time.Time) (bool, error) {
+	args := m.Called(keyID, userID, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchKey(keyID uuid.UUID, now time.Time) error {
+	return m.Called(keyID, now).Error(0)
+}
+
+func TestCreateAPIKeyStoresOnlyTheHash(t *testing.T) {
+	repo := new(MockAPIKeyRepository)
+	service := NewAPIKeyService(repo, time.Minute)
+	repo.On("CreateKey", mock.AnythingOfType("*models.APIKey")).Return(nil)
+
+	userID := uuid.New()
+	record, key, err := service.CreateKey(userID, "backup script", []string{models.ScopeTasksRead, models.ScopeTasksRead}, nil)
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key, APIKeyPrefix))
+	assert.Equal(t, auth.HashOpaqueToken(key), record.KeyHash)
+	assert.NotContains(t, record.KeyHash, key)
+	assert.True(t, strings.HasPrefix(key, record.Prefix))
+	assert.Equal(t, models.StringList{models.ScopeTasksRead}, record.Scopes)
+	assert.Equal(t, userID, record.UserID)
+}
+
+func TestCreateAPIKeyValidatesInput(t *testing.T) {
+	repo := new(MockAPIKeyRepository)
+	service := NewAPIKeyService(repo, time.Minute)
+
+	_, _, err := service.CreateKey(uuid.New(), "key", []string{"admin:all"}, nil)
+	assert.Equal(t, ErrInvalidAPIKeyScope, err)
+
+	past := time.Now().Add(-time.Hour)
+	_, _, err = service.CreateKey(uuid.New(), "key", []string{models.ScopeTasksRead}, &past)
+	assert.Equal(t, ErrAPIKeyExpiryPassed, err)
+	repo.AssertNotCalled(t, "CreateKey", mock.Anything)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	repo := new(MockAPIKeyRepository)
+	service := NewAPIKeyService(repo, time.Minute)
+
+	past, future, recent := time.Now().Add(-time.Hour), time.Now().Add(time.Hour), time.Now().Add(-10*time.Second)
+	live := &models.APIKey{ID: uuid.New(), LastUsedAt: &past}
+	fresh := &models.APIKey{ID: uuid.New(), ExpiresAt: &future, LastUsedAt: &recent}
+	expired := &models.APIKey{ID: uuid.New(), ExpiresAt: &past}
+	revoked := &models.APIKey{ID: uuid.New(), RevokedAt: &past}
+	repo.On("FindKeyByHash", auth.HashOpaqueToken("todo_live")).Return(live, nil)
+	repo.On("FindKeyByHash", auth.HashOpaqueToken("todo_fresh")).Return(fresh, nil)
+	repo.On("FindKeyByHash", auth.HashOpaqueToken("todo_expired")).Return(expired, nil)
+	repo.On("FindKeyByHash", auth.HashOpaqueToken("todo_revoked")).Return(revoked, nil)
+	repo.On("FindKeyByHash", auth.HashOpaqueToken("todo_unknown")).Return(nil, gorm.ErrRecordNotFound)
+	repo.On("TouchKey", live.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	got, err := service.Authenticate("todo_live")
+	assert.NoError(t, err)
+	assert.Equal(t, live.ID, got.ID)
+	_, err = service.Authenticate("todo_fresh")
+	assert.NoError(t, err)
+
+	for _, key := range []string{"todo_expired", "todo_revoked", "todo_unknown", "not-a-key"} {
+		_, err := service.Authenticate(key)
+		assert.Equal(t, ErrInvalidAPIKey, err, key)
+	}
+	repo.AssertNotCalled(t, "TouchKey", fresh.ID, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestRevokeAPIKeyOfAnotherUser(t *testing.T) {
+	repo := new(MockAPIKeyRepository)
+	service := NewAPIKeyService(repo, time.Minute)
+
+	keyID, userID := uuid.New(), uuid.New()
+	repo.On("RevokeKey", keyID, userID, mock.AnythingOfType("time.Time")).Return(false, nil)
+
+	assert.Equal(t, ErrAPIKeyNotFound, service.RevokeKey(keyID, userID))
+}
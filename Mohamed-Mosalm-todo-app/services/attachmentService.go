@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	attachmentRepository "github.com/MohamedMosalm/Todo-App/repositories/attachmentRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/storage"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+)
+
+// sniffLen is how much of an upload is inspected to detect its MIME type.
+const sniffLen = 3072
+
+var (
+	ErrAttachmentTooLarge = errors.New("attachment exceeds the maximum size")
+	ErrMIMETypeNotAllowed = errors.New("attachment type is not allowed")
+	ErrQuotaExceeded      = errors.New("attachment storage quota exceeded")
+)
+
+// AttachmentLimits bounds uploads. AllowedTypes entries are MIME types or
+// wildcards such as "image/*"; zero sizes disable the respective limit.
+type AttachmentLimits struct {
+	MaxSize      int64
+	AllowedTypes []string
+	UserQuota    int64
+}
+
+type AttachmentService interface {
+	Upload(ctx context.Context, task *models.Task, fileName string, content io.Reader) (*models.Attachment, error)
+	GetAttachmentsByTaskID(taskID uuid.UUID) ([]models.Attachment, error)
+	GetAttachmentByID(attachmentID uuid.UUID) (*models.Attachment, error)
+	Open(ctx context.Context, attachment *models.Attachment) (io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, attachment *models.Attachment) error
+	// DeleteBlobs removes the stored content of attachments whose records
+	// are already gone, trying every key even after a failure.
+	DeleteBlobs(ctx context.Context, storageKeys []string) error
+}
+
+type attachmentService struct {
+	attachmentRepo attachmentRepository.AttachmentRepository
+	store          storage.BlobStore
+	limits         AttachmentLimits
+}
+
+func NewAttachmentService(attachmentRepo attachmentRepository.AttachmentRepository, store storage.BlobStore, limits AttachmentLimits) AttachmentService {
+	return &attachmentService{attachmentRepo: attachmentRepo, store: store, limits: limits}
+}
+
+// Upload streams content into the blob store. The MIME type is detected from
+// the content itself rather than trusted from the client, and the upload is
+// cut off as soon as it passes the size limit or the owner's remaining quota.
+// Uploads running side by side may each fit the quota on their own, so it is
+// checked again, atomically, when the attachment is recorded.
+func (s *attachmentService) Upload(ctx context.Context, task *models.Task, fileName string, content io.Reader) (*models.Attachment, error) {
+	limit, limitErr := s.limits.MaxSize, ErrAttachmentTooLarge
+	if s.limits.UserQuota > 0 {
+		used, err := s.attachmentRepo.TotalSizeByUserID(task.UserID)
+		if err != nil {
+			return nil, err
+		}
+		remaining := s.limits.UserQuota - used
+		if remaining <= 0 {
+			return nil, ErrQuotaExceeded
+		}
+		if limit <= 0 || remaining < limit {
+			limit, limitErr = remaining, ErrQuotaExceeded
+		}
+	}
+
+	buffered := bufio.NewReaderSize(content, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	contentType := mimetype.Detect(head).String()
+	if !s.typeAllowed(contentType) {
+		return nil, ErrMIMETypeNotAllowed
+	}
+
+	body := &limitedReader{r: buffered, limit: limit}
+	key := path.Join(task.UserID.String(), uuid.NewString())
+	if err := s.store.Put(ctx, key, body, -1, contentType); err != nil {
+		if body.exceeded {
+			s.store.Delete(ctx, key)
+			return nil, limitErr
+		}
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		TaskID:      task.ID,
+		UserID:      task.UserID,
+		FileName:    cleanFileName(fileName),
+		ContentType: contentType,
+		Size:        body.read,
+		StorageKey:  key,
+	}
+	created, err := true, error(nil)
+	if s.limits.UserQuota > 0 {
+		created, err = s.attachmentRepo.CreateAttachmentWithinQuota(attachment, s.limits.UserQuota)
+	} else {
+		err = s.attachmentRepo.CreateAttachment(attachment)
+	}
+	if err != nil || !created {
+		s.store.Delete(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrQuotaExceeded
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) GetAttachmentsByTaskID(taskID uuid.UUID) ([]models.Attachment, error) {
+	return s.attachmentRepo.GetAttachmentsByTaskID(taskID)
+}
+
+func (s *attachmentService) GetAttachmentByID(attachmentID uuid.UUID) (*models.Attachment, error) {
+	return s.attachmentRepo.GetAttachmentByID(attachmentID)
+}
+
+func (s *attachmentService) Open(ctx context.Context, attachment *models.Attachment) (io.ReadCloser, error) {
+	return s.store.Get(ctx, attachment.StorageKey)
+}
+
+// DeleteAttachment removes the record before the blob, so a failure in
+// between leaves an unreferenced blob rather than a broken attachment.
+func (s *attachmentService) DeleteAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.attachmentRepo.DeleteAttachment(attachment.ID); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, attachment.StorageKey)
+}
+
+func (s *attachmentService) DeleteBlobs(ctx context.Context, storageKeys []string) error {
+	var errs []error
+	for _, key := range storageKeys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *attachmentService) typeAllowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range s.limits.AllowedTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func cleanFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if len(name) > 255 {
+		// Cut at 255 bytes without splitting a multi-byte character.
+		end := 255
+		for end > 0 && !utf8.RuneStart(name[end]) {
+			end--
+		}
+		name = name[:end]
+	}
+	return name
+}
+
+// limitedReader fails once more than limit bytes are read from r, and
+// records how many bytes it passed through. A zero limit disables the check.
+type limitedReader struct {
+	r        io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.limit > 0 {
+		if left := l.limit - l.read + 1; int64(len(p)) > left {
+			p = p[:left]
+		}
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.limit > 0 && l.read > l.limit {
+		l.exceeded = true
+		return 0, ErrAttachmentTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAttachmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentRepository) CreateAttachment(attachment *models.Attachment) error {
+	return m.Called(attachment).Error(0)
+}
+
+func (m *MockAttachmentRepository) CreateAttachmentWithinQuota(attachment *models.Attachment, quota int64) (bool, error) {
+	args := m.Called(attachment, quota)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) GetAttachmentsByTaskID(taskID uuid.UUID) ([]models.Attachment, error) {
+	args := m.Called(taskID)
+	return args.Get(0).([]models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) GetAttachmentByID(attachmentID uuid.UUID) (*models.Attachment, error) {
+	args := m.Called(attachmentID)
+	return args.Get(0).(*models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) DeleteAttachment(attachmentID uuid.UUID) error {
+	return m.Called(attachmentID).Error(0)
+}
+
+func (m *MockAttachmentRepository) TotalSizeByUserID(userID uuid.UUID) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func newTestAttachmentService(t *testing.T, limits AttachmentLimits) (AttachmentService, *MockAttachmentRepository) {
+	store, err := storage.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	repo := new(MockAttachmentRepository)
+	return NewAttachmentService(repo, store, limits), repo
+}
+
+func TestUploadAttachment(t *testing.T) {
+	service, repo := newTestAttachmentService(t, AttachmentLimits{
+		MaxSize:      1024,
+		AllowedTypes: []string{"text/*"},
+		UserQuota:    4096,
+	})
+	task := &models.Task{ID: uuid.New(), UserID: uuid.New()}
+
+	repo.On("TotalSizeByUserID", task.UserID).Return(int64(0), nil)
+	repo.On("CreateAttachmentWithinQuota", mock.AnythingOfType("*models.Attachment"), int64(4096)).Return(true, nil)
+
+	attachment, err := service.Upload(context.Background(), task, "../notes.txt", strings.NewReader("hello world"))
+	assert.NoError(t, err)
+	assert.Equal(t, "notes.txt", attachment.FileName)
+	assert.Equal(t, "text/plain; charset=utf-8", attachment.ContentType)
+	assert.Equal(t, int64(11), attachment.Size)
+
+	content, err := service.Open(context.Background(), attachment)
+	assert.NoError(t, err)
+	defer content.Close()
+	data, _ := io.ReadAll(content)
+	assert.Equal(t, "hello world", string(data))
+}
+
+func TestUploadAttachmentLimits(t *testing.T) {
+	task := &models.Task{ID: uuid.New(), UserID: uuid.New()}
+	pdf := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("x"), 100)...)
+
+	t.Run("too large", func(t *testing.T) {
+		service, repo := newTestAttachmentService(t, AttachmentLimits{MaxSize: 50, AllowedTypes: []string{"application/pdf"}, UserQuota: 4096})
+		repo.On("TotalSizeByUserID", task.UserID).Return(int64(0), nil)
+
+		_, err := service.Upload(context.Background(), task, "a.pdf", bytes.NewReader(pdf))
+		assert.Equal(t, ErrAttachmentTooLarge, err)
+		repo.AssertNotCalled(t, "CreateAttachment", mock.Anything)
+	})
+
+	t.Run("type not allowed", func(t *testing.T) {
+		service, repo := newTestAttachmentService(t, AttachmentLimits{MaxSize: 1024, AllowedTypes: []string{"image/*"}})
+
+		_, err := service.Upload(context.Background(), task, "a.pdf", bytes.NewReader(pdf))
+		assert.Equal(t, ErrMIMETypeNotAllowed, err)
+		repo.AssertNotCalled(t, "CreateAttachment", mock.Anything)
+	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		service, repo := newTestAttachmentService(t, AttachmentLimits{MaxSize: 1024, AllowedTypes: []string{"application/pdf"}, UserQuota: 4096})
+		repo.On("TotalSizeByUserID", task.UserID).Return(int64(4000), nil)
+
+		_, err := service.Upload(context.Background(), task, "a.pdf", bytes.NewReader(pdf))
+		assert.Equal(t, ErrQuotaExceeded, err)
+		repo.AssertNotCalled(t, "CreateAttachment", mock.Anything)
+	})
+
+	t.Run("quota used up by a concurrent upload", func(t *testing.T) {
+		root := t.TempDir()
+		store, err := storage.NewLocalBlobStore(root)
+		assert.NoError(t, err)
+		repo := new(MockAttachmentRepository)
+		service := NewAttachmentService(repo, store, AttachmentLimits{MaxSize: 1024, AllowedTypes: []string{"application/pdf"}, UserQuota: 4096})
+		repo.On("TotalSizeByUserID", task.UserID).Return(int64(0), nil)
+		repo.On("CreateAttachmentWithinQuota", mock.AnythingOfType("*models.Attachment"), int64(4096)).Return(false, nil)
+
+		_, err = service.Upload(context.Background(), task, "a.pdf", bytes.NewReader(pdf))
+		assert.Equal(t, ErrQuotaExceeded, err)
+		blobs, _ := os.ReadDir(filepath.Join(root, task.UserID.String()))
+		assert.Empty(t, blobs, "the stored blob is deleted again")
+	})
+}
+
+func TestCleanFileName(t *testing.T) {
+	assert.Equal(t, "report.pdf", cleanFileName(`C:\Users\jane\report.pdf`))
+
+	// Byte 255 falls inside the 128th "é", which is dropped whole.
+	long := strings.Repeat("é", 128) + ".pdf"
+	cleaned := cleanFileName(long)
+	assert.True(t, utf8.ValidString(cleaned))
+	assert.Equal(t, strings.Repeat("é", 127), cleaned)
+}
+
+func TestDeleteTaskDeletesAttachmentBlobs(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalBlobStore(root)
+	assert.NoError(t, err)
+	taskRepo := new(MockTaskRepository)
+	service := NewTaskService(taskRepo, nil, NewAttachmentService(new(MockAttachmentRepository), store, AttachmentLimits{}))
+
+	task := &models.Task{ID: uuid.New(), UserID: uuid.New()}
+	keys := []string{task.UserID.String() + "/a", task.UserID.String() + "/b"}
+	for _, key := range keys {
+		assert.NoError(t, store.Put(context.Background(), key, strings.NewReader("content"), -1, "text/plain"))
+	}
+	taskRepo.On("DeleteTask", task.ID, task.UserID).Return(keys, nil).Once()
+
+	// The blobs go even when the request is over by the time they are deleted.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, service.DeleteTask(ctx, task.ID, task.UserID))
+	blobs, _ := os.ReadDir(filepath.Join(root, task.UserID.String()))
+	assert.Empty(t, blobs)
+	taskRepo.AssertExpectations(t)
+}
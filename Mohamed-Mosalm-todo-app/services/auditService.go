@@ -0,0 +1,50 @@
+package services
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	auditRepository "github.com/MohamedMosalm/Todo-App/repositories/auditRepository"
+	"github.com/google/uuid"
+)
+
+type AuditService interface {
+	Record(event *models.AuditEvent) error
+	// ListEvents returns a page of the events matching options, newest
+	// first, and how many match in all.
+	ListEvents(options AuditListOptions) ([]models.AuditEvent, int64, error)
+}
+
+// AuditListOptions narrows an audit log listing; zero fields match every
+// event.
+type AuditListOptions struct {
+	ActorID  *uuid.UUID
+	TargetID *uuid.UUID
+	Action   string
+	Since    *time.Time
+	Offset   int
+	Limit    int
+}
+
+type auditService struct {
+	auditRepo auditRepository.AuditRepository
+}
+
+func NewAuditService(auditRepo auditRepository.AuditRepository) AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+func (s *auditService) Record(event *models.AuditEvent) error {
+	return s.auditRepo.CreateEvent(event)
+}
+
+func (s *auditService) ListEvents(options AuditListOptions) ([]models.AuditEvent, int64, error) {
+	return s.auditRepo.FindEvents(auditRepository.AuditCriteria{
+		ActorID:  options.ActorID,
+		TargetID: options.TargetID,
+		Action:   options.Action,
+		Since:    options.Since,
+		Offset:   options.Offset,
+		Limit:    options.Limit,
+	})
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+)
+
+// ErrInvalidCredentials is returned for unknown users and wrong passwords
+// alike.
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// Authenticator checks the email and password a user logs in with.
+type Authenticator interface {
+	// Authenticate returns the user the credentials belong to, or
+	// ErrInvalidCredentials. Other errors mean the credentials could not be
+	// checked.
+	Authenticate(ctx context.Context, email, password string) (*models.User, error)
+}
+
+type passwordAuthenticator struct {
+	userService     UserService
+	passwordService *auth.PasswordService
+}
+
+// NewPasswordAuthenticator checks passwords against the hashes stored with
+// users, upgrading outdated hashes as it goes.
+func NewPasswordAuthenticator(userService UserService, passwordService *auth.PasswordService) Authenticator {
+	return &passwordAuthenticator{userService: userService, passwordService: passwordService}
+}
+
+func (a *passwordAuthenticator) Authenticate(_ context.Context, email, password string) (*models.User, error) {
+	user, err := a.userService.FindUserByEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := a.passwordService.ComparePasswords(user.Password, password); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if a.passwordService.NeedsRehash(user.Password) {
+		a.rehashPassword(user, password)
+	}
+	return user, nil
+}
+
+// rehashPassword upgrades the stored hash of a just-verified password to
+// the current algorithm and parameters. Failing only delays the upgrade to
+// the next login.
+func (a *passwordAuthenticator) rehashPassword(user *models.User, password string) {
+	hashedPassword, err := a.passwordService.HashPassword(password)
+	if err != nil {
+		log.Printf("rehashing password failed: %v", err)
+		return
+	}
+	if err := a.userService.UpdatePassword(user.ID, hashedPassword); err != nil {
+		log.Printf("saving rehashed password failed: %v", err)
+		return
+	}
+	user.Password = hashedPassword
+}
+
+type chainAuthenticator []Authenticator
+
+// NewChainAuthenticator asks each authenticator in turn and returns the
+// first user one of them accepts. An authenticator that fails does not keep
+// the others from being asked, but if none accepts, its error is returned
+// rather than ErrInvalidCredentials.
+func NewChainAuthenticator(authenticators ...Authenticator) Authenticator {
+	if len(authenticators) == 1 {
+		return authenticators[0]
+	}
+	return chainAuthenticator(authenticators)
+}
+
+func (c chainAuthenticator) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	var failure error
+	for _, authenticator := range c {
+		user, err := authenticator.Authenticate(ctx, email, password)
+		if err == nil {
+			return user, nil
+		}
+		if err != ErrInvalidCredentials && failure == nil {
+			failure = err
+		}
+	}
+	if failure != nil {
+		return nil, failure
+	}
+	return nil, ErrInvalidCredentials
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/directory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeDirectory knows one password per email address.
+type fakeDirectory struct {
+	entries   map[string]*directory.Entry
+	passwords map[string]string
+}
+
+func (d *fakeDirectory) Authenticate(_ context.Context, email, password string) (*directory.Entry, error) {
+	entry, ok := d.entries[email]
+	if !ok || d.passwords[email] != password {
+		return nil, directory.ErrInvalidCredentials
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// stubAuthenticator answers every login the same way.
+type stubAuthenticator struct {
+	user *models.User
+	err  error
+}
+
+func (a stubAuthenticator) Authenticate(context.Context, string, string) (*models.User, error) {
+	return a.user, a.err
+}
+
+const adminsDN = "cn=admins,ou=groups,dc=example,dc=org"
+
+func newTestDirectory() *fakeDirectory {
+	return &fakeDirectory{
+		entries: map[string]*directory.Entry{
+			"jane@example.org": {DN: "uid=jane,ou=people,dc=example,dc=org", Email: "jane@example.org", FirstName: "Jane", LastName: "Doe", Groups: []string{"CN=Admins,OU=Groups,DC=example,DC=org"}},
+			"john@example.org": {DN: "uid=john,ou=people,dc=example,dc=org", Email: "john@example.org", FirstName: "John", LastName: "Roe"},
+		},
+		passwords: map[string]string{"jane@example.org": "jane-password", "john@example.org": "john-password"},
+	}
+}
+
+var testRoleMapping = RoleMapping{Groups: []GroupRole{{GroupDN: adminsDN, Role: models.RoleAdmin}}, DefaultRole: models.RoleUser}
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(event *models.AuditEvent) error {
+	return m.Called(event).Error(0)
+}
+
+func (m *MockAuditService) ListEvents(options AuditListOptions) ([]models.AuditEvent, int64, error) {
+	args := m.Called(options)
+	return args.Get(0).([]models.AuditEvent), args.Get(1).(int64), args.Error(2)
+}
+
+type ldapFixture struct {
+	authenticator Authenticator
+	userRepo      *MockUserRepository
+	identities    *MockOIDCRepository
+	audit         *MockAuditService
+}
+
+func newLDAPFixture() *ldapFixture {
+	f := &ldapFixture{userRepo: new(MockUserRepository), identities: new(MockOIDCRepository), audit: new(MockAuditService)}
+	f.authenticator = NewLDAPAuthenticator(newTestDirectory(), f.userRepo, f.identities, f.audit, testRoleMapping)
+	return f
+}
+
+// link marks user as provisioned by the directory, or not.
+func (f *ldapFixture) link(user *models.User, provisioned bool) {
+	if provisioned {
+		f.identities.On("FindUserIdentity", user.ID, ldapIdentityProvider).Return(&models.UserIdentity{UserID: user.ID, Provider: ldapIdentityProvider}, nil)
+		return
+	}
+	f.identities.On("FindUserIdentity", user.ID, ldapIdentityProvider).Return(nil, gorm.ErrRecordNotFound)
+}
+
+func TestLDAPAuthenticatorProvisionsUsers(t *testing.T) {
+	f := newLDAPFixture()
+	authenticator, userRepo := f.authenticator, f.userRepo
+
+	userRepo.On("FindUserByEmail", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	f.identities.On("CreateUserWithIdentity", mock.AnythingOfType("*models.User"), mock.AnythingOfType("*models.UserIdentity")).Return(nil)
+
+	jane, err := authenticator.Authenticate(context.Background(), "jane@example.org", "jane-password")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", jane.FirstName)
+	assert.Equal(t, models.RoleAdmin, jane.Role, "group DNs compare case-insensitively")
+	assert.NotNil(t, jane.EmailVerifiedAt)
+	assert.Empty(t, jane.Password, "directory users have no local password")
+
+	john, err := authenticator.Authenticate(context.Background(), "john@example.org", "john-password")
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, john.Role)
+
+	_, err = authenticator.Authenticate(context.Background(), "john@example.org", "jane-password")
+	assert.Equal(t, ErrInvalidCredentials, err)
+	f.identities.AssertNumberOfCalls(t, "CreateUserWithIdentity", 2)
+	identity := f.identities.Calls[0].Arguments.Get(1).(*models.UserIdentity)
+	assert.Equal(t, models.UserIdentity{Provider: ldapIdentityProvider, Subject: "uid=jane,ou=people,dc=example,dc=org", Email: "jane@example.org"}, *identity)
+}
+
+func TestLDAPAuthenticatorSyncsExistingUsers(t *testing.T) {
+	f := newLDAPFixture()
+	authenticator, userRepo := f.authenticator, f.userRepo
+	verifiedAt := time.Now().Add(-time.Hour)
+
+	// John has left the admins group since his last login.
+	john := &models.User{ID: uuid.New(), FirstName: "John", LastName: "Roe", Email: "john@example.org", Role: models.RoleAdmin, EmailVerifiedAt: &verifiedAt}
+	f.link(john, true)
+	userRepo.On("FindUserByEmail", "john@example.org").Return(john, nil)
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+	userRepo.On("UpdateUser", john.ID, map[string]interface{}{"first_name": "John", "last_name": "Roe", "role": models.RoleUser}).Return(nil).Once()
+
+	user, err := authenticator.Authenticate(context.Background(), "john@example.org", "john-password")
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, user.Role)
+
+	event := f.audit.Calls[0].Arguments.Get(0).(*models.AuditEvent)
+	assert.Equal(t, models.AuditUserRoleChange, event.Action)
+	assert.Nil(t, event.ActorID)
+	assert.Equal(t, john.ID, *event.TargetID)
+	assert.Equal(t, models.AuditDetails{"via": "ldap", "from": models.RoleAdmin, "to": models.RoleUser}, event.Details)
+
+	// Unchanged users are not written.
+	_, err = authenticator.Authenticate(context.Background(), "john@example.org", "john-password")
+	require.NoError(t, err)
+	userRepo.AssertExpectations(t)
+	f.audit.AssertExpectations(t)
+}
+
+func TestLDAPAuthenticatorKeepsRolesOfLocalAccounts(t *testing.T) {
+	f := newLDAPFixture()
+	verifiedAt := time.Now().Add(-time.Hour)
+
+	// John's account was created here, say with create-admin, and only
+	// signs in through the directory.
+	john := &models.User{ID: uuid.New(), FirstName: "Johnny", LastName: "Roe", Email: "john@example.org", Password: "local-hash", Role: models.RoleAdmin, EmailVerifiedAt: &verifiedAt}
+	f.link(john, false)
+	f.userRepo.On("FindUserByEmail", "john@example.org").Return(john, nil)
+	f.userRepo.On("UpdateUser", john.ID, map[string]interface{}{"first_name": "John", "last_name": "Roe"}).Return(nil).Once()
+
+	user, err := f.authenticator.Authenticate(context.Background(), "john@example.org", "john-password")
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, user.Role)
+	assert.Equal(t, "John", user.FirstName)
+	f.userRepo.AssertExpectations(t)
+	f.identities.AssertNotCalled(t, "CreateIdentity", mock.Anything)
+	f.audit.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestLDAPAuthenticatorTakesOverUnverifiedAccounts(t *testing.T) {
+	f := newLDAPFixture()
+	authenticator, userRepo := f.authenticator, f.userRepo
+
+	squatted := &models.User{ID: uuid.New(), FirstName: "Jane", LastName: "Doe", Email: "jane@example.org", Password: "someone-elses-hash", Role: models.RoleUser}
+	f.link(squatted, false)
+	f.identities.On("CreateIdentity", &models.UserIdentity{UserID: squatted.ID, Provider: ldapIdentityProvider, Subject: "uid=jane,ou=people,dc=example,dc=org", Email: "jane@example.org"}).Return(nil).Once()
+	f.audit.On("Record", mock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+	userRepo.On("FindUserByEmail", "jane@example.org").Return(squatted, nil)
+	userRepo.On("UpdatePassword", squatted.ID, "").Return(nil).Once()
+	userRepo.On("MarkEmailVerified", squatted.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	userRepo.On("UpdateUser", squatted.ID, map[string]interface{}{"first_name": "Jane", "last_name": "Doe", "role": models.RoleAdmin}).Return(nil).Once()
+
+	user, err := authenticator.Authenticate(context.Background(), "jane@example.org", "jane-password")
+	require.NoError(t, err)
+	assert.Empty(t, user.Password)
+	assert.Equal(t, models.RoleAdmin, user.Role)
+	userRepo.AssertExpectations(t)
+	f.identities.AssertExpectations(t)
+}
+
+func TestChainAuthenticator(t *testing.T) {
+	jane := &models.User{Email: "jane@example.org"}
+	unreachable := errors.New("connecting to the LDAP server: connection refused")
+	ctx := context.Background()
+
+	chain := NewChainAuthenticator(stubAuthenticator{err: ErrInvalidCredentials}, stubAuthenticator{user: jane})
+	user, err := chain.Authenticate(ctx, "jane@example.org", "pw")
+	require.NoError(t, err)
+	assert.Same(t, jane, user)
+
+	chain = NewChainAuthenticator(stubAuthenticator{err: unreachable}, stubAuthenticator{user: jane})
+	user, err = chain.Authenticate(ctx, "jane@example.org", "pw")
+	require.NoError(t, err, "a failing backend does not keep the next from being asked")
+	assert.Same(t, jane, user)
+
+	chain = NewChainAuthenticator(stubAuthenticator{err: ErrInvalidCredentials}, stubAuthenticator{err: unreachable})
+	_, err = chain.Authenticate(ctx, "jane@example.org", "pw")
+	assert.Equal(t, unreachable, err, "the password may have been right for the backend that failed")
+
+	chain = NewChainAuthenticator(stubAuthenticator{err: ErrInvalidCredentials}, stubAuthenticator{err: ErrInvalidCredentials})
+	_, err = chain.Authenticate(ctx, "jane@example.org", "pw")
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
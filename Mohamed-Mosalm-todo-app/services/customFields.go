@@ -0,0 +1,248 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+)
+
+var customFieldKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// CustomFieldError reports why a custom field definition, value or filter
+// was rejected.
+type CustomFieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *CustomFieldError) Error() string {
+	if e.Field == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("custom field %q: %s", e.Field, e.Reason)
+}
+
+func validateFieldSchema(fields []models.CustomField) error {
+	seen := make(map[string]bool, len(fields))
+	for i := range fields {
+		field := &fields[i]
+		if !customFieldKey.MatchString(field.Key) {
+			return &CustomFieldError{Field: field.Key, Reason: "key must be lowercase letters, digits and underscores"}
+		}
+		if seen[field.Key] {
+			return &CustomFieldError{Field: field.Key, Reason: "key is defined more than once"}
+		}
+		seen[field.Key] = true
+
+		switch field.Type {
+		case models.CustomFieldText, models.CustomFieldNumber, models.CustomFieldDate:
+			field.Options = models.StringList{}
+		case models.CustomFieldSelect, models.CustomFieldMultiSelect:
+			if len(field.Options) == 0 {
+				return &CustomFieldError{Field: field.Key, Reason: "select fields need at least one option"}
+			}
+		default:
+			return &CustomFieldError{Field: field.Key, Reason: fmt.Sprintf("unknown type %q", field.Type)}
+		}
+		field.Position = i
+	}
+	return nil
+}
+
+// validateCustomFieldValues checks values against a project's schema and
+// returns them normalized: numbers as float64, dates as YYYY-MM-DD and
+// multi-select values as de-duplicated string lists. Null values are
+// dropped.
+func validateCustomFieldValues(fields []models.CustomField, values models.CustomFieldValues) (models.CustomFieldValues, error) {
+	schema := make(map[string]*models.CustomField, len(fields))
+	for i := range fields {
+		schema[fields[i].Key] = &fields[i]
+	}
+
+	normalized := make(models.CustomFieldValues, len(values))
+	for key, value := range values {
+		field, ok := schema[key]
+		if !ok {
+			return nil, &CustomFieldError{Field: key, Reason: "is not defined for this project"}
+		}
+		if value == nil {
+			continue
+		}
+		v, err := normalizeCustomFieldValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		normalized[key] = v
+	}
+
+	for _, field := range fields {
+		if _, ok := normalized[field.Key]; field.Required && !ok {
+			return nil, &CustomFieldError{Field: field.Key, Reason: "is required"}
+		}
+	}
+	return normalized, nil
+}
+
+func normalizeCustomFieldValue(field *models.CustomField, value interface{}) (interface{}, error) {
+	invalid := func(reason string) error {
+		return &CustomFieldError{Field: field.Key, Reason: reason}
+	}
+
+	switch field.Type {
+	case models.CustomFieldText:
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid("must be a string")
+		}
+		return s, nil
+
+	case models.CustomFieldNumber:
+		n, ok := value.(float64)
+		if !ok {
+			return nil, invalid("must be a number")
+		}
+		return n, nil
+
+	case models.CustomFieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid("must be a date string")
+		}
+		date, err := parseDate(s)
+		if err != nil {
+			return nil, invalid("must be a date in YYYY-MM-DD or RFC 3339 format")
+		}
+		return date.Format(time.DateOnly), nil
+
+	case models.CustomFieldSelect:
+		s, ok := value.(string)
+		if !ok || !slices.Contains(field.Options, s) {
+			return nil, invalid("must be one of " + strings.Join(field.Options, ", "))
+		}
+		return s, nil
+
+	case models.CustomFieldMultiSelect:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, invalid("must be a list of options")
+		}
+		selected := make([]string, 0, len(items))
+		seen := make(map[string]bool, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok || !slices.Contains(field.Options, s) {
+				return nil, invalid("values must be among " + strings.Join(field.Options, ", "))
+			}
+			if !seen[s] {
+				seen[s] = true
+				selected = append(selected, s)
+			}
+		}
+		return selected, nil
+	}
+
+	return nil, invalid(fmt.Sprintf("unknown type %q", field.Type))
+}
+
+// customFieldCriteria turns raw filter values into repository criteria.
+// Number and date fields accept an exact value or an inclusive range written
+// as "from..to", where either bound may be omitted.
+func customFieldCriteria(fields []models.CustomField, filters map[string]string) ([]taskRepository.CustomFieldCriterion, error) {
+	var criteria []taskRepository.CustomFieldCriterion
+	for key, raw := range filters {
+		field := findField(fields, key)
+		if field == nil {
+			return nil, &CustomFieldError{Field: key, Reason: "is not defined for this project"}
+		}
+
+		switch field.Type {
+		case models.CustomFieldMultiSelect:
+			criteria = append(criteria, taskRepository.CustomFieldCriterion{
+				Key: key, Type: field.Type, Operator: taskRepository.OpContains, Value: raw,
+			})
+
+		case models.CustomFieldNumber, models.CustomFieldDate:
+			bounds, err := parseRange(field, raw)
+			if err != nil {
+				return nil, err
+			}
+			criteria = append(criteria, bounds...)
+
+		default:
+			criteria = append(criteria, taskRepository.CustomFieldCriterion{
+				Key: key, Type: field.Type, Operator: taskRepository.OpEquals, Value: raw,
+			})
+		}
+	}
+	return criteria, nil
+}
+
+func parseRange(field *models.CustomField, raw string) ([]taskRepository.CustomFieldCriterion, error) {
+	parse := func(s string) (interface{}, error) {
+		if field.Type == models.CustomFieldNumber {
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, &CustomFieldError{Field: field.Key, Reason: fmt.Sprintf("%q is not a number", s)}
+			}
+			return n, nil
+		}
+		date, err := parseDate(s)
+		if err != nil {
+			return nil, &CustomFieldError{Field: field.Key, Reason: fmt.Sprintf("%q is not a date", s)}
+		}
+		return date.Format(time.DateOnly), nil
+	}
+
+	criterion := func(op taskRepository.Operator, s string) (taskRepository.CustomFieldCriterion, error) {
+		value, err := parse(s)
+		return taskRepository.CustomFieldCriterion{Key: field.Key, Type: field.Type, Operator: op, Value: value}, err
+	}
+
+	from, to, isRange := strings.Cut(raw, "..")
+	if !isRange {
+		c, err := criterion(taskRepository.OpEquals, raw)
+		if err != nil {
+			return nil, err
+		}
+		return []taskRepository.CustomFieldCriterion{c}, nil
+	}
+
+	var criteria []taskRepository.CustomFieldCriterion
+	if from != "" {
+		c, err := criterion(taskRepository.OpGreaterEqual, from)
+		if err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, c)
+	}
+	if to != "" {
+		c, err := criterion(taskRepository.OpLessEqual, to)
+		if err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, c)
+	}
+	return criteria, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, s); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func findField(fields []models.CustomField, key string) *models.CustomField {
+	for i := range fields {
+		if fields[i].Key == key {
+			return &fields[i]
+		}
+	}
+	return nil
+}
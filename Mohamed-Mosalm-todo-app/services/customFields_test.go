@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFields = []models.CustomField{
+	{Key: "customer", Type: models.CustomFieldText, Required: true},
+	{Key: "points", Type: models.CustomFieldNumber},
+	{Key: "launch", Type: models.CustomFieldDate},
+	{Key: "severity", Type: models.CustomFieldSelect, Options: models.StringList{"low", "high"}},
+	{Key: "labels", Type: models.CustomFieldMultiSelect, Options: models.StringList{"ui", "api"}},
+}
+
+func TestValidateCustomFieldValues(t *testing.T) {
+	values, err := validateCustomFieldValues(testFields, models.CustomFieldValues{
+		"customer": "Acme",
+		"points":   float64(5),
+		"launch":   "2024-05-01T10:00:00Z",
+		"severity": "high",
+		"labels":   []interface{}{"ui", "api", "ui"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-01", values["launch"])
+	assert.Equal(t, []string{"ui", "api"}, values["labels"])
+
+	_, err = validateCustomFieldValues(testFields, models.CustomFieldValues{"customer": "Acme", "obsolete": "x"})
+	assert.Error(t, err)
+
+	_, err = validateCustomFieldValues(testFields, models.CustomFieldValues{"points": float64(1)})
+	assert.Equal(t, &CustomFieldError{Field: "customer", Reason: "is required"}, err)
+
+	_, err = validateCustomFieldValues(testFields, models.CustomFieldValues{"customer": "Acme", "severity": "urgent"})
+	assert.Error(t, err)
+
+	_, err = validateCustomFieldValues(testFields, models.CustomFieldValues{"customer": "Acme", "points": "five"})
+	assert.Error(t, err)
+}
+
+func TestCustomFieldCriteria(t *testing.T) {
+	criteria, err := customFieldCriteria(testFields, map[string]string{"points": "3..", "labels": "ui"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []taskRepository.CustomFieldCriterion{
+		{Key: "points", Type: models.CustomFieldNumber, Operator: taskRepository.OpGreaterEqual, Value: float64(3)},
+		{Key: "labels", Type: models.CustomFieldMultiSelect, Operator: taskRepository.OpContains, Value: "ui"},
+	}, criteria)
+
+	criteria, err = customFieldCriteria(testFields, map[string]string{"launch": "2024-01-01..2024-02-01"})
+	assert.NoError(t, err)
+	assert.Len(t, criteria, 2)
+
+	_, err = customFieldCriteria(testFields, map[string]string{"points": "many"})
+	assert.Error(t, err)
+
+	_, err = customFieldCriteria(testFields, map[string]string{"unknown": "x"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/mailer"
+	"gorm.io/gorm"
+)
+
+type EmailVerificationService interface {
+	// SendVerification emails user a link that confirms their address.
+	SendVerification(ctx context.Context, user *models.User) error
+	// ResendVerification sends a fresh link to an unverified account.
+	// Unknown and already verified addresses are silently ignored.
+	ResendVerification(ctx context.Context, email string) error
+	VerifyEmail(token string) error
+}
+
+type emailVerificationService struct {
+	userRepo     userRepository.UserRepository
+	tokenService OneTimeTokenService
+	mailer       mailer.Mailer
+	baseURL      string
+	ttl          time.Duration
+}
+
+func NewEmailVerificationService(userRepo userRepository.UserRepository, tokenService OneTimeTokenService, mailer mailer.Mailer, baseURL string, ttl time.Duration) EmailVerificationService {
+	return &emailVerificationService{
+		userRepo:     userRepo,
+		tokenService: tokenService,
+		mailer:       mailer,
+		baseURL:      baseURL,
+		ttl:          ttl,
+	}
+}
+
+func (s *emailVerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := s.tokenService.Issue(user.ID, models.TokenPurposeEmailVerification, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/verify-email?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that this is your email address by opening the link below within %s:\n\n"+
+			"%s\n\n"+
+			"If you did not create an account, you can ignore this email.\n",
+			user.FirstName, s.ttl, link),
+	})
+}
+
+func (s *emailVerificationService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindUserByEmail(email)
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return s.SendVerification(ctx, user)
+}
+
+func (s *emailVerificationService) VerifyEmail(token string) error {
+	record, err := s.tokenService.Consume(token, models.TokenPurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.MarkEmailVerified(record.UserID, time.Now())
+}
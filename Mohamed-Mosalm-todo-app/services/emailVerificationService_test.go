@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResendVerificationSkipsVerifiedUsers(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	tokenRepo := new(MockOneTimeTokenRepository)
+	mail := &recordingMailer{}
+	service := NewEmailVerificationService(userRepo, NewOneTimeTokenService(tokenRepo), mail, "https://todo.example.com", time.Hour)
+
+	verifiedAt := time.Now()
+	verified := &models.User{ID: uuid.New(), Email: "verified@example.com", EmailVerifiedAt: &verifiedAt}
+	pending := &models.User{ID: uuid.New(), Email: "pending@example.com"}
+	userRepo.On("FindUserByEmail", verified.Email).Return(verified, nil)
+	userRepo.On("FindUserByEmail", pending.Email).Return(pending, nil)
+	tokenRepo.On("InvalidateUserTokens", pending.ID, models.TokenPurposeEmailVerification, mock.Anything).Return(nil)
+	tokenRepo.On("CreateToken", mock.AnythingOfType("*models.OneTimeToken")).Return(nil)
+
+	assert.NoError(t, service.ResendVerification(context.Background(), verified.Email))
+	assert.NoError(t, service.ResendVerification(context.Background(), pending.Email))
+
+	assert.Len(t, mail.sent, 1)
+	assert.Equal(t, pending.Email, mail.sent[0].To)
+	assert.Contains(t, mail.sent[0].Body, "https://todo.example.com/verify-email?token=")
+}
+
+func TestVerifyEmail(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	tokenRepo := new(MockOneTimeTokenRepository)
+	service := NewEmailVerificationService(userRepo, NewOneTimeTokenService(tokenRepo), &recordingMailer{}, "https://todo.example.com", time.Hour)
+
+	record := &models.OneTimeToken{ID: uuid.New(), UserID: uuid.New(), Purpose: models.TokenPurposeEmailVerification, ExpiresAt: time.Now().Add(time.Hour)}
+	tokenRepo.On("FindTokenByHash", auth.HashOpaqueToken("token"), models.TokenPurposeEmailVerification).Return(record, nil)
+	tokenRepo.On("ConsumeToken", record.ID, mock.Anything).Return(true, nil)
+	userRepo.On("MarkEmailVerified", record.UserID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	assert.NoError(t, service.VerifyEmail("token"))
+	userRepo.AssertExpectations(t)
+}
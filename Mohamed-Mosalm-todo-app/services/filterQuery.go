@@ -0,0 +1,374 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+	"github.com/google/uuid"
+)
+
+// FilterQueryError reports a syntax or value error in a filter query.
+type FilterQueryError struct {
+	Reason string
+}
+
+func (e *FilterQueryError) Error() string {
+	return "invalid filter query: " + e.Reason
+}
+
+// ParseFilterQuery parses the saved filter query language into a repository
+// condition. A query is a boolean expression of terms joined by "and", "or"
+// and "not" (adjacent terms are and-ed), grouped with parentheses:
+//
+//	due<7d and tag:work and not status:done
+//	(priority>=high or tag:"on call") and project:none
+//
+// Supported terms:
+//
+//	status:done|open        whether the task is done
+//	state:<state>           board column state
+//	tag:<tag>               task carries the tag
+//	priority<op><level>     none, low, medium, high, urgent
+//	project:<id>|none       project the task belongs to
+//	due<op><when>           due date; <when> is a relative offset such as
+//	                        7d, -2w or 12h, a date (2006-01-02), "today",
+//	                        "overdue" or "none"
+//
+// <op> is one of : = < <= > >=. For due dates ":" and "=" match the whole
+// day. Relative values are resolved against now.
+func ParseFilterQuery(query string, now time.Time) (taskRepository.Condition, error) {
+	tokens, err := tokenizeFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, &FilterQueryError{Reason: "query is empty"}
+	}
+
+	p := &filterParser{tokens: tokens, now: now}
+	condition, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, &FilterQueryError{Reason: fmt.Sprintf("unexpected %q", p.peek().text)}
+	}
+	return condition, nil
+}
+
+type filterTokenKind int
+
+const (
+	tokenTerm filterTokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+func tokenizeFilter(query string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokenOpen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokenClose, text: ")"})
+			i++
+		default:
+			var word strings.Builder
+			quoted := false
+			for ; i < len(runes); i++ {
+				r = runes[i]
+				if r == '"' {
+					quoted = !quoted
+					word.WriteRune(r)
+					continue
+				}
+				if !quoted && (unicode.IsSpace(r) || r == '(' || r == ')') {
+					break
+				}
+				word.WriteRune(r)
+			}
+			if quoted {
+				return nil, &FilterQueryError{Reason: "unterminated quote"}
+			}
+			tokens = append(tokens, wordToken(word.String()))
+		}
+	}
+	return tokens, nil
+}
+
+func wordToken(word string) filterToken {
+	switch strings.ToLower(word) {
+	case "and":
+		return filterToken{kind: tokenAnd, text: word}
+	case "or":
+		return filterToken{kind: tokenOr, text: word}
+	case "not":
+		return filterToken{kind: tokenNot, text: word}
+	}
+	return filterToken{kind: tokenTerm, text: word}
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	now    time.Time
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) parseOr() (taskRepository.Condition, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	conditions := taskRepository.Or{first}
+	for !p.done() && p.peek().kind == tokenOr {
+		p.pos++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, next)
+	}
+	if len(conditions) == 1 {
+		return first, nil
+	}
+	return conditions, nil
+}
+
+func (p *filterParser) parseAnd() (taskRepository.Condition, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	conditions := taskRepository.And{first}
+	for !p.done() {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.pos++
+		case tokenTerm, tokenNot, tokenOpen:
+		default:
+			return flattenAnd(conditions), nil
+		}
+		next, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, next)
+	}
+	return flattenAnd(conditions), nil
+}
+
+func flattenAnd(conditions taskRepository.And) taskRepository.Condition {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return conditions
+}
+
+func (p *filterParser) parseUnary() (taskRepository.Condition, error) {
+	if p.done() {
+		return nil, &FilterQueryError{Reason: "unexpected end of query"}
+	}
+
+	token := p.peek()
+	p.pos++
+	switch token.kind {
+	case tokenNot:
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return taskRepository.Not{Condition: inner}, nil
+	case tokenOpen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek().kind != tokenClose {
+			return nil, &FilterQueryError{Reason: "missing closing parenthesis"}
+		}
+		p.pos++
+		return inner, nil
+	case tokenTerm:
+		return p.parseTerm(token.text)
+	}
+	return nil, &FilterQueryError{Reason: fmt.Sprintf("unexpected %q", token.text)}
+}
+
+func (p *filterParser) parseTerm(term string) (taskRepository.Condition, error) {
+	i := strings.IndexAny(term, ":=<>")
+	if i <= 0 {
+		return nil, &FilterQueryError{Reason: fmt.Sprintf("%q is not a field comparison", term)}
+	}
+	field := strings.ToLower(term[:i])
+	op, rest := splitOperator(term[i:])
+	value := strings.Trim(rest, `"`)
+	if value == "" {
+		return nil, &FilterQueryError{Reason: fmt.Sprintf("%q has no value", term)}
+	}
+
+	switch field {
+	case "status":
+		if op != taskRepository.OpEquals {
+			return nil, badOperator(field)
+		}
+		switch strings.ToLower(value) {
+		case "done", "closed", "true":
+			return eq("status", true), nil
+		case "open", "todo", "false":
+			return eq("status", false), nil
+		}
+		return nil, &FilterQueryError{Reason: fmt.Sprintf("unknown status %q", value)}
+
+	case "state":
+		if op != taskRepository.OpEquals {
+			return nil, badOperator(field)
+		}
+		return eq("state", value), nil
+
+	case "tag":
+		if op != taskRepository.OpEquals {
+			return nil, badOperator(field)
+		}
+		return taskRepository.Compare{Field: "tags", Operator: taskRepository.OpContains, Value: value}, nil
+
+	case "priority":
+		priority, err := models.ParsePriority(strings.ToLower(value))
+		if err != nil {
+			n, convErr := strconv.Atoi(value)
+			if convErr != nil || n < int(models.PriorityNone) || n > int(models.PriorityUrgent) {
+				return nil, &FilterQueryError{Reason: err.Error()}
+			}
+			priority = models.Priority(n)
+		}
+		return taskRepository.Compare{Field: "priority", Operator: op, Value: int(priority)}, nil
+
+	case "project":
+		if op != taskRepository.OpEquals {
+			return nil, badOperator(field)
+		}
+		if strings.EqualFold(value, "none") {
+			return taskRepository.Compare{Field: "project_id", Operator: taskRepository.OpIsNull}, nil
+		}
+		projectID, err := uuid.Parse(value)
+		if err != nil {
+			return nil, &FilterQueryError{Reason: fmt.Sprintf("%q is not a project ID", value)}
+		}
+		return eq("project_id", projectID), nil
+
+	case "due":
+		return p.parseDue(op, strings.ToLower(value))
+	}
+
+	return nil, &FilterQueryError{Reason: fmt.Sprintf("unknown field %q", field)}
+}
+
+func (p *filterParser) parseDue(op taskRepository.Operator, value string) (taskRepository.Condition, error) {
+	switch value {
+	case "none":
+		if op != taskRepository.OpEquals {
+			return nil, badOperator("due")
+		}
+		return taskRepository.Compare{Field: "due_date", Operator: taskRepository.OpIsNull}, nil
+	case "overdue":
+		if op != taskRepository.OpEquals {
+			return nil, badOperator("due")
+		}
+		return taskRepository.Compare{Field: "due_date", Operator: taskRepository.OpLess, Value: p.now}, nil
+	}
+
+	var when time.Time
+	if value == "today" {
+		when = p.now
+	} else if offset, err := parseOffset(value); err == nil {
+		when = p.now.Add(offset)
+	} else if date, err := time.Parse(time.DateOnly, value); err == nil {
+		when = date
+	} else {
+		return nil, &FilterQueryError{Reason: fmt.Sprintf("%q is not a due date", value)}
+	}
+
+	if op != taskRepository.OpEquals {
+		return taskRepository.Compare{Field: "due_date", Operator: op, Value: when}, nil
+	}
+
+	day := time.Date(when.Year(), when.Month(), when.Day(), 0, 0, 0, 0, when.Location())
+	return taskRepository.And{
+		taskRepository.Compare{Field: "due_date", Operator: taskRepository.OpGreaterEqual, Value: day},
+		taskRepository.Compare{Field: "due_date", Operator: taskRepository.OpLess, Value: day.AddDate(0, 0, 1)},
+	}, nil
+}
+
+// parseOffset reads relative offsets such as 7d, -2w or 12h.
+func parseOffset(value string) (time.Duration, error) {
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid offset %q", value)
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil {
+		return 0, err
+	}
+	switch value[len(value)-1] {
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("invalid offset unit in %q", value)
+}
+
+func splitOperator(s string) (taskRepository.Operator, string) {
+	for _, candidate := range []struct {
+		text string
+		op   taskRepository.Operator
+	}{
+		{"<=", taskRepository.OpLessEqual},
+		{">=", taskRepository.OpGreaterEqual},
+		{"<", taskRepository.OpLess},
+		{">", taskRepository.OpGreater},
+		{":", taskRepository.OpEquals},
+		{"=", taskRepository.OpEquals},
+	} {
+		if rest, ok := strings.CutPrefix(s, candidate.text); ok {
+			return candidate.op, rest
+		}
+	}
+	return taskRepository.OpEquals, s
+}
+
+func eq(field string, value interface{}) taskRepository.Compare {
+	return taskRepository.Compare{Field: field, Operator: taskRepository.OpEquals, Value: value}
+}
+
+func badOperator(field string) error {
+	return &FilterQueryError{Reason: fmt.Sprintf("%s only supports ':'", field)}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilterQuery(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	condition, err := ParseFilterQuery("due<7d and tag:work and not status:done", now)
+	assert.NoError(t, err)
+	assert.Equal(t, taskRepository.And{
+		taskRepository.Compare{Field: "due_date", Operator: taskRepository.OpLess, Value: now.AddDate(0, 0, 7)},
+		taskRepository.Compare{Field: "tags", Operator: taskRepository.OpContains, Value: "work"},
+		taskRepository.Not{Condition: taskRepository.Compare{Field: "status", Operator: taskRepository.OpEquals, Value: true}},
+	}, condition)
+
+	condition, err = ParseFilterQuery(`(priority>=high OR tag:"on call") project:none`, now)
+	assert.NoError(t, err)
+	assert.Equal(t, taskRepository.And{
+		taskRepository.Or{
+			taskRepository.Compare{Field: "priority", Operator: taskRepository.OpGreaterEqual, Value: 3},
+			taskRepository.Compare{Field: "tags", Operator: taskRepository.OpContains, Value: "on call"},
+		},
+		taskRepository.Compare{Field: "project_id", Operator: taskRepository.OpIsNull},
+	}, condition)
+
+	condition, err = ParseFilterQuery("due:today", now)
+	assert.NoError(t, err)
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, taskRepository.And{
+		taskRepository.Compare{Field: "due_date", Operator: taskRepository.OpGreaterEqual, Value: day},
+		taskRepository.Compare{Field: "due_date", Operator: taskRepository.OpLess, Value: day.AddDate(0, 0, 1)},
+	}, condition)
+
+	condition, err = ParseFilterQuery("a:b or c", now)
+	assert.Nil(t, condition)
+	assert.IsType(t, &FilterQueryError{}, err)
+}
+
+func TestParseFilterQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"tag:work and",
+		"(tag:work",
+		"tag:work)",
+		`tag:"work`,
+		"status>done",
+		"priority:critical",
+		"due<soon",
+		"owner:me",
+	} {
+		_, err := ParseFilterQuery(query, time.Now())
+		assert.IsType(t, &FilterQueryError{}, err, "query %q", query)
+	}
+}
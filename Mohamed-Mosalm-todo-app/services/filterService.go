@@ -0,0 +1,80 @@
+package services
+
+import (
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	filterRepository "github.com/MohamedMosalm/Todo-App/repositories/filterRepository"
+	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+	"github.com/google/uuid"
+)
+
+type FilterService interface {
+	CreateFilter(filter *models.SavedFilter) error
+	GetFiltersByUserID(userID uuid.UUID) ([]models.SavedFilter, error)
+	GetFilterByID(filterID uuid.UUID) (*models.SavedFilter, error)
+	UpdateFilter(filter *models.SavedFilter) error
+	DeleteFilter(filterID, userID uuid.UUID) error
+	FilterTasks(filter *models.SavedFilter, now time.Time) ([]models.Task, error)
+}
+
+type filterService struct {
+	filterRepo  filterRepository.FilterRepository
+	taskService TaskService
+}
+
+func NewFilterService(filterRepo filterRepository.FilterRepository, taskService TaskService) FilterService {
+	return &filterService{filterRepo: filterRepo, taskService: taskService}
+}
+
+func (s *filterService) CreateFilter(filter *models.SavedFilter) error {
+	if err := validateFilter(filter); err != nil {
+		return err
+	}
+	return s.filterRepo.CreateFilter(filter)
+}
+
+func (s *filterService) GetFiltersByUserID(userID uuid.UUID) ([]models.SavedFilter, error) {
+	return s.filterRepo.GetFiltersByUserID(userID)
+}
+
+func (s *filterService) GetFilterByID(filterID uuid.UUID) (*models.SavedFilter, error) {
+	return s.filterRepo.GetFilterByID(filterID)
+}
+
+func (s *filterService) UpdateFilter(filter *models.SavedFilter) error {
+	if err := validateFilter(filter); err != nil {
+		return err
+	}
+	return s.filterRepo.UpdateFilter(filter)
+}
+
+func (s *filterService) DeleteFilter(filterID, userID uuid.UUID) error {
+	return s.filterRepo.DeleteFilter(filterID, userID)
+}
+
+// FilterTasks evaluates the filter's query for its owner, resolving relative
+// dates against now.
+func (s *filterService) FilterTasks(filter *models.SavedFilter, now time.Time) ([]models.Task, error) {
+	condition, err := ParseFilterQuery(filter.Query, now)
+	if err != nil {
+		return nil, err
+	}
+	return s.taskService.ListTasks(filter.UserID, TaskListOptions{
+		Condition: condition,
+		SortBy:    filter.SortBy,
+		Desc:      filter.SortDesc,
+	})
+}
+
+// validateFilter rejects queries that do not parse and sorts on anything but
+// plain task columns, since custom field sorts need a project.
+func validateFilter(filter *models.SavedFilter) error {
+	if _, err := ParseFilterQuery(filter.Query, time.Now()); err != nil {
+		return err
+	}
+	if filter.SortBy != "" && !taskRepository.SortableColumns[filter.SortBy] {
+		return ErrInvalidSort
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	oidcRepository "github.com/MohamedMosalm/Todo-App/repositories/oidcRepository"
+	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/directory"
+	"gorm.io/gorm"
+)
+
+// Directory checks passwords against an LDAP directory; see
+// directory.Directory.
+type Directory interface {
+	Authenticate(ctx context.Context, email, password string) (*directory.Entry, error)
+}
+
+// GroupRole gives the members of a directory group a role.
+type GroupRole struct {
+	GroupDN string
+	Role    string
+}
+
+// RoleMapping decides the role of directory users: the first of Groups the
+// user is a member of gives theirs, and DefaultRole applies otherwise.
+type RoleMapping struct {
+	Groups      []GroupRole
+	DefaultRole string
+}
+
+// roleFor returns the role of a member of groups.
+func (m RoleMapping) roleFor(groups []string) string {
+	for _, groupRole := range m.Groups {
+		for _, group := range groups {
+			if strings.EqualFold(group, groupRole.GroupDN) {
+				return groupRole.Role
+			}
+		}
+	}
+	return m.DefaultRole
+}
+
+// ldapIdentityProvider links users to the directory. OpenID Connect
+// provider names are lowercase, so it cannot clash with one of them.
+const ldapIdentityProvider = "LDAP"
+
+type ldapAuthenticator struct {
+	directory  Directory
+	userRepo   userRepository.UserRepository
+	identities oidcRepository.OIDCRepository
+	audit      AuditService
+	roles      RoleMapping
+}
+
+// NewLDAPAuthenticator checks passwords against the directory. Users are
+// provisioned on their first login, and their name is brought in line with
+// the directory on every login. Only the roles of users the directory
+// provisioned follow its groups; accounts it merely signs in to keep the
+// role they were given here.
+func NewLDAPAuthenticator(dir Directory, userRepo userRepository.UserRepository, identities oidcRepository.OIDCRepository, audit AuditService, roles RoleMapping) Authenticator {
+	return &ldapAuthenticator{directory: dir, userRepo: userRepo, identities: identities, audit: audit, roles: roles}
+}
+
+func (a *ldapAuthenticator) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	entry, err := a.directory.Authenticate(ctx, email, password)
+	if err == directory.ErrInvalidCredentials {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The user filter may match on something else than mail, but it did
+	// match the address logged in with.
+	if entry.Email == "" {
+		entry.Email = email
+	}
+	role := a.roles.roleFor(entry.Groups)
+	now := time.Now()
+	identity := &models.UserIdentity{Provider: ldapIdentityProvider, Subject: entry.DN, Email: entry.Email}
+
+	user, err := a.userRepo.FindUserByEmail(entry.Email)
+	if err == gorm.ErrRecordNotFound {
+		user = &models.User{
+			FirstName:       entry.FirstName,
+			LastName:        entry.LastName,
+			Email:           entry.Email,
+			Role:            role,
+			EmailVerifiedAt: &now,
+		}
+		if err := a.identities.CreateUserWithIdentity(user, identity); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	managed, err := a.provisioned(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// As with OIDC, the directory vouches for the address; whoever
+	// registered an unverified account with it locally never did, so the
+	// directory takes it over as if it had provisioned it.
+	if user.EmailVerifiedAt == nil {
+		if user.Password != "" {
+			if err := a.userRepo.UpdatePassword(user.ID, ""); err != nil {
+				return nil, err
+			}
+			user.Password = ""
+		}
+		if err := a.userRepo.MarkEmailVerified(user.ID, now); err != nil {
+			return nil, err
+		}
+		user.EmailVerifiedAt = &now
+		if !managed {
+			identity.UserID = user.ID
+			if err := a.identities.CreateIdentity(identity); err != nil {
+				return nil, err
+			}
+			managed = true
+		}
+	}
+
+	if !managed {
+		role = user.Role
+	}
+	if user.FirstName == entry.FirstName && user.LastName == entry.LastName && user.Role == role {
+		return user, nil
+	}
+	if user.Role != role {
+		err := a.audit.Record(&models.AuditEvent{
+			Action:   models.AuditUserRoleChange,
+			TargetID: &user.ID,
+			Details:  models.AuditDetails{"via": "ldap", "from": user.Role, "to": role},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	updates := map[string]interface{}{"first_name": entry.FirstName, "last_name": entry.LastName}
+	if managed {
+		updates["role"] = role
+	}
+	if err := a.userRepo.UpdateUser(user.ID, updates); err != nil {
+		return nil, err
+	}
+	user.FirstName, user.LastName, user.Role = entry.FirstName, entry.LastName, role
+	return user, nil
+}
+
+// provisioned reports whether the directory created the user's account.
+func (a *ldapAuthenticator) provisioned(user *models.User) (bool, error) {
+	_, err := a.identities.FindUserIdentity(user.ID, ldapIdentityProvider)
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	loginAttemptRepository "github.com/MohamedMosalm/Todo-App/repositories/loginAttemptRepository"
+	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/mailer"
+	"gorm.io/gorm"
+)
+
+// LockoutPolicy decides when failed logins lock an account or client IP out.
+type LockoutPolicy struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	BaseLockout        time.Duration
+	MaxLockout         time.Duration
+	FailureWindow      time.Duration
+}
+
+type LoginAttemptService interface {
+	// Check reports how long logins for email from ip must wait, or zero
+	// if they may go ahead.
+	Check(email, ip string) (time.Duration, error)
+	// RecordFailure counts a failed login against both the account and the
+	// IP, and emails the account owner when the account becomes locked.
+	RecordFailure(ctx context.Context, email, ip string) error
+	// RecordSuccess clears the account's failures. The IP keeps its count,
+	// so one valid login cannot wipe out a guessing run from the same IP.
+	RecordSuccess(email string) error
+}
+
+type loginAttemptService struct {
+	store    loginAttemptRepository.LoginAttemptStore
+	userRepo userRepository.UserRepository
+	mailer   mailer.Mailer
+	policy   LockoutPolicy
+}
+
+func NewLoginAttemptService(store loginAttemptRepository.LoginAttemptStore, userRepo userRepository.UserRepository, mailer mailer.Mailer, policy LockoutPolicy) LoginAttemptService {
+	return &loginAttemptService{store: store, userRepo: userRepo, mailer: mailer, policy: policy}
+}
+
+func (s *loginAttemptService) Check(email, ip string) (time.Duration, error) {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, key := range []struct {
+		key         string
+		maxFailures int
+	}{
+		{accountKey(email), s.policy.MaxAccountFailures},
+		{ipKey(ip), s.policy.MaxIPFailures},
+	} {
+		attempt, err := s.store.GetAttempt(key.key, now)
+		if err != nil {
+			return 0, err
+		}
+		if attempt == nil {
+			continue
+		}
+		if wait := s.lockedUntil(attempt, key.maxFailures).Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	return retryAfter, nil
+}
+
+func (s *loginAttemptService) RecordFailure(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	if _, err := s.store.RecordFailure(ipKey(ip), now, s.policy.FailureWindow); err != nil {
+		return err
+	}
+
+	attempt, err := s.store.RecordFailure(accountKey(email), now, s.policy.FailureWindow)
+	if err != nil {
+		return err
+	}
+	// Only the failure that starts the lockout notifies, not every one
+	// that extends it.
+	if attempt.Failures != s.policy.MaxAccountFailures {
+		return nil
+	}
+	return s.notifyLockout(ctx, email, ip, s.lockedUntil(attempt, s.policy.MaxAccountFailures))
+}
+
+func (s *loginAttemptService) RecordSuccess(email string) error {
+	return s.store.Reset(accountKey(email))
+}
+
+// lockedUntil doubles the lockout for every failure past maxFailures.
+func (s *loginAttemptService) lockedUntil(attempt *models.LoginAttempt, maxFailures int) time.Time {
+	if attempt.Failures < maxFailures {
+		return time.Time{}
+	}
+	lockout := s.policy.BaseLockout
+	for i := maxFailures; i < attempt.Failures && lockout < s.policy.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > s.policy.MaxLockout {
+		lockout = s.policy.MaxLockout
+	}
+	return attempt.LastFailureAt.Add(lockout)
+}
+
+func (s *loginAttemptService) notifyLockout(ctx context.Context, email, ip string, until time.Time) error {
+	user, err := s.userRepo.FindUserByEmail(email)
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account was temporarily locked",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"There were %d failed attempts to log in to your account, the last one from %s, so logins are paused until %s.\n\n"+
+			"If this was you, wait and try again, or reset your password. If it was not, your password has not been changed, but consider choosing a stronger one and enabling two-factor authentication.\n",
+			user.FirstName, s.policy.MaxAccountFailures, ip, until.UTC().Format(time.RFC1123)),
+	})
+}
+
+// accountKey normalises email so case variants share one counter.
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
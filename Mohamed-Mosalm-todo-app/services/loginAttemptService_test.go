@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	loginAttemptRepository "github.com/MohamedMosalm/Todo-App/repositories/loginAttemptRepository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var testLockoutPolicy = LockoutPolicy{
+	MaxAccountFailures: 3,
+	MaxIPFailures:      5,
+	BaseLockout:        time.Minute,
+	MaxLockout:         5 * time.Minute,
+	FailureWindow:      time.Hour,
+}
+
+func TestLoginAttemptLockoutBacksOffExponentially(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	mail := &recordingMailer{}
+	service := NewLoginAttemptService(loginAttemptRepository.NewMemoryLoginAttemptStore(), userRepo, mail, testLockoutPolicy)
+	ctx := context.Background()
+	user := &models.User{ID: uuid.New(), Email: "jane@example.com", FirstName: "Jane"}
+	userRepo.On("FindUserByEmail", "Jane@Example.com").Return(user, nil)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, service.RecordFailure(ctx, "Jane@Example.com", "203.0.113.7"))
+	}
+	retryAfter, err := service.Check("jane@example.com", "198.51.100.1")
+	assert.NoError(t, err)
+	assert.Zero(t, retryAfter, "failures below the limit do not lock")
+
+	assert.NoError(t, service.RecordFailure(ctx, "Jane@Example.com", "203.0.113.7"))
+	retryAfter, _ = service.Check("jane@example.com", "198.51.100.1")
+	assert.InDelta(t, time.Minute, retryAfter, float64(time.Second), "the account is locked from any IP")
+	assert.Len(t, mail.sent, 1)
+	assert.Equal(t, user.Email, mail.sent[0].To)
+	assert.Contains(t, mail.sent[0].Body, "203.0.113.7")
+
+	expected := []time.Duration{2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for _, want := range expected {
+		assert.NoError(t, service.RecordFailure(ctx, "Jane@Example.com", "203.0.113.7"))
+		retryAfter, _ = service.Check("jane@example.com", "")
+		assert.InDelta(t, want, retryAfter, float64(time.Second))
+	}
+	assert.Len(t, mail.sent, 1, "only the failure that starts the lockout notifies")
+
+	assert.NoError(t, service.RecordSuccess("JANE@example.com"))
+	retryAfter, _ = service.Check("jane@example.com", "198.51.100.1")
+	assert.Zero(t, retryAfter)
+}
+
+func TestLoginAttemptLockoutPerIP(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	mail := &recordingMailer{}
+	service := NewLoginAttemptService(loginAttemptRepository.NewMemoryLoginAttemptStore(), userRepo, mail, testLockoutPolicy)
+
+	// Spread across accounts so no single account reaches its limit.
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"} {
+		assert.NoError(t, service.RecordFailure(context.Background(), email, "203.0.113.7"))
+	}
+
+	retryAfter, err := service.Check("f@example.com", "203.0.113.7")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, retryAfter, float64(time.Second))
+	retryAfter, _ = service.Check("f@example.com", "198.51.100.1")
+	assert.Zero(t, retryAfter, "other IPs are unaffected")
+
+	assert.NoError(t, service.RecordSuccess("f@example.com"))
+	retryAfter, _ = service.Check("f@example.com", "203.0.113.7")
+	assert.NotZero(t, retryAfter, "a successful login does not clear the IP")
+	assert.Empty(t, mail.sent)
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	loginAttemptRepository "github.com/MohamedMosalm/Todo-App/repositories/loginAttemptRepository"
+	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/mailer"
+	"gorm.io/gorm"
+)
+
+var ErrMagicLinkWrongDevice = errors.New("magic link was requested from another device")
+
+// MagicLinkPolicy decides how long sign-in links last and how many one
+// email address may ask for.
+type MagicLinkPolicy struct {
+	TTL time.Duration
+	// MaxRequests links may be asked for per address until RequestWindow
+	// passes without another request.
+	MaxRequests   int
+	RequestWindow time.Duration
+}
+
+type MagicLinkService interface {
+	// RequestLink emails a sign-in link to the account registered under
+	// email. deviceHash, if not empty, binds the link to the device holding
+	// the secret it hashes. If email has asked for too many links lately,
+	// nothing is sent and the wait is returned instead. Unknown addresses
+	// are counted alike and are not an error, so callers cannot tell
+	// whether an account exists.
+	RequestLink(ctx context.Context, email, deviceHash string) (time.Duration, error)
+	// ConsumeLink signs in with an emailed link exactly once and returns its
+	// user. A link bound to another device is refused and stays usable.
+	ConsumeLink(token, deviceHash string) (*models.User, error)
+}
+
+type magicLinkService struct {
+	userRepo     userRepository.UserRepository
+	tokenService OneTimeTokenService
+	requests     loginAttemptRepository.LoginAttemptStore
+	mailer       mailer.Mailer
+	baseURL      string
+	policy       MagicLinkPolicy
+}
+
+func NewMagicLinkService(userRepo userRepository.UserRepository, tokenService OneTimeTokenService, requests loginAttemptRepository.LoginAttemptStore, mailer mailer.Mailer, baseURL string, policy MagicLinkPolicy) MagicLinkService {
+	return &magicLinkService{
+		userRepo:     userRepo,
+		tokenService: tokenService,
+		requests:     requests,
+		mailer:       mailer,
+		baseURL:      baseURL,
+		policy:       policy,
+	}
+}
+
+func (s *magicLinkService) RequestLink(ctx context.Context, email, deviceHash string) (time.Duration, error) {
+	now := time.Now()
+	key := magicLinkKey(email)
+	requests, err := s.requests.GetAttempt(key, now)
+	if err != nil {
+		return 0, err
+	}
+	// Refused requests are not counted, so the wait does not grow.
+	if requests != nil && requests.Failures >= s.policy.MaxRequests {
+		return requests.ExpiresAt.Sub(now), nil
+	}
+	if _, err := s.requests.RecordFailure(key, now, s.policy.RequestWindow); err != nil {
+		return 0, err
+	}
+
+	user, err := s.userRepo.FindUserByEmail(email)
+	if err == gorm.ErrRecordNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !user.IsActive() {
+		return 0, nil
+	}
+
+	token, err := s.tokenService.IssueForDevice(user.ID, models.TokenPurposeMagicLink, s.policy.TTL, deviceHash)
+	if err != nil {
+		return 0, err
+	}
+
+	link := s.baseURL + "/magic-link?token=" + url.QueryEscape(token)
+	return 0, s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Open the link below within %s to sign in. It works once, in the browser you asked for it from:\n\n"+
+			"%s\n\n"+
+			"If you did not ask for this, you can ignore this email; nobody can sign in without the link.\n",
+			user.FirstName, s.policy.TTL, link),
+	})
+}
+
+func (s *magicLinkService) ConsumeLink(token, deviceHash string) (*models.User, error) {
+	record, err := s.tokenService.Lookup(token, models.TokenPurposeMagicLink)
+	if err != nil {
+		return nil, err
+	}
+	if record.DeviceHash != "" && subtle.ConstantTimeCompare([]byte(record.DeviceHash), []byte(deviceHash)) != 1 {
+		return nil, ErrMagicLinkWrongDevice
+	}
+	if _, err := s.tokenService.Consume(token, models.TokenPurposeMagicLink); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindUserByID(record.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.EmailVerifiedAt == nil {
+		// Whoever registered the account never proved they own the
+		// address; the link's reader just did, so their password goes.
+		if user.Password != "" {
+			if err := s.userRepo.UpdatePassword(user.ID, ""); err != nil {
+				return nil, err
+			}
+			user.Password = ""
+		}
+		now := time.Now()
+		if err := s.userRepo.MarkEmailVerified(user.ID, now); err != nil {
+			return nil, err
+		}
+		user.EmailVerifiedAt = &now
+	}
+	return user, nil
+}
+
+// magicLinkKey normalises email so case variants share one counter.
+func magicLinkKey(email string) string {
+	return "magic_link:" + strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	loginAttemptRepository "github.com/MohamedMosalm/Todo-App/repositories/loginAttemptRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testMagicLinkPolicy = MagicLinkPolicy{TTL: 15 * time.Minute, MaxRequests: 2, RequestWindow: time.Hour}
+
+func TestRequestMagicLinkIsRateLimited(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	tokenRepo := new(MockOneTimeTokenRepository)
+	mail := &recordingMailer{}
+	service := NewMagicLinkService(userRepo, NewOneTimeTokenService(tokenRepo), loginAttemptRepository.NewMemoryLoginAttemptStore(), mail, "https://todo.example.com", testMagicLinkPolicy)
+	ctx := context.Background()
+
+	user := &models.User{ID: uuid.New(), Email: "jane@example.com", FirstName: "Jane"}
+	userRepo.On("FindUserByEmail", mock.MatchedBy(func(email string) bool { return email != "nobody@example.com" })).Return(user, nil)
+	userRepo.On("FindUserByEmail", "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+	tokenRepo.On("InvalidateUserTokens", user.ID, models.TokenPurposeMagicLink, mock.Anything).Return(nil)
+	tokenRepo.On("CreateToken", mock.MatchedBy(func(token *models.OneTimeToken) bool {
+		return token.DeviceHash == "device-hash" && token.ExpiresAt.Sub(time.Now()) <= testMagicLinkPolicy.TTL
+	})).Return(nil)
+
+	for _, email := range []string{"jane@example.com", "JANE@example.com"} {
+		retryAfter, err := service.RequestLink(ctx, email, "device-hash")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+	}
+	retryAfter, err := service.RequestLink(ctx, "jane@example.com", "device-hash")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, retryAfter, float64(time.Second), "case variants share a limit")
+	assert.Len(t, mail.sent, 2)
+	assert.Contains(t, mail.sent[0].Body, "https://todo.example.com/magic-link?token=")
+
+	// Unknown addresses are limited alike, so the limit gives nothing away.
+	for i := 0; i < 2; i++ {
+		retryAfter, _ = service.RequestLink(ctx, "nobody@example.com", "")
+		assert.Zero(t, retryAfter)
+	}
+	retryAfter, _ = service.RequestLink(ctx, "nobody@example.com", "")
+	assert.Positive(t, retryAfter)
+	assert.Len(t, mail.sent, 2)
+}
+
+func TestConsumeMagicLinkChecksDevice(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	tokenRepo := new(MockOneTimeTokenRepository)
+	service := NewMagicLinkService(userRepo, NewOneTimeTokenService(tokenRepo), loginAttemptRepository.NewMemoryLoginAttemptStore(), &recordingMailer{}, "https://todo.example.com", testMagicLinkPolicy)
+
+	verifiedAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "jane@example.com", Password: "hash", EmailVerifiedAt: &verifiedAt}
+	record := &models.OneTimeToken{ID: uuid.New(), UserID: user.ID, Purpose: models.TokenPurposeMagicLink, ExpiresAt: time.Now().Add(time.Minute), DeviceHash: auth.HashOpaqueToken("device")}
+	tokenRepo.On("FindTokenByHash", auth.HashOpaqueToken("token"), models.TokenPurposeMagicLink).Return(record, nil)
+	tokenRepo.On("ConsumeToken", record.ID, mock.Anything).Return(true, nil).Once()
+	userRepo.On("FindUserByID", user.ID).Return(user, nil)
+
+	_, err := service.ConsumeLink("token", "")
+	assert.Equal(t, ErrMagicLinkWrongDevice, err)
+	_, err = service.ConsumeLink("token", auth.HashOpaqueToken("another-device"))
+	assert.Equal(t, ErrMagicLinkWrongDevice, err)
+
+	signedIn, err := service.ConsumeLink("token", auth.HashOpaqueToken("device"))
+	require.NoError(t, err)
+	assert.Equal(t, "hash", signedIn.Password, "verified accounts keep their password")
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestConsumeMagicLinkTakesOverUnverifiedAccounts(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	tokenRepo := new(MockOneTimeTokenRepository)
+	service := NewMagicLinkService(userRepo, NewOneTimeTokenService(tokenRepo), loginAttemptRepository.NewMemoryLoginAttemptStore(), &recordingMailer{}, "https://todo.example.com", testMagicLinkPolicy)
+
+	squatted := &models.User{ID: uuid.New(), Email: "jane@example.com", Password: "someone-elses-hash"}
+	record := &models.OneTimeToken{ID: uuid.New(), UserID: squatted.ID, Purpose: models.TokenPurposeMagicLink, ExpiresAt: time.Now().Add(time.Minute)}
+	tokenRepo.On("FindTokenByHash", auth.HashOpaqueToken("token"), models.TokenPurposeMagicLink).Return(record, nil)
+	tokenRepo.On("ConsumeToken", record.ID, mock.Anything).Return(true, nil)
+	userRepo.On("FindUserByID", squatted.ID).Return(squatted, nil)
+	userRepo.On("UpdatePassword", squatted.ID, "").Return(nil).Once()
+	userRepo.On("MarkEmailVerified", squatted.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	user, err := service.ConsumeLink("token", "unbound-links-ignore-the-device")
+	require.NoError(t, err)
+	assert.Empty(t, user.Password)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	userRepo.AssertExpectations(t)
+}
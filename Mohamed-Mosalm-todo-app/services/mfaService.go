@@ -0,0 +1,166 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	mfaRepository "github.com/MohamedMosalm/Todo-App/repositories/mfaRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// recoveryCodeCount is how many recovery codes a confirmed enrolment gets.
+const recoveryCodeCount = 10
+
+var (
+	ErrMFANotEnrolled     = errors.New("two-factor authentication is not set up")
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrInvalidMFACode     = errors.New("invalid authentication code")
+	recoveryCodeEncoding  = base32.StdEncoding.WithPadding(base32.NoPadding)
+	recoveryCodeSeparator = strings.NewReplacer("-", "", " ", "")
+)
+
+type MFAService interface {
+	// Enroll starts a new, unconfirmed enrolment and returns its secret
+	// together with the otpauth URI for authenticator apps.
+	Enroll(user *models.User) (secret, uri string, err error)
+	// Confirm enables two-factor authentication once code proves the app
+	// is set up, and returns freshly generated recovery codes.
+	Confirm(userID uuid.UUID, code string) ([]string, error)
+	IsEnabled(userID uuid.UUID) (bool, error)
+	// Verify accepts either a current TOTP code or an unused recovery code.
+	Verify(userID uuid.UUID, code string) error
+	Disable(userID uuid.UUID) error
+}
+
+type mfaService struct {
+	mfaRepo mfaRepository.MFARepository
+	issuer  string
+}
+
+func NewMFAService(mfaRepo mfaRepository.MFARepository, issuer string) MFAService {
+	return &mfaService{mfaRepo: mfaRepo, issuer: issuer}
+}
+
+func (s *mfaService) Enroll(user *models.User) (string, string, error) {
+	enabled, err := s.IsEnabled(user.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if enabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.mfaRepo.SaveCredential(&models.TOTPCredential{UserID: user.ID, Secret: secret, CreatedAt: time.Now()}); err != nil {
+		return "", "", err
+	}
+	return secret, auth.TOTPURI(s.issuer, user.Email, secret), nil
+}
+
+func (s *mfaService) Confirm(userID uuid.UUID, code string) ([]string, error) {
+	credential, err := s.getCredential(userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	now := time.Now()
+	counter, ok := auth.ValidateTOTP(credential.Secret, code, now, credential.LastCounter)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = generateRecoveryCode(); err != nil {
+			return nil, err
+		}
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(codes[i])}
+	}
+
+	if err := s.mfaRepo.ConfirmCredential(userID, counter, now, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *mfaService) IsEnabled(userID uuid.UUID) (bool, error) {
+	credential, err := s.mfaRepo.GetCredential(userID)
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return credential.ConfirmedAt != nil, nil
+}
+
+func (s *mfaService) Verify(userID uuid.UUID, code string) error {
+	credential, err := s.getCredential(userID)
+	if err != nil {
+		return err
+	}
+	if credential.ConfirmedAt == nil {
+		return ErrMFANotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+	if counter, ok := auth.ValidateTOTP(credential.Secret, code, time.Now(), credential.LastCounter); ok {
+		advanced, err := s.mfaRepo.AdvanceCounter(userID, counter)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	consumed, err := s.mfaRepo.ConsumeRecoveryCode(userID, hashRecoveryCode(code), time.Now())
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *mfaService) Disable(userID uuid.UUID) error {
+	return s.mfaRepo.DeleteCredential(userID)
+}
+
+func (s *mfaService) getCredential(userID uuid.UUID) (*models.TOTPCredential, error) {
+	credential, err := s.mfaRepo.GetCredential(userID)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrMFANotEnrolled
+	}
+	return credential, err
+}
+
+// generateRecoveryCode returns 60 random bits as "xxxx-xxxx-xxxx".
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))[:12]
+	return code[:4] + "-" + code[4:8] + "-" + code[8:], nil
+}
+
+// hashRecoveryCode normalises a code as users might type it before hashing.
+func hashRecoveryCode(code string) string {
+	return auth.HashOpaqueToken(strings.ToLower(recoveryCodeSeparator.Replace(code)))
+}
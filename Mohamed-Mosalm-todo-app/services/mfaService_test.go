@@ -0,0 +1,148 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) GetCredential(userID uuid.UUID) (*models.TOTPCredential, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TOTPCredential), args.Error(1)
+}
+
+func (m *MockMFARepository) SaveCredential(credential *models.TOTPCredential) error {
+	return m.Called(credential).Error(0)
+}
+
+func (m *MockMFARepository) ConfirmCredential(userID uuid.UUID, counter int64, now time.Time, recoveryCodes []models.RecoveryCode) error {
+	return m.Called(userID, counter, now, recoveryCodes).Error(0)
+}
+
+func (m *MockMFARepository) AdvanceCounter(userID uuid.UUID, counter int64) (bool, error) {
+	args := m.Called(userID, counter)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) ConsumeRecoveryCode(userID uuid.UUID, codeHash string, now time.Time) (bool, error) {
+	args := m.Called(userID, codeHash, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) DeleteCredential(userID uuid.UUID) error {
+	return m.Called(userID).Error(0)
+}
+
+func TestMFAEnrollAndConfirm(t *testing.T) {
+	repo := new(MockMFARepository)
+	service := NewMFAService(repo, "Todo App")
+	user := &models.User{ID: uuid.New(), Email: "jane@example.com"}
+
+	var saved *models.TOTPCredential
+	repo.On("GetCredential", user.ID).Return(nil, gorm.ErrRecordNotFound).Once()
+	repo.On("SaveCredential", mock.AnythingOfType("*models.TOTPCredential")).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*models.TOTPCredential)
+	}).Return(nil)
+
+	secret, uri, err := service.Enroll(user)
+	assert.NoError(t, err)
+	assert.Equal(t, secret, saved.Secret)
+	assert.Nil(t, saved.ConfirmedAt)
+	assert.Contains(t, uri, "otpauth://totp/")
+	assert.Contains(t, uri, "secret="+secret)
+
+	repo.On("GetCredential", user.ID).Return(saved, nil)
+	var stored []models.RecoveryCode
+	repo.On("ConfirmCredential", user.ID, mock.AnythingOfType("int64"), mock.AnythingOfType("time.Time"), mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(3).([]models.RecoveryCode)
+	}).Return(nil)
+
+	codes, err := service.Confirm(user.ID, currentTOTPCode(t, secret))
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, stored, recoveryCodeCount)
+	for i, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, code)
+		assert.Equal(t, hashRecoveryCode(code), stored[i].CodeHash)
+		assert.NotContains(t, stored[i].CodeHash, code)
+	}
+	repo.AssertExpectations(t)
+}
+
+func currentTOTPCode(t *testing.T, secret string) string {
+	code, err := auth.TOTPCode(secret, time.Now())
+	assert.NoError(t, err)
+	return code
+}
+
+func TestMFAEnrollRefusesWhenEnabled(t *testing.T) {
+	repo := new(MockMFARepository)
+	service := NewMFAService(repo, "Todo App")
+	user := &models.User{ID: uuid.New()}
+	confirmedAt := time.Now()
+	repo.On("GetCredential", user.ID).Return(&models.TOTPCredential{UserID: user.ID, ConfirmedAt: &confirmedAt}, nil)
+
+	_, _, err := service.Enroll(user)
+	assert.Equal(t, ErrMFAAlreadyEnabled, err)
+	repo.AssertNotCalled(t, "SaveCredential", mock.Anything)
+}
+
+func TestMFAVerify(t *testing.T) {
+	secret, err := auth.GenerateTOTPSecret()
+	assert.NoError(t, err)
+	userID := uuid.New()
+	confirmedAt := time.Now()
+	credential := &models.TOTPCredential{UserID: userID, Secret: secret, ConfirmedAt: &confirmedAt}
+
+	t.Run("accepts a current code once", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := NewMFAService(repo, "Todo App")
+		repo.On("GetCredential", userID).Return(credential, nil)
+		repo.On("AdvanceCounter", userID, mock.AnythingOfType("int64")).Return(true, nil).Once()
+		repo.On("AdvanceCounter", userID, mock.AnythingOfType("int64")).Return(false, nil).Once()
+
+		code := currentTOTPCode(t, secret)
+		assert.NoError(t, service.Verify(userID, code))
+		assert.Equal(t, ErrInvalidMFACode, service.Verify(userID, code))
+	})
+
+	t.Run("accepts a recovery code however it is typed", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := NewMFAService(repo, "Todo App")
+		repo.On("GetCredential", userID).Return(credential, nil)
+		repo.On("ConsumeRecoveryCode", userID, hashRecoveryCode("abcd-efgh-ijkl"), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+
+		assert.NoError(t, service.Verify(userID, " ABCD EFGH-IJKL "))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects unknown codes", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := NewMFAService(repo, "Todo App")
+		repo.On("GetCredential", userID).Return(credential, nil)
+		repo.On("ConsumeRecoveryCode", userID, mock.Anything, mock.Anything).Return(false, nil)
+
+		assert.Equal(t, ErrInvalidMFACode, service.Verify(userID, "not-a-code"))
+	})
+
+	t.Run("requires a confirmed enrolment", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := NewMFAService(repo, "Todo App")
+		repo.On("GetCredential", userID).Return(&models.TOTPCredential{UserID: userID, Secret: secret}, nil)
+
+		assert.Equal(t, ErrMFANotEnrolled, service.Verify(userID, currentTOTPCode(t, secret)))
+	})
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	oidcRepository "github.com/MohamedMosalm/Todo-App/repositories/oidcRepository"
+	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/sso"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownOIDCProvider = errors.New("unknown identity provider")
+	ErrInvalidOIDCState    = errors.New("login is invalid or has expired")
+	// ErrOIDCEmailNotVerified is returned for first logins whose provider
+	// did not vouch for the email address, which is what accounts are
+	// matched and created by.
+	ErrOIDCEmailNotVerified = errors.New("identity provider did not share a verified email address")
+)
+
+type OIDCService interface {
+	// Providers lists the configured providers in configuration order.
+	Providers() []*sso.Provider
+	// BeginLogin returns the provider URL to send the user to and the state
+	// the provider will send back with them.
+	BeginLogin(ctx context.Context, provider string) (authURL, state string, err error)
+	// CompleteLogin verifies the provider's answer and returns the user it
+	// is for, linking the identity to an existing account with the same
+	// verified email address or creating an account on first login.
+	CompleteLogin(ctx context.Context, provider, state, code string) (*models.User, error)
+}
+
+type oidcService struct {
+	providers []*sso.Provider
+	oidcRepo  oidcRepository.OIDCRepository
+	userRepo  userRepository.UserRepository
+	loginTTL  time.Duration
+}
+
+// NewOIDCService gives users loginTTL to sign in at the provider.
+func NewOIDCService(providers []*sso.Provider, oidcRepo oidcRepository.OIDCRepository, userRepo userRepository.UserRepository, loginTTL time.Duration) OIDCService {
+	return &oidcService{providers: providers, oidcRepo: oidcRepo, userRepo: userRepo, loginTTL: loginTTL}
+}
+
+func (s *oidcService) Providers() []*sso.Provider {
+	return s.providers
+}
+
+func (s *oidcService) provider(name string) (*sso.Provider, error) {
+	for _, provider := range s.providers {
+		if provider.Name == name {
+			return provider, nil
+		}
+	}
+	return nil, ErrUnknownOIDCProvider
+}
+
+func (s *oidcService) BeginLogin(ctx context.Context, providerName string) (string, string, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, stateHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier := oauth2.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	// Logins abandoned at the provider are never consumed.
+	if err := s.oidcRepo.DeleteExpiredLoginStates(now); err != nil {
+		return "", "", err
+	}
+	if err := s.oidcRepo.CreateLoginState(&models.OIDCLoginState{
+		StateHash:    stateHash,
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    now.Add(s.loginTTL),
+	}); err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+func (s *oidcService) CompleteLogin(ctx context.Context, providerName, state, code string) (*models.User, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	loginState, err := s.oidcRepo.ConsumeLoginState(auth.HashOpaqueToken(state))
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrInvalidOIDCState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if loginState.Provider != provider.Name || !time.Now().Before(loginState.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	claims, err := provider.Exchange(ctx, code, loginState.Nonce, loginState.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := s.oidcRepo.FindIdentity(provider.Name, claims.Subject)
+	if err == nil {
+		return s.userRepo.FindUserByID(identity.UserID)
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+	identity = &models.UserIdentity{Provider: provider.Name, Subject: claims.Subject, Email: claims.Email}
+	now := time.Now()
+
+	user, err := s.userRepo.FindUserByEmail(claims.Email)
+	if err == gorm.ErrRecordNotFound {
+		user = &models.User{
+			FirstName:       claims.GivenName,
+			LastName:        claims.FamilyName,
+			Email:           claims.Email,
+			EmailVerifiedAt: &now,
+		}
+		if err := s.oidcRepo.CreateUserWithIdentity(user, identity); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	identity.UserID = user.ID
+	if err := s.oidcRepo.CreateIdentity(identity); err != nil {
+		return nil, err
+	}
+	// The provider has confirmed the address the account was registered with.
+	// Whoever registered it never did, so the password they chose goes; the
+	// owner can set one with a password reset.
+	if user.EmailVerifiedAt == nil {
+		if user.Password != "" {
+			if err := s.userRepo.UpdatePassword(user.ID, ""); err != nil {
+				return nil, err
+			}
+			user.Password = ""
+		}
+		if err := s.userRepo.MarkEmailVerified(user.ID, now); err != nil {
+			return nil, err
+		}
+		user.EmailVerifiedAt = &now
+	}
+	return user, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/sso"
+	"github.com/MohamedMosalm/Todo-App/utils/sso/ssotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockOIDCRepository struct {
+	mock.Mock
+}
+
+func (m *MockOIDCRepository) CreateLoginState(state *models.OIDCLoginState) error {
+	return m.Called(state).Error(0)
+}
+
+func (m *MockOIDCRepository) ConsumeLoginState(stateHash string) (*models.OIDCLoginState, error) {
+	args := m.Called(stateHash)
+	state := args.Get(0)
+	if state == nil {
+		return nil, args.Error(1)
+	}
+	return state.(*models.OIDCLoginState), args.Error(1)
+}
+
+func (m *MockOIDCRepository) DeleteExpiredLoginStates(now time.Time) error {
+	return m.Called(now).Error(0)
+}
+
+func (m *MockOIDCRepository) FindIdentity(provider, subject string) (*models.UserIdentity, error) {
+	args := m.Called(provider, subject)
+	identity := args.Get(0)
+	if identity == nil {
+		return nil, args.Error(1)
+	}
+	return identity.(*models.UserIdentity), args.Error(1)
+}
+
+func (m *MockOIDCRepository) FindUserIdentity(userID uuid.UUID, provider string) (*models.UserIdentity, error) {
+	args := m.Called(userID, provider)
+	identity := args.Get(0)
+	if identity == nil {
+		return nil, args.Error(1)
+	}
+	return identity.(*models.UserIdentity), args.Error(1)
+}
+
+func (m *MockOIDCRepository) CreateIdentity(identity *models.UserIdentity) error {
+	return m.Called(identity).Error(0)
+}
+
+func (m *MockOIDCRepository) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return m.Called(user, identity).Error(0)
+}
+
+type oidcFixture struct {
+	service  OIDCService
+	server   *ssotest.Server
+	oidcRepo *MockOIDCRepository
+	userRepo *MockUserRepository
+	// states holds the login states BeginLogin stored, by state hash.
+	states map[string]*models.OIDCLoginState
+}
+
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	t.Helper()
+	f := &oidcFixture{
+		server:   ssotest.NewServer("todo-app", "s3cret"),
+		oidcRepo: new(MockOIDCRepository),
+		userRepo: new(MockUserRepository),
+		states:   map[string]*models.OIDCLoginState{},
+	}
+	t.Cleanup(f.server.Close)
+
+	provider := sso.NewProvider(config.OIDCProviderConfig{
+		Name:         "corp",
+		IssuerURL:    f.server.URL,
+		ClientID:     "todo-app",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"openid", "email", "profile"},
+	}, "https://todo.example.com/api/auth/oidc/corp/callback")
+	f.service = NewOIDCService([]*sso.Provider{provider}, f.oidcRepo, f.userRepo, 10*time.Minute)
+
+	f.oidcRepo.On("DeleteExpiredLoginStates", mock.Anything).Return(nil)
+	f.oidcRepo.On("CreateLoginState", mock.AnythingOfType("*models.OIDCLoginState")).Return(nil).Run(func(args mock.Arguments) {
+		state := args.Get(0).(*models.OIDCLoginState)
+		f.states[state.StateHash] = state
+	})
+	return f
+}
+
+// login runs the whole flow for user and returns what CompleteLogin did.
+func (f *oidcFixture) login(t *testing.T, user ssotest.User) (*models.User, error) {
+	t.Helper()
+	f.server.SignIn(user)
+	authURL, state, err := f.service.BeginLogin(context.Background(), "corp")
+	require.NoError(t, err)
+
+	code, returnedState, err := f.server.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, state, returnedState)
+
+	stored := f.states[auth.HashOpaqueToken(state)]
+	require.NotNil(t, stored, "only a hash of the state is stored")
+	f.oidcRepo.On("ConsumeLoginState", auth.HashOpaqueToken(state)).Return(stored, nil).Once()
+	return f.service.CompleteLogin(context.Background(), "corp", state, code)
+}
+
+var jane = ssotest.User{Subject: "jane-sub", Email: "jane@example.com", EmailVerified: true, GivenName: "Jane", FamilyName: "Doe"}
+
+func TestOIDCLoginCreatesUser(t *testing.T) {
+	f := newOIDCFixture(t)
+	f.oidcRepo.On("FindIdentity", "corp", "jane-sub").Return(nil, gorm.ErrRecordNotFound)
+	f.userRepo.On("FindUserByEmail", "jane@example.com").Return(nil, gorm.ErrRecordNotFound)
+	f.oidcRepo.On("CreateUserWithIdentity", mock.AnythingOfType("*models.User"), mock.AnythingOfType("*models.UserIdentity")).Return(nil)
+
+	user, err := f.login(t, jane)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", user.FirstName)
+	assert.Equal(t, "Doe", user.LastName)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	assert.Empty(t, user.Password, "accounts created by SSO cannot log in with a password")
+
+	identity := f.oidcRepo.Calls[len(f.oidcRepo.Calls)-1].Arguments.Get(1).(*models.UserIdentity)
+	assert.Equal(t, models.UserIdentity{Provider: "corp", Subject: "jane-sub", Email: "jane@example.com"}, *identity)
+}
+
+func TestOIDCLoginFindsLinkedUser(t *testing.T) {
+	f := newOIDCFixture(t)
+	existing := &models.User{ID: uuid.New(), Email: "jane.old@example.com"}
+	f.oidcRepo.On("FindIdentity", "corp", "jane-sub").Return(&models.UserIdentity{UserID: existing.ID}, nil)
+	f.userRepo.On("FindUserByID", existing.ID).Return(existing, nil)
+
+	user, err := f.login(t, jane)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, user.ID, "identities are matched by subject, not email")
+	f.oidcRepo.AssertNotCalled(t, "CreateIdentity", mock.Anything)
+}
+
+func TestOIDCLoginLinksExistingAccount(t *testing.T) {
+	f := newOIDCFixture(t)
+	existing := &models.User{ID: uuid.New(), Email: "jane@example.com", Password: "hash"}
+	f.oidcRepo.On("FindIdentity", "corp", "jane-sub").Return(nil, gorm.ErrRecordNotFound)
+	f.userRepo.On("FindUserByEmail", "jane@example.com").Return(existing, nil)
+	f.oidcRepo.On("CreateIdentity", mock.AnythingOfType("*models.UserIdentity")).Return(nil).Once()
+	f.userRepo.On("MarkEmailVerified", existing.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	f.userRepo.On("UpdatePassword", existing.ID, "").Return(nil).Once()
+
+	user, err := f.login(t, jane)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, user.ID)
+	assert.Empty(t, user.Password, "the password of an unverified account may belong to someone else")
+	f.oidcRepo.AssertExpectations(t)
+	f.userRepo.AssertExpectations(t)
+}
+
+func TestOIDCLoginRequiresVerifiedEmail(t *testing.T) {
+	f := newOIDCFixture(t)
+	f.oidcRepo.On("FindIdentity", "corp", "mallory-sub").Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := f.login(t, ssotest.User{Subject: "mallory-sub", Email: "jane@example.com"})
+	assert.Equal(t, ErrOIDCEmailNotVerified, err)
+	f.userRepo.AssertNotCalled(t, "FindUserByEmail", mock.Anything)
+}
+
+func TestOIDCLoginRejectsUnknownState(t *testing.T) {
+	f := newOIDCFixture(t)
+	f.oidcRepo.On("ConsumeLoginState", auth.HashOpaqueToken("forged-state")).Return(nil, gorm.ErrRecordNotFound)
+	f.server.SignIn(jane)
+	authURL, _, err := f.service.BeginLogin(context.Background(), "corp")
+	require.NoError(t, err)
+	code, _, err := f.server.Authorize(authURL)
+	require.NoError(t, err)
+
+	_, err = f.service.CompleteLogin(context.Background(), "corp", "forged-state", code)
+	assert.Equal(t, ErrInvalidOIDCState, err)
+
+	_, _, err = f.service.BeginLogin(context.Background(), "unknown")
+	assert.Equal(t, ErrUnknownOIDCProvider, err)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	oneTimeTokenRepository "github.com/MohamedMosalm/Todo-App/repositories/oneTimeTokenRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidOneTimeToken = errors.New("token is invalid, expired or already used")
+
+type OneTimeTokenService interface {
+	// Issue creates a token for purpose that expires after ttl. Earlier
+	// unused tokens of the same user and purpose stop working.
+	Issue(userID uuid.UUID, purpose models.TokenPurpose, ttl time.Duration) (string, error)
+	// IssueForDevice is Issue for a token bound to the device whose secret
+	// hashes to deviceHash. Checking it is up to the caller.
+	IssueForDevice(userID uuid.UUID, purpose models.TokenPurpose, ttl time.Duration, deviceHash string) (string, error)
+	// Lookup returns the token's record if it is still usable for purpose,
+	// without using it up.
+	Lookup(token string, purpose models.TokenPurpose) (*models.OneTimeToken, error)
+	// Consume accepts a token for purpose exactly once.
+	Consume(token string, purpose models.TokenPurpose) (*models.OneTimeToken, error)
+}
+
+type oneTimeTokenService struct {
+	tokenRepo oneTimeTokenRepository.OneTimeTokenRepository
+}
+
+func NewOneTimeTokenService(tokenRepo oneTimeTokenRepository.OneTimeTokenRepository) OneTimeTokenService {
+	return &oneTimeTokenService{tokenRepo: tokenRepo}
+}
+
+func (s *oneTimeTokenService) Issue(userID uuid.UUID, purpose models.TokenPurpose, ttl time.Duration) (string, error) {
+	return s.IssueForDevice(userID, purpose, ttl, "")
+}
+
+func (s *oneTimeTokenService) IssueForDevice(userID uuid.UUID, purpose models.TokenPurpose, ttl time.Duration, deviceHash string) (string, error) {
+	token, tokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if err := s.tokenRepo.InvalidateUserTokens(userID, purpose, now); err != nil {
+		return "", err
+	}
+
+	record := &models.OneTimeToken{
+		UserID:     userID,
+		Purpose:    purpose,
+		TokenHash:  tokenHash,
+		ExpiresAt:  now.Add(ttl),
+		DeviceHash: deviceHash,
+	}
+	if err := s.tokenRepo.CreateToken(record); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *oneTimeTokenService) Lookup(token string, purpose models.TokenPurpose) (*models.OneTimeToken, error) {
+	record, err := s.tokenRepo.FindTokenByHash(auth.HashOpaqueToken(token), purpose)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrInvalidOneTimeToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if record.UsedAt != nil || !time.Now().Before(record.ExpiresAt) {
+		return nil, ErrInvalidOneTimeToken
+	}
+	return record, nil
+}
+
+func (s *oneTimeTokenService) Consume(token string, purpose models.TokenPurpose) (*models.OneTimeToken, error) {
+	record, err := s.Lookup(token, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	consumed, err := s.tokenRepo.ConsumeToken(record.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidOneTimeToken
+	}
+	return record, nil
+}
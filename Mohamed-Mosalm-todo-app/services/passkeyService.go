@@ -0,0 +1,400 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	passkeyRepository "github.com/MohamedMosalm/Todo-App/repositories/passkeyRepository"
+	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPasskeyCeremony = errors.New("passkey ceremony is invalid or has expired")
+	// ErrPasskeyRejected means the authenticator's answer did not check out:
+	// it is malformed, answers another challenge or origin, is signed by an
+	// unknown passkey or comes from what looks like a cloned authenticator.
+	ErrPasskeyRejected = errors.New("passkey was rejected")
+	ErrPasskeyNotFound = errors.New("passkey not found")
+	ErrNoPasskeys      = errors.New("no passkeys are registered")
+)
+
+type PasskeyService interface {
+	// BeginRegistration returns the options to create a passkey with and
+	// the ceremony token that must come back with the authenticator's
+	// answer. Passkeys the user has already are excluded.
+	BeginRegistration(user *models.User) (*protocol.CredentialCreation, string, error)
+	// FinishRegistration checks the authenticator's answer and stores the
+	// passkey under name.
+	FinishRegistration(user *models.User, ceremony, name string, response []byte) (*models.Passkey, error)
+	GetPasskeys(userID uuid.UUID) ([]models.Passkey, error)
+	HasPasskeys(userID uuid.UUID) (bool, error)
+	DeletePasskey(passkeyID, userID uuid.UUID) error
+
+	// BeginLogin challenges any passkey for a passwordless login; the
+	// authenticator tells whose it is. User verification is required, so a
+	// passkey is both factors at once.
+	BeginLogin() (*protocol.CredentialAssertion, string, error)
+	// FinishLogin checks the answer to BeginLogin and returns the passkey's
+	// user.
+	FinishLogin(ceremony string, response []byte) (*models.User, error)
+
+	// BeginSecondFactor challenges one of the user's passkeys after their
+	// password, or another first factor, checked out.
+	BeginSecondFactor(userID uuid.UUID) (*protocol.CredentialAssertion, string, error)
+	FinishSecondFactor(userID uuid.UUID, ceremony string, response []byte) error
+}
+
+type passkeyService struct {
+	relyingParty *webauthn.WebAuthn
+	passkeyRepo  passkeyRepository.PasskeyRepository
+	userRepo     userRepository.UserRepository
+	ceremonyTTL  time.Duration
+}
+
+// NewPasskeyService gives authenticators ceremonyTTL to answer a challenge.
+func NewPasskeyService(relyingParty *webauthn.WebAuthn, passkeyRepo passkeyRepository.PasskeyRepository, userRepo userRepository.UserRepository, ceremonyTTL time.Duration) PasskeyService {
+	return &passkeyService{relyingParty: relyingParty, passkeyRepo: passkeyRepo, userRepo: userRepo, ceremonyTTL: ceremonyTTL}
+}
+
+// passkeyUser presents a user and their passkeys to the WebAuthn library.
+// The user handle stored with each passkey is the user's ID, which gives
+// nothing about them away.
+type passkeyUser struct {
+	user     *models.User
+	passkeys []models.Passkey
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if name := strings.TrimSpace(u.user.FirstName + " " + u.user.LastName); name != "" {
+		return name
+	}
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(u.passkeys))
+	for i, passkey := range u.passkeys {
+		transports := make([]protocol.AuthenticatorTransport, len(passkey.Transports))
+		for j, transport := range passkey.Transports {
+			transports[j] = protocol.AuthenticatorTransport(transport)
+		}
+		credentials[i] = webauthn.Credential{
+			ID:              passkey.CredentialID,
+			PublicKey:       passkey.PublicKey,
+			AttestationType: passkey.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: passkey.BackupEligible,
+				BackupState:    passkey.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{AAGUID: passkey.AAGUID, SignCount: passkey.SignCount},
+		}
+	}
+	return credentials
+}
+
+func (u *passkeyUser) descriptors() []protocol.CredentialDescriptor {
+	descriptors := make([]protocol.CredentialDescriptor, len(u.passkeys))
+	for i, credential := range u.WebAuthnCredentials() {
+		descriptors[i] = credential.Descriptor()
+	}
+	return descriptors
+}
+
+// find returns the passkey the credential was verified with.
+func (u *passkeyUser) find(credential *webauthn.Credential) *models.Passkey {
+	for i := range u.passkeys {
+		if bytes.Equal(u.passkeys[i].CredentialID, credential.ID) {
+			return &u.passkeys[i]
+		}
+	}
+	return nil
+}
+
+func (s *passkeyService) loadUser(user *models.User) (*passkeyUser, error) {
+	passkeys, err := s.passkeyRepo.GetPasskeysByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &passkeyUser{user: user, passkeys: passkeys}, nil
+}
+
+// startCeremony stores the session of a ceremony that just began and
+// returns the token its answer must come with.
+func (s *passkeyService) startCeremony(purpose models.PasskeyCeremonyPurpose, userID *uuid.UUID, session *webauthn.SessionData) (string, error) {
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	token, tokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	// Ceremonies the authenticator never answered are never consumed.
+	if err := s.passkeyRepo.DeleteExpiredCeremonies(now); err != nil {
+		return "", err
+	}
+	if err := s.passkeyRepo.CreateCeremony(&models.PasskeyCeremony{
+		TokenHash:   tokenHash,
+		Purpose:     purpose,
+		UserID:      userID,
+		SessionData: string(sessionData),
+		ExpiresAt:   now.Add(s.ceremonyTTL),
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// finishCeremony consumes the ceremony, which must have been started for
+// userID, or for nobody if userID is nil.
+func (s *passkeyService) finishCeremony(purpose models.PasskeyCeremonyPurpose, userID *uuid.UUID, token string) (*webauthn.SessionData, error) {
+	ceremony, err := s.passkeyRepo.ConsumeCeremony(auth.HashOpaqueToken(token), purpose)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrInvalidPasskeyCeremony
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(ceremony.ExpiresAt) {
+		return nil, ErrInvalidPasskeyCeremony
+	}
+	if (userID == nil) != (ceremony.UserID == nil) || (userID != nil && *userID != *ceremony.UserID) {
+		return nil, ErrInvalidPasskeyCeremony
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(ceremony.SessionData), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *passkeyService) BeginRegistration(user *models.User) (*protocol.CredentialCreation, string, error) {
+	webAuthnUser, err := s.loadUser(user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	creation, session, err := s.relyingParty.BeginRegistration(webAuthnUser,
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		}),
+		webauthn.WithExclusions(webAuthnUser.descriptors()),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := s.startCeremony(models.PasskeyCeremonyRegistration, &user.ID, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return creation, token, nil
+}
+
+func (s *passkeyService) FinishRegistration(user *models.User, ceremony, name string, response []byte) (*models.Passkey, error) {
+	session, err := s.finishCeremony(models.PasskeyCeremonyRegistration, &user.ID, ceremony)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyRejected, err)
+	}
+	webAuthnUser, err := s.loadUser(user)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := s.relyingParty.CreateCredential(webAuthnUser, *session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyRejected, err)
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = fmt.Sprintf("Passkey %d", len(webAuthnUser.passkeys)+1)
+	}
+	transports := make(models.StringList, len(credential.Transport))
+	for i, transport := range credential.Transport {
+		transports[i] = string(transport)
+	}
+	passkey := &models.Passkey{
+		UserID:          user.ID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+	if err := s.passkeyRepo.CreatePasskey(passkey); err != nil {
+		return nil, err
+	}
+	return passkey, nil
+}
+
+func (s *passkeyService) GetPasskeys(userID uuid.UUID) ([]models.Passkey, error) {
+	return s.passkeyRepo.GetPasskeysByUserID(userID)
+}
+
+func (s *passkeyService) HasPasskeys(userID uuid.UUID) (bool, error) {
+	count, err := s.passkeyRepo.CountPasskeys(userID)
+	return count > 0, err
+}
+
+func (s *passkeyService) DeletePasskey(passkeyID, userID uuid.UUID) error {
+	deleted, err := s.passkeyRepo.DeletePasskey(passkeyID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+func (s *passkeyService) BeginLogin() (*protocol.CredentialAssertion, string, error) {
+	assertion, session, err := s.relyingParty.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := s.startCeremony(models.PasskeyCeremonyLogin, nil, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, token, nil
+}
+
+func (s *passkeyService) FinishLogin(ceremony string, response []byte) (*models.User, error) {
+	session, err := s.finishCeremony(models.PasskeyCeremonyLogin, nil, ceremony)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyRejected, err)
+	}
+
+	// The library only reports that the lookup failed, so failures other
+	// than an unknown user are kept to be returned as they are.
+	var webAuthnUser *passkeyUser
+	var lookupErr error
+	credential, err := s.relyingParty.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user, err := s.userRepo.FindUserByID(userID)
+		if err != nil {
+			if err != gorm.ErrRecordNotFound {
+				lookupErr = err
+			}
+			return nil, err
+		}
+		webAuthnUser, lookupErr = s.loadUser(user)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		return webAuthnUser, nil
+	}, *session, parsed)
+	if lookupErr != nil {
+		return nil, lookupErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyRejected, err)
+	}
+	if err := s.recordUse(webAuthnUser, credential); err != nil {
+		return nil, err
+	}
+	return webAuthnUser.user, nil
+}
+
+func (s *passkeyService) BeginSecondFactor(userID uuid.UUID) (*protocol.CredentialAssertion, string, error) {
+	user, err := s.userRepo.FindUserByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	webAuthnUser, err := s.loadUser(user)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(webAuthnUser.passkeys) == 0 {
+		return nil, "", ErrNoPasskeys
+	}
+
+	assertion, session, err := s.relyingParty.BeginLogin(webAuthnUser, webauthn.WithUserVerification(protocol.VerificationPreferred))
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := s.startCeremony(models.PasskeyCeremonySecondFactor, &userID, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, token, nil
+}
+
+func (s *passkeyService) FinishSecondFactor(userID uuid.UUID, ceremony string, response []byte) error {
+	session, err := s.finishCeremony(models.PasskeyCeremonySecondFactor, &userID, ceremony)
+	if err != nil {
+		return err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPasskeyRejected, err)
+	}
+	user, err := s.userRepo.FindUserByID(userID)
+	if err != nil {
+		return err
+	}
+	webAuthnUser, err := s.loadUser(user)
+	if err != nil {
+		return err
+	}
+	credential, err := s.relyingParty.ValidateLogin(webAuthnUser, *session, parsed)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPasskeyRejected, err)
+	}
+	return s.recordUse(webAuthnUser, credential)
+}
+
+// recordUse refuses logins whose signature counter did not go up, which
+// suggests the passkey's key was copied off its authenticator. Passkeys
+// that sync between devices always report zero and are never refused.
+func (s *passkeyService) recordUse(user *passkeyUser, credential *webauthn.Credential) error {
+	if credential.Authenticator.CloneWarning {
+		return fmt.Errorf("%w: signature counter went backwards", ErrPasskeyRejected)
+	}
+	passkey := user.find(credential)
+	if passkey == nil {
+		return ErrPasskeyRejected
+	}
+	return s.passkeyRepo.RecordPasskeyUse(passkey.ID, credential.Authenticator.SignCount, credential.Flags.BackupState, time.Now())
+}
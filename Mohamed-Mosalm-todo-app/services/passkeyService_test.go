@@ -0,0 +1,201 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/passkey"
+	"github.com/MohamedMosalm/Todo-App/utils/passkey/passkeytest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockPasskeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasskeyRepository) CreatePasskey(passkey *models.Passkey) error {
+	return m.Called(passkey).Error(0)
+}
+
+func (m *MockPasskeyRepository) GetPasskeysByUserID(userID uuid.UUID) ([]models.Passkey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Passkey), args.Error(1)
+}
+
+func (m *MockPasskeyRepository) CountPasskeys(userID uuid.UUID) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPasskeyRepository) RecordPasskeyUse(passkeyID uuid.UUID, signCount uint32, backupState bool, now time.Time) error {
+	return m.Called(passkeyID, signCount, backupState, now).Error(0)
+}
+
+func (m *MockPasskeyRepository) DeletePasskey(passkeyID, userID uuid.UUID) (bool, error) {
+	args := m.Called(passkeyID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasskeyRepository) CreateCeremony(ceremony *models.PasskeyCeremony) error {
+	return m.Called(ceremony).Error(0)
+}
+
+func (m *MockPasskeyRepository) ConsumeCeremony(tokenHash string, purpose models.PasskeyCeremonyPurpose) (*models.PasskeyCeremony, error) {
+	args := m.Called(tokenHash, purpose)
+	ceremony := args.Get(0)
+	if ceremony == nil {
+		return nil, args.Error(1)
+	}
+	return ceremony.(*models.PasskeyCeremony), args.Error(1)
+}
+
+func (m *MockPasskeyRepository) DeleteExpiredCeremonies(now time.Time) error {
+	return m.Called(now).Error(0)
+}
+
+type passkeyFixture struct {
+	service       PasskeyService
+	authenticator *passkeytest.Authenticator
+	passkeyRepo   *MockPasskeyRepository
+	userRepo      *MockUserRepository
+	user          *models.User
+	// ceremonies holds the ceremonies the service started, by token hash.
+	ceremonies map[string]*models.PasskeyCeremony
+}
+
+func newPasskeyFixture(t *testing.T) *passkeyFixture {
+	t.Helper()
+	relyingParty, err := passkey.NewRelyingParty(config.WebAuthnConfig{
+		RPID:          "todo.example.com",
+		RPDisplayName: "Todo App",
+		Origins:       []string{"https://todo.example.com"},
+	})
+	require.NoError(t, err)
+
+	f := &passkeyFixture{
+		authenticator: passkeytest.NewAuthenticator("https://todo.example.com"),
+		passkeyRepo:   new(MockPasskeyRepository),
+		userRepo:      new(MockUserRepository),
+		user:          &models.User{ID: uuid.New(), Email: "jane@example.com", FirstName: "Jane", LastName: "Doe"},
+		ceremonies:    map[string]*models.PasskeyCeremony{},
+	}
+	f.service = NewPasskeyService(relyingParty, f.passkeyRepo, f.userRepo, 5*time.Minute)
+
+	f.userRepo.On("FindUserByID", f.user.ID).Return(f.user, nil)
+	f.passkeyRepo.On("DeleteExpiredCeremonies", mock.Anything).Return(nil)
+	f.passkeyRepo.On("CreateCeremony", mock.AnythingOfType("*models.PasskeyCeremony")).Return(nil).Run(func(args mock.Arguments) {
+		ceremony := args.Get(0).(*models.PasskeyCeremony)
+		f.ceremonies[ceremony.TokenHash] = ceremony
+	})
+	return f
+}
+
+// expectCeremony lets the ceremony started with token be consumed once.
+func (f *passkeyFixture) expectCeremony(t *testing.T, token string, purpose models.PasskeyCeremonyPurpose) {
+	t.Helper()
+	stored := f.ceremonies[auth.HashOpaqueToken(token)]
+	require.NotNil(t, stored, "only a hash of the ceremony token is stored")
+	f.passkeyRepo.On("ConsumeCeremony", auth.HashOpaqueToken(token), purpose).Return(stored, nil).Once()
+}
+
+// register runs a registration ceremony with the fixture's authenticator
+// and returns the stored passkey.
+func (f *passkeyFixture) register(t *testing.T) *models.Passkey {
+	t.Helper()
+	f.passkeyRepo.On("GetPasskeysByUserID", f.user.ID).Return([]models.Passkey{}, nil).Twice()
+	var stored *models.Passkey
+	f.passkeyRepo.On("CreatePasskey", mock.AnythingOfType("*models.Passkey")).Return(nil).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*models.Passkey)
+		stored.ID = uuid.New()
+	}).Once()
+
+	creation, ceremony, err := f.service.BeginRegistration(f.user)
+	require.NoError(t, err)
+	response, err := f.authenticator.Create(creation)
+	require.NoError(t, err)
+	f.expectCeremony(t, ceremony, models.PasskeyCeremonyRegistration)
+
+	passkey, err := f.service.FinishRegistration(f.user, ceremony, "  ", response)
+	require.NoError(t, err)
+	assert.Same(t, stored, passkey)
+	return passkey
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	f := newPasskeyFixture(t)
+	passkey := f.register(t)
+	assert.Equal(t, "Passkey 1", passkey.Name)
+	assert.Equal(t, models.StringList{"internal"}, passkey.Transports)
+	f.passkeyRepo.On("GetPasskeysByUserID", f.user.ID).Return([]models.Passkey{*passkey}, nil)
+
+	assertion, ceremony, err := f.service.BeginLogin()
+	require.NoError(t, err)
+	assert.Empty(t, assertion.Response.AllowedCredentials, "the authenticator says whose passkey it is")
+	response, err := f.authenticator.Get(assertion)
+	require.NoError(t, err)
+	f.expectCeremony(t, ceremony, models.PasskeyCeremonyLogin)
+	f.passkeyRepo.On("RecordPasskeyUse", passkey.ID, uint32(1), false, mock.Anything).Return(nil).Once()
+
+	user, err := f.service.FinishLogin(ceremony, response)
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID, user.ID)
+
+	// Each challenge is answered once.
+	f.passkeyRepo.On("ConsumeCeremony", auth.HashOpaqueToken(ceremony), models.PasskeyCeremonyLogin).Return(nil, gorm.ErrRecordNotFound)
+	_, err = f.service.FinishLogin(ceremony, response)
+	assert.Equal(t, ErrInvalidPasskeyCeremony, err)
+	f.passkeyRepo.AssertExpectations(t)
+}
+
+func TestPasskeySecondFactor(t *testing.T) {
+	f := newPasskeyFixture(t)
+	passkey := f.register(t)
+	passkey.SignCount = 1
+	f.passkeyRepo.On("GetPasskeysByUserID", f.user.ID).Return([]models.Passkey{*passkey}, nil)
+
+	assertion, ceremony, err := f.service.BeginSecondFactor(f.user.ID)
+	require.NoError(t, err)
+	require.Len(t, assertion.Response.AllowedCredentials, 1)
+	assert.Equal(t, passkey.CredentialID, []byte(assertion.Response.AllowedCredentials[0].CredentialID))
+	response, err := f.authenticator.Get(assertion)
+	require.NoError(t, err)
+
+	// The ceremony belongs to the user it was started for.
+	f.expectCeremony(t, ceremony, models.PasskeyCeremonySecondFactor)
+	assert.Equal(t, ErrInvalidPasskeyCeremony, f.service.FinishSecondFactor(uuid.New(), ceremony, response))
+
+	f.expectCeremony(t, ceremony, models.PasskeyCeremonySecondFactor)
+	assert.ErrorIs(t, f.service.FinishSecondFactor(f.user.ID, ceremony, response), ErrPasskeyRejected, "the counter did not go up, as if the key were cloned")
+	f.passkeyRepo.AssertNotCalled(t, "RecordPasskeyUse", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	assertion, ceremony, err = f.service.BeginSecondFactor(f.user.ID)
+	require.NoError(t, err)
+	response, err = f.authenticator.Get(assertion)
+	require.NoError(t, err)
+	f.expectCeremony(t, ceremony, models.PasskeyCeremonySecondFactor)
+	f.passkeyRepo.On("RecordPasskeyUse", passkey.ID, uint32(2), false, mock.Anything).Return(nil).Once()
+	require.NoError(t, f.service.FinishSecondFactor(f.user.ID, ceremony, response))
+}
+
+func TestPasskeyRegistrationChecksOrigin(t *testing.T) {
+	f := newPasskeyFixture(t)
+	f.authenticator.Origin = "https://todo.example.com.evil.test"
+	f.passkeyRepo.On("GetPasskeysByUserID", f.user.ID).Return([]models.Passkey{}, nil)
+
+	creation, ceremony, err := f.service.BeginRegistration(f.user)
+	require.NoError(t, err)
+	response, err := f.authenticator.Create(creation)
+	require.NoError(t, err)
+	f.expectCeremony(t, ceremony, models.PasskeyCeremonyRegistration)
+
+	_, err = f.service.FinishRegistration(f.user, ceremony, "Laptop", response)
+	assert.ErrorIs(t, err, ErrPasskeyRejected)
+	f.passkeyRepo.AssertNotCalled(t, "CreatePasskey", mock.Anything)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/mailer"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PasswordResetService interface {
+	// RequestReset emails a reset link to the account registered under
+	// email. Unknown addresses are not an error, so callers cannot tell
+	// whether an account exists.
+	RequestReset(ctx context.Context, email string) error
+	// ResetPassword sets a new password using an emailed token and ends
+	// every session of the account. A password the policy rejects leaves
+	// the token usable for another try.
+	ResetPassword(token, newPassword string) error
+	// ForceReset is an admin's reset: the user's password stops working,
+	// their sessions end, and they are emailed a link to choose a new one.
+	ForceReset(ctx context.Context, userID uuid.UUID) error
+}
+
+type passwordResetService struct {
+	userRepo        userRepository.UserRepository
+	tokenService    OneTimeTokenService
+	sessionService  SessionService
+	passwordService *auth.PasswordService
+	passwordPolicy  *auth.PasswordPolicy
+	mailer          mailer.Mailer
+	baseURL         string
+	ttl             time.Duration
+}
+
+func NewPasswordResetService(userRepo userRepository.UserRepository, tokenService OneTimeTokenService, sessionService SessionService, passwordService *auth.PasswordService, passwordPolicy *auth.PasswordPolicy, mailer mailer.Mailer, baseURL string, ttl time.Duration) PasswordResetService {
+	return &passwordResetService{
+		userRepo:        userRepo,
+		tokenService:    tokenService,
+		sessionService:  sessionService,
+		passwordService: passwordService,
+		passwordPolicy:  passwordPolicy,
+		mailer:          mailer,
+		baseURL:         baseURL,
+		ttl:             ttl,
+	}
+}
+
+func (s *passwordResetService) RequestReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindUserByEmail(email)
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.emailResetLink(ctx, user, "Reset your password",
+		"Someone asked to reset the password of your account. If it was you, open the link below within %s to choose a new password:",
+		"If you did not ask for this, you can ignore this email; your password stays the same.")
+}
+
+func (s *passwordResetService) ForceReset(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.FindUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	// An empty hash matches no password.
+	if err := s.userRepo.UpdatePassword(user.ID, ""); err != nil {
+		return err
+	}
+	if err := s.sessionService.RevokeAllSessions(user.ID); err != nil {
+		return err
+	}
+
+	return s.emailResetLink(ctx, user, "Choose a new password",
+		"An administrator has reset the password of your account and signed you out. Open the link below within %s to choose a new password:",
+		`If the link expires, ask for a new one with "Forgot password".`)
+}
+
+// emailResetLink issues a reset token and emails its link between intro,
+// which is given the link's lifetime, and outro.
+func (s *passwordResetService) emailResetLink(ctx context.Context, user *models.User, subject, intro, outro string) error {
+	token, err := s.tokenService.Issue(user.ID, models.TokenPurposePasswordReset, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Hi %s,\n\n"+intro+"\n\n%s\n\n"+outro+"\n", user.FirstName, s.ttl, link),
+	})
+}
+
+func (s *passwordResetService) ResetPassword(token, newPassword string) error {
+	record, err := s.tokenService.Lookup(token, models.TokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindUserByID(record.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.passwordPolicy.Validate(newPassword, user.Email, user.FirstName, user.LastName); err != nil {
+		return err
+	}
+	hashedPassword, err := s.passwordService.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.tokenService.Consume(token, models.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(record.UserID, hashedPassword); err != nil {
+		return err
+	}
+
+	return s.sessionService.RevokeAllSessions(record.UserID)
+}
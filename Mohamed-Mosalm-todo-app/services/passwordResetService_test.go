@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/MohamedMosalm/Todo-App/models"
+	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/MohamedMosalm/Todo-App/utils/mailer"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) CreateUser(user *models.User) error {
+	return m.Called(user).Error(0)
+}
+
+func (m *MockUserRepository) FindUserByEmail(email string) (*models.User, error) {
+	args := m.Called(email)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserByID(id uuid.UUID) (*models.User, error) {
+	args := m.Called(id)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(id uuid.UUID, hashedPassword string) error {
+	return m.Called(id, hashedPassword).Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(id uuid.UUID, verifiedAt time.Time) error {
+	return m.Called(id, verifiedAt).Error(0)
+}
+
+func (m *MockUserRepository) UpdateUser(id uuid.UUID, updates map[string]interface{}) error {
+	return m.Called(id, updates).Error(0)
+}
+
+func (m *MockUserRepository) FindUsers(criteria userRepository.UserCriteria) ([]models.User, int64, error) {
+	args := m.Called(criteria)
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+type MockOneTimeTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockOneTimeTokenRepository) CreateToken(token *models.OneTimeToken) error {
+	return m.Called(token).Error(0)
+}
+
+func (m *MockOneTimeTokenRepository) FindTokenByHash(tokenHash string, purpose models.TokenPurpose) (*models.OneTimeToken, error) {
+	args := m.Called(tokenHash, purpose)
+	token := args.Get(0)
+	if token == nil {
+		return nil, args.Error(1)
+	}
+	return token.(*models.OneTimeToken), args.Error(1)
+}
+
+func (m *MockOneTimeTokenRepository) ConsumeToken(tokenID uuid.UUID, now time.Time) (bool, error) {
+	args := m.Called(tokenID, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOneTimeTokenRepository) InvalidateUserTokens(userID uuid.UUID, purpose models.TokenPurpose, now time.Time) error {
+	return m.Called(userID, purpose, now).Error(0)
+}
+
+// recordingMailer keeps sent messages for inspection.
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type passwordResetFixture struct {
+	service   PasswordResetService
+	userRepo  *MockUserRepository
+	tokenRepo *MockOneTimeTokenRepository
+	sessions  *MockSessionRepository
+	mailer    *recordingMailer
+}
+
+func newTestPasswordPolicy() *auth.PasswordPolicy {
+	policy, _ := auth.NewPasswordPolicy(config.PasswordConfig{MinLength: 8, MaxLength: 72, MinStrength: 2})
+	return policy
+}
+
+func newPasswordResetFixture() *passwordResetFixture {
+	f := &passwordResetFixture{
+		userRepo:  new(MockUserRepository),
+		tokenRepo: new(MockOneTimeTokenRepository),
+		sessions:  new(MockSessionRepository),
+		mailer:    &recordingMailer{},
+	}
+	f.service = NewPasswordResetService(f.userRepo, NewOneTimeTokenService(f.tokenRepo), NewSessionService(f.sessions, time.Minute),
+		auth.NewPasswordService(auth.NewBcryptHasher(bcrypt.MinCost)), newTestPasswordPolicy(), f.mailer, "https://todo.example.com", time.Hour)
+	return f
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	f := newPasswordResetFixture()
+	user := &models.User{ID: uuid.New(), Email: "jane@example.com", FirstName: "Jane"}
+	f.userRepo.On("FindUserByEmail", user.Email).Return(user, nil)
+	f.tokenRepo.On("InvalidateUserTokens", user.ID, models.TokenPurposePasswordReset, mock.Anything).Return(nil)
+	f.tokenRepo.On("CreateToken", mock.AnythingOfType("*models.OneTimeToken")).Return(nil)
+
+	assert.NoError(t, f.service.RequestReset(context.Background(), user.Email))
+
+	assert.Len(t, f.mailer.sent, 1)
+	assert.Equal(t, user.Email, f.mailer.sent[0].To)
+	link := regexp.MustCompile(`https://todo\.example\.com/reset-password\?token=(\S+)`).FindStringSubmatch(f.mailer.sent[0].Body)
+	assert.NotNil(t, link)
+
+	token, _ := url.QueryUnescape(link[1])
+	stored := f.tokenRepo.Calls[1].Arguments.Get(0).(*models.OneTimeToken)
+	assert.Equal(t, auth.HashOpaqueToken(token), stored.TokenHash, "only the hash is stored")
+	assert.Equal(t, models.TokenPurposePasswordReset, stored.Purpose)
+}
+
+func TestRequestPasswordResetForUnknownEmail(t *testing.T) {
+	f := newPasswordResetFixture()
+	f.userRepo.On("FindUserByEmail", "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	assert.NoError(t, f.service.RequestReset(context.Background(), "nobody@example.com"))
+	assert.Empty(t, f.mailer.sent)
+}
+
+func TestForcePasswordReset(t *testing.T) {
+	f := newPasswordResetFixture()
+	user := &models.User{ID: uuid.New(), Email: "jane@example.com", FirstName: "Jane", Password: "hash"}
+	f.userRepo.On("FindUserByID", user.ID).Return(user, nil)
+	f.userRepo.On("UpdatePassword", user.ID, "").Return(nil).Once()
+	f.sessions.On("RevokeUserSessions", user.ID, mock.Anything).Return(nil).Once()
+	f.tokenRepo.On("InvalidateUserTokens", user.ID, models.TokenPurposePasswordReset, mock.Anything).Return(nil)
+	f.tokenRepo.On("CreateToken", mock.AnythingOfType("*models.OneTimeToken")).Return(nil)
+
+	assert.NoError(t, f.service.ForceReset(context.Background(), user.ID))
+
+	assert.Len(t, f.mailer.sent, 1)
+	assert.Contains(t, f.mailer.sent[0].Body, "An administrator has reset the password")
+	assert.Regexp(t, `https://todo\.example\.com/reset-password\?token=\S+`, f.mailer.sent[0].Body)
+	f.userRepo.AssertExpectations(t)
+	f.sessions.AssertExpectations(t)
+}
+
+func TestResetPassword(t *testing.T) {
+	f := newPasswordResetFixture()
+	record := &models.OneTimeToken{ID: uuid.New(), UserID: uuid.New(), Purpose: models.TokenPurposePasswordReset, ExpiresAt: time.Now().Add(time.Hour)}
+	f.tokenRepo.On("FindTokenByHash", auth.HashOpaqueToken("token"), models.TokenPurposePasswordReset).Return(record, nil)
+	f.userRepo.On("FindUserByID", record.UserID).Return(&models.User{ID: record.UserID, Email: "jane@example.com"}, nil)
+	f.tokenRepo.On("ConsumeToken", record.ID, mock.Anything).Return(true, nil).Once()
+	f.tokenRepo.On("ConsumeToken", record.ID, mock.Anything).Return(false, nil)
+	f.userRepo.On("UpdatePassword", record.UserID, mock.AnythingOfType("string")).Return(nil).Once()
+	f.sessions.On("RevokeUserSessions", record.UserID, mock.Anything).Return(nil).Once()
+
+	assert.NoError(t, f.service.ResetPassword("token", "plum-orbit-canvas"))
+	assert.Equal(t, ErrInvalidOneTimeToken, f.service.ResetPassword("token", "quiet-harbor-lamp"), "tokens are single-use")
+
+	hashed := f.userRepo.Calls[1].Arguments.String(1)
+	assert.NoError(t, auth.NewPasswordService(auth.NewBcryptHasher(bcrypt.MinCost)).ComparePasswords(hashed, "plum-orbit-canvas"))
+	f.userRepo.AssertExpectations(t)
+	f.sessions.AssertExpectations(t)
+}
+
+func TestResetPasswordEnforcesPolicy(t *testing.T) {
+	f := newPasswordResetFixture()
+	record := &models.OneTimeToken{ID: uuid.New(), UserID: uuid.New(), Purpose: models.TokenPurposePasswordReset, ExpiresAt: time.Now().Add(time.Hour)}
+	f.tokenRepo.On("FindTokenByHash", auth.HashOpaqueToken("token"), models.TokenPurposePasswordReset).Return(record, nil)
+	f.userRepo.On("FindUserByID", record.UserID).Return(&models.User{ID: record.UserID, Email: "jane.doe@example.com", FirstName: "Jane"}, nil)
+
+	err := f.service.ResetPassword("token", "jane.doe2024")
+	policyErr, ok := err.(*auth.PasswordPolicyError)
+	assert.True(t, ok, "got %v", err)
+	assert.Contains(t, policyErr.Reasons, "must not contain your email address or name")
+	f.tokenRepo.AssertNotCalled(t, "ConsumeToken", mock.Anything, mock.Anything)
+	f.userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+}
+
+func TestResetPasswordWithExpiredToken(t *testing.T) {
+	f := newPasswordResetFixture()
+	record := &models.OneTimeToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+	f.tokenRepo.On("FindTokenByHash", auth.HashOpaqueToken("token"), models.TokenPurposePasswordReset).Return(record, nil)
+
+	assert.Equal(t, ErrInvalidOneTimeToken, f.service.ResetPassword("token", "new-password"))
+	f.userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+}
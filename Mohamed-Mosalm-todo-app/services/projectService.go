@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	projectRepository "github.com/MohamedMosalm/Todo-App/repositories/projectRepository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWIPLimitExceeded = errors.New("column work-in-progress limit reached")
+	ErrUnknownState     = errors.New("state does not match any board column")
+	ErrDuplicateState   = errors.New("board columns must have unique states")
+	ErrColumnNotEmpty   = errors.New("cannot remove a column that still holds tasks")
+	ErrTaskNotInProject = errors.New("task does not belong to this project")
+)
+
+type ProjectService interface {
+	CreateProject(project *models.Project) error
+	GetProjectsByUserID(userID uuid.UUID) ([]models.Project, error)
+	GetProjectByID(projectID uuid.UUID) (*models.Project, error)
+	UpdateColumns(project *models.Project, columns []models.BoardColumn) error
+	UpdateFields(project *models.Project, fields []models.CustomField) error
+	GetProjectTasks(projectID uuid.UUID) ([]models.Task, error)
+	MoveTask(project *models.Project, task *models.Task, state string, position int, force bool) error
+}
+
+type projectService struct {
+	projectRepo projectRepository.ProjectRepository
+}
+
+func NewProjectService(projectRepo projectRepository.ProjectRepository) ProjectService {
+	return &projectService{projectRepo: projectRepo}
+}
+
+func (s *projectService) CreateProject(project *models.Project) error {
+	if len(project.Columns) == 0 {
+		project.Columns = models.DefaultBoardColumns()
+	}
+	if !uniqueStates(project.Columns) {
+		return ErrDuplicateState
+	}
+	return s.projectRepo.CreateProject(project)
+}
+
+func (s *projectService) GetProjectsByUserID(userID uuid.UUID) ([]models.Project, error) {
+	return s.projectRepo.GetProjectsByUserID(userID)
+}
+
+func (s *projectService) GetProjectByID(projectID uuid.UUID) (*models.Project, error) {
+	return s.projectRepo.GetProjectByID(projectID)
+}
+
+// UpdateColumns replaces the board layout of a project. Columns are ordered as
+// given and a state may only be dropped once no task is left in it.
+func (s *projectService) UpdateColumns(project *models.Project, columns []models.BoardColumn) error {
+	if !uniqueStates(columns) {
+		return ErrDuplicateState
+	}
+
+	for i := range columns {
+		columns[i].Position = i
+	}
+
+	// The repository checks for tasks left in dropped states while holding
+	// the board, so no move can slip into one.
+	replaced, err := s.projectRepo.ReplaceColumns(project.ID, columns)
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		return ErrColumnNotEmpty
+	}
+	project.Columns = columns
+	return nil
+}
+
+// UpdateFields replaces the custom field schema of a project. Values stored
+// on tasks for removed fields are left in place and dropped on their next
+// update.
+func (s *projectService) UpdateFields(project *models.Project, fields []models.CustomField) error {
+	if err := validateFieldSchema(fields); err != nil {
+		return err
+	}
+	if err := s.projectRepo.ReplaceFields(project.ID, fields); err != nil {
+		return err
+	}
+	project.Fields = fields
+	return nil
+}
+
+func (s *projectService) GetProjectTasks(projectID uuid.UUID) ([]models.Task, error) {
+	return s.projectRepo.GetProjectTasks(projectID)
+}
+
+// MoveTask moves a task into the column for state. Moves into a column that
+// is at its WIP limit are refused unless force is set; reordering within the
+// same column never counts against the limit.
+func (s *projectService) MoveTask(project *models.Project, task *models.Task, state string, position int, force bool) error {
+	if task.ProjectID == nil || *task.ProjectID != project.ID {
+		return ErrTaskNotInProject
+	}
+
+	column := findColumn(project.Columns, state)
+	if column == nil {
+		return ErrUnknownState
+	}
+
+	wipLimit := column.WIPLimit
+	if force {
+		wipLimit = 0
+	}
+	moved, err := s.projectRepo.MoveTask(task, state, position, column.IsDone, wipLimit, nil)
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return ErrWIPLimitExceeded
+	}
+	return nil
+}
+
+func findColumn(columns []models.BoardColumn, state string) *models.BoardColumn {
+	for i := range columns {
+		if columns[i].State == state {
+			return &columns[i]
+		}
+	}
+	return nil
+}
+
+func uniqueStates(columns []models.BoardColumn) bool {
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if seen[column.State] {
+			return false
+		}
+		seen[column.State] = true
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockProjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepository) CreateProject(project *models.Project) error {
+	return m.Called(project).Error(0)
+}
+
+func (m *MockProjectRepository) GetProjectsByUserID(userID uuid.UUID) ([]models.Project, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) GetProjectByID(projectID uuid.UUID) (*models.Project, error) {
+	args := m.Called(projectID)
+	project := args.Get(0)
+	if project == nil {
+		return nil, args.Error(1)
+	}
+	return project.(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) ReplaceColumns(projectID uuid.UUID, columns []models.BoardColumn) (bool, error) {
+	args := m.Called(projectID, columns)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockProjectRepository) ReplaceFields(projectID uuid.UUID, fields []models.CustomField) error {
+	return m.Called(projectID, fields).Error(0)
+}
+
+func (m *MockProjectRepository) GetProjectTasks(projectID uuid.UUID) ([]models.Task, error) {
+	args := m.Called(projectID)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+func (m *MockProjectRepository) CreateTaskInColumn(task *models.Task, wipLimit int) (bool, error) {
+	args := m.Called(task, wipLimit)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockProjectRepository) MoveTask(task *models.Task, state string, position int, done bool, wipLimit int, updates map[string]interface{}) (bool, error) {
+	args := m.Called(task, state, position, done, wipLimit, updates)
+	return args.Bool(0), args.Error(1)
+}
+
+// newBoardProject returns a project whose "todo" and "doing" columns hold
+// at most two tasks.
+func newBoardProject() *models.Project {
+	return &models.Project{
+		ID:     uuid.New(),
+		UserID: uuid.New(),
+		Columns: []models.BoardColumn{
+			{State: "todo", Name: "To Do", WIPLimit: 2},
+			{State: "doing", Name: "Doing", WIPLimit: 2},
+			{State: "done", Name: "Done", IsDone: true},
+		},
+	}
+}
+
+func TestMoveTaskWIPLimit(t *testing.T) {
+	projectRepo := new(MockProjectRepository)
+	service := NewProjectService(projectRepo)
+	project := newBoardProject()
+	task := &models.Task{ID: uuid.New(), ProjectID: &project.ID, State: "todo"}
+
+	// The repository counts the column in the same transaction as the move.
+	projectRepo.On("MoveTask", task, "doing", -1, false, 2, map[string]interface{}(nil)).Return(false, nil).Once()
+	assert.Equal(t, ErrWIPLimitExceeded, service.MoveTask(project, task, "doing", -1, false))
+
+	projectRepo.On("MoveTask", task, "doing", -1, false, 0, map[string]interface{}(nil)).Return(true, nil).Once()
+	require.NoError(t, service.MoveTask(project, task, "doing", -1, true))
+	projectRepo.AssertExpectations(t)
+}
+
+func TestCreateTaskRespectsWIPLimit(t *testing.T) {
+	projectRepo := new(MockProjectRepository)
+	service := NewTaskService(new(MockTaskRepository), projectRepo, nil)
+	project := newBoardProject()
+	projectRepo.On("GetProjectByID", project.ID).Return(project, nil)
+
+	task := &models.Task{Title: "Write docs", UserID: project.UserID, ProjectID: &project.ID}
+	projectRepo.On("CreateTaskInColumn", task, 2).Return(false, nil).Once()
+	assert.Equal(t, ErrWIPLimitExceeded, service.CreateTask(task))
+	assert.Equal(t, "todo", task.State)
+
+	projectRepo.On("CreateTaskInColumn", task, 2).Return(true, nil).Once()
+	require.NoError(t, service.CreateTask(task))
+	projectRepo.AssertExpectations(t)
+}
+
+func TestUpdateTaskStatusMovesBoardTask(t *testing.T) {
+	taskRepo := new(MockTaskRepository)
+	projectRepo := new(MockProjectRepository)
+	service := NewTaskService(taskRepo, projectRepo, nil)
+	project := newBoardProject()
+	projectRepo.On("GetProjectByID", project.ID).Return(project, nil)
+
+	task := &models.Task{ID: uuid.New(), UserID: project.UserID, ProjectID: &project.ID, State: "doing"}
+	taskRepo.On("GetTaskByID", task.ID).Return(task, nil)
+	// The rest of the update is written in the same transaction as the move.
+	projectRepo.On("MoveTask", task, "done", -1, true, 0, map[string]interface{}{"title": "Shipped"}).Return(true, nil).Once()
+	require.NoError(t, service.UpdateTask(task.ID, map[string]interface{}{"title": "Shipped", "status": true}))
+	taskRepo.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+
+	// Reopening goes to the first column that is not done, within its limit.
+	task.State, task.Status = "done", true
+	projectRepo.On("MoveTask", task, "todo", -1, false, 2, map[string]interface{}{}).Return(false, nil).Once()
+	assert.Equal(t, ErrWIPLimitExceeded, service.UpdateTask(task.ID, map[string]interface{}{"status": false}))
+
+	// A board without a done column cannot hold a finished task.
+	project.Columns = project.Columns[:2]
+	task.State, task.Status = "doing", false
+	assert.Equal(t, ErrNoColumnForStatus, service.UpdateTask(task.ID, map[string]interface{}{"status": true}))
+	taskRepo.AssertExpectations(t)
+	projectRepo.AssertExpectations(t)
+}
+
+func TestUpdateColumnsKeepsTasksOnTheBoard(t *testing.T) {
+	projectRepo := new(MockProjectRepository)
+	service := NewProjectService(projectRepo)
+	project := newBoardProject()
+
+	// Dropping "doing" while it holds tasks is refused by the repository.
+	columns := []models.BoardColumn{project.Columns[0], project.Columns[2]}
+	projectRepo.On("ReplaceColumns", project.ID, columns).Return(false, nil).Once()
+	assert.Equal(t, ErrColumnNotEmpty, service.UpdateColumns(project, columns))
+	assert.Len(t, project.Columns, 3)
+
+	projectRepo.On("ReplaceColumns", project.ID, columns).Return(true, nil).Once()
+	require.NoError(t, service.UpdateColumns(project, columns))
+	assert.Equal(t, columns, project.Columns)
+	assert.Equal(t, 1, project.Columns[1].Position)
+	projectRepo.AssertExpectations(t)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	refreshTokenRepository "github.com/MohamedMosalm/Todo-App/repositories/refreshTokenRepository"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+)
+
+type RefreshTokenService interface {
+	// Issue returns the first token of the family for a new session.
+	Issue(userID, sessionID uuid.UUID) (string, error)
+	// Rotate exchanges a refresh token for its successor. Presenting a token
+	// that was already rotated or revoked revokes its whole family.
+	Rotate(token string) (string, *models.RefreshToken, error)
+}
+
+type refreshTokenService struct {
+	refreshTokenRepo refreshTokenRepository.RefreshTokenRepository
+	ttl              time.Duration
+}
+
+func NewRefreshTokenService(refreshTokenRepo refreshTokenRepository.RefreshTokenRepository, ttl time.Duration) RefreshTokenService {
+	return &refreshTokenService{refreshTokenRepo: refreshTokenRepo, ttl: ttl}
+}
+
+func (s *refreshTokenService) Issue(userID, sessionID uuid.UUID) (string, error) {
+	token, record, err := s.newToken(userID, sessionID, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if err := s.refreshTokenRepo.CreateToken(record); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *refreshTokenService) Rotate(token string) (string, *models.RefreshToken, error) {
+	current, err := s.refreshTokenRepo.FindTokenByHash(auth.HashOpaqueToken(token))
+	if err == gorm.ErrRecordNotFound {
+		return "", nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	if current.UsedAt != nil {
+		return "", nil, s.revokeReused(current, now)
+	}
+	if current.RevokedAt != nil || !now.Before(current.ExpiresAt) {
+		return "", nil, ErrInvalidRefreshToken
+	}
+
+	next, record, err := s.newToken(current.UserID, current.FamilyID, now)
+	if err != nil {
+		return "", nil, err
+	}
+	rotated, err := s.refreshTokenRepo.RotateToken(current, record, now)
+	if err != nil {
+		return "", nil, err
+	}
+	if !rotated {
+		// Another request rotated the token between the lookup and the
+		// update, which is the same replay seen from the other side.
+		return "", nil, s.revokeReused(current, now)
+	}
+	return next, record, nil
+}
+
+func (s *refreshTokenService) revokeReused(token *models.RefreshToken, now time.Time) error {
+	if err := s.refreshTokenRepo.RevokeFamily(token.FamilyID, now); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *refreshTokenService) newToken(userID, familyID uuid.UUID, now time.Time) (string, *models.RefreshToken, error) {
+	token, tokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(s.ttl),
+	}, nil
+}
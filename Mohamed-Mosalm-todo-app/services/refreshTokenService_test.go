@@ -0,0 +1,104 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) CreateToken(token *models.RefreshToken) error {
+	return m.Called(token).Error(0)
+}
+
+func (m *MockRefreshTokenRepository) FindTokenByHash(tokenHash string) (*models.RefreshToken, error) {
+	args := m.Called(tokenHash)
+	token := args.Get(0)
+	if token == nil {
+		return nil, args.Error(1)
+	}
+	return token.(*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RotateToken(current, next *models.RefreshToken, now time.Time) (bool, error) {
+	args := m.Called(current, next, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(familyID uuid.UUID, now time.Time) error {
+	return m.Called(familyID, now).Error(0)
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	service := NewRefreshTokenService(repo, time.Hour)
+
+	current := &models.RefreshToken{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	repo.On("FindTokenByHash", auth.HashOpaqueToken("current")).Return(current, nil)
+	repo.On("RotateToken", current, mock.AnythingOfType("*models.RefreshToken"), mock.AnythingOfType("time.Time")).Return(true, nil)
+
+	next, record, err := service.Rotate("current")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "current", next)
+	assert.Equal(t, current.UserID, record.UserID)
+	assert.Equal(t, current.FamilyID, record.FamilyID)
+	assert.Equal(t, auth.HashOpaqueToken(next), record.TokenHash)
+	repo.AssertExpectations(t)
+}
+
+func TestRotateReusedRefreshTokenRevokesFamily(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	service := NewRefreshTokenService(repo, time.Hour)
+
+	usedAt := time.Now().Add(-time.Minute)
+	used := &models.RefreshToken{ID: uuid.New(), FamilyID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+	repo.On("FindTokenByHash", auth.HashOpaqueToken("used")).Return(used, nil)
+	repo.On("RevokeFamily", used.FamilyID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	_, _, err := service.Rotate("used")
+	assert.Equal(t, ErrRefreshTokenReused, err)
+	repo.AssertExpectations(t)
+}
+
+func TestRotateRefreshTokenLosingRaceRevokesFamily(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	service := NewRefreshTokenService(repo, time.Hour)
+
+	current := &models.RefreshToken{ID: uuid.New(), FamilyID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	repo.On("FindTokenByHash", auth.HashOpaqueToken("current")).Return(current, nil)
+	repo.On("RotateToken", current, mock.Anything, mock.Anything).Return(false, nil)
+	repo.On("RevokeFamily", current.FamilyID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	_, _, err := service.Rotate("current")
+	assert.Equal(t, ErrRefreshTokenReused, err)
+	repo.AssertExpectations(t)
+}
+
+func TestRotateExpiredRevokedOrUnknownRefreshToken(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	service := NewRefreshTokenService(repo, time.Hour)
+
+	expired := &models.RefreshToken{ID: uuid.New(), FamilyID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+	revokedAt := time.Now().Add(-time.Minute)
+	revoked := &models.RefreshToken{ID: uuid.New(), FamilyID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}
+	repo.On("FindTokenByHash", auth.HashOpaqueToken("expired")).Return(expired, nil)
+	repo.On("FindTokenByHash", auth.HashOpaqueToken("revoked")).Return(revoked, nil)
+	repo.On("FindTokenByHash", auth.HashOpaqueToken("unknown")).Return(nil, gorm.ErrRecordNotFound)
+
+	_, _, err := service.Rotate("expired")
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+	_, _, err = service.Rotate("revoked")
+	assert.Equal(t, ErrInvalidRefreshToken, err, "tokens of a logged out session are not treated as theft")
+	_, _, err = service.Rotate("unknown")
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+	repo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	roleRepository "github.com/MohamedMosalm/Todo-App/repositories/roleRepository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("role already exists")
+	ErrInvalidRoleName   = errors.New("role names are 1 to 32 lowercase letters, digits, - or _, starting with a letter")
+	ErrBuiltInRole       = errors.New("built-in roles cannot be changed")
+	ErrRoleInUse         = errors.New("role is still assigned to users")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+type RoleService interface {
+	// ListRoles returns the built-in roles followed by the custom ones.
+	ListRoles() ([]models.Role, error)
+	GetRole(name string) (*models.Role, error)
+	CreateRole(name, description string, permissions []string) (*models.Role, error)
+	UpdateRole(name, description string, permissions []string) (*models.Role, error)
+	// DeleteRole deletes a custom role nobody has any more.
+	DeleteRole(name string) error
+	// HasPermissions reports whether role grants every one of permissions.
+	// Unknown roles grant nothing.
+	HasPermissions(role string, permissions ...string) (bool, error)
+}
+
+type roleService struct {
+	roleRepo roleRepository.RoleRepository
+}
+
+func NewRoleService(roleRepo roleRepository.RoleRepository) RoleService {
+	return &roleService{roleRepo: roleRepo}
+}
+
+func builtInRole(name string) *models.Role {
+	for _, role := range models.BuiltInRoles() {
+		if role.Name == name {
+			return &role
+		}
+	}
+	return nil
+}
+
+func (s *roleService) ListRoles() ([]models.Role, error) {
+	custom, err := s.roleRepo.GetRoles()
+	if err != nil {
+		return nil, err
+	}
+	return append(models.BuiltInRoles(), custom...), nil
+}
+
+func (s *roleService) GetRole(name string) (*models.Role, error) {
+	if role := builtInRole(name); role != nil {
+		return role, nil
+	}
+	role, err := s.roleRepo.FindRoleByName(name)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrRoleNotFound
+	}
+	return role, err
+}
+
+func (s *roleService) CreateRole(name, description string, permissions []string) (*models.Role, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, ErrInvalidRoleName
+	}
+	if models.IsBuiltInRole(name) {
+		return nil, ErrRoleExists
+	}
+	granted, err := permissionList(permissions)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.roleRepo.FindRoleByName(name); err == nil {
+		return nil, ErrRoleExists
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	role := &models.Role{Name: name, Description: description, Permissions: granted}
+	if err := s.roleRepo.CreateRole(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *roleService) UpdateRole(name, description string, permissions []string) (*models.Role, error) {
+	if models.IsBuiltInRole(name) {
+		return nil, ErrBuiltInRole
+	}
+	granted, err := permissionList(permissions)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Description, role.Permissions, role.UpdatedAt = description, granted, time.Now()
+	if err := s.roleRepo.UpdateRole(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(name string) error {
+	if models.IsBuiltInRole(name) {
+		return ErrBuiltInRole
+	}
+	if _, err := s.GetRole(name); err != nil {
+		return err
+	}
+	// Users left with a deleted role would quietly lose their permissions.
+	count, err := s.roleRepo.CountUsersWithRole(name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+	return s.roleRepo.DeleteRole(name)
+}
+
+func (s *roleService) HasPermissions(name string, permissions ...string) (bool, error) {
+	role, err := s.GetRole(name)
+	if err == ErrRoleNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, permission := range permissions {
+		if !containsString(role.Permissions, permission) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// permissionList checks and dedupes the permissions of a custom role.
+func permissionList(permissions []string) (models.StringList, error) {
+	granted := models.StringList{}
+	for _, permission := range permissions {
+		if !models.IsPermission(permission) {
+			return nil, ErrUnknownPermission
+		}
+		if !containsString(granted, permission) {
+			granted = append(granted, permission)
+		}
+	}
+	return granted, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
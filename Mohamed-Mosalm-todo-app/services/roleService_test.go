@@ -0,0 +1,115 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepository) CreateRole(role *models.Role) error {
+	return m.Called(role).Error(0)
+}
+
+func (m *MockRoleRepository) GetRoles() ([]models.Role, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) FindRoleByName(name string) (*models.Role, error) {
+	args := m.Called(name)
+	role := args.Get(0)
+	if role == nil {
+		return nil, args.Error(1)
+	}
+	return role.(*models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) UpdateRole(role *models.Role) error {
+	return m.Called(role).Error(0)
+}
+
+func (m *MockRoleRepository) DeleteRole(name string) error {
+	return m.Called(name).Error(0)
+}
+
+func (m *MockRoleRepository) CountUsersWithRole(name string) (int64, error) {
+	args := m.Called(name)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestCreateRole(t *testing.T) {
+	roleRepo := new(MockRoleRepository)
+	service := NewRoleService(roleRepo)
+	roleRepo.On("FindRoleByName", "support").Return(nil, gorm.ErrRecordNotFound)
+	roleRepo.On("CreateRole", mock.AnythingOfType("*models.Role")).Return(nil)
+
+	role, err := service.CreateRole("support", "Helps users", []string{models.PermissionUsersRead, models.PermissionUsersRead})
+	require.NoError(t, err)
+	assert.Equal(t, models.StringList{models.PermissionUsersRead}, role.Permissions)
+
+	_, err = service.CreateRole("Support Team", "", nil)
+	assert.Equal(t, ErrInvalidRoleName, err)
+	_, err = service.CreateRole("auditor", "", []string{"tasks:delete"})
+	assert.Equal(t, ErrUnknownPermission, err)
+	_, err = service.CreateRole(models.RoleAdmin, "", nil)
+	assert.Equal(t, ErrRoleExists, err)
+	roleRepo.AssertNumberOfCalls(t, "CreateRole", 1)
+}
+
+func TestBuiltInRolesCannotChange(t *testing.T) {
+	roleRepo := new(MockRoleRepository)
+	service := NewRoleService(roleRepo)
+
+	_, err := service.UpdateRole(models.RoleUser, "", []string{models.PermissionUsersManage})
+	assert.Equal(t, ErrBuiltInRole, err)
+	assert.Equal(t, ErrBuiltInRole, service.DeleteRole(models.RoleAdmin))
+	roleRepo.AssertNotCalled(t, "UpdateRole", mock.Anything)
+	roleRepo.AssertNotCalled(t, "DeleteRole", mock.Anything)
+}
+
+func TestDeleteRoleInUse(t *testing.T) {
+	roleRepo := new(MockRoleRepository)
+	service := NewRoleService(roleRepo)
+	roleRepo.On("FindRoleByName", "support").Return(&models.Role{Name: "support"}, nil)
+	roleRepo.On("CountUsersWithRole", "support").Return(int64(2), nil).Once()
+
+	assert.Equal(t, ErrRoleInUse, service.DeleteRole("support"))
+	roleRepo.AssertNotCalled(t, "DeleteRole", mock.Anything)
+
+	roleRepo.On("CountUsersWithRole", "support").Return(int64(0), nil)
+	roleRepo.On("DeleteRole", "support").Return(nil)
+	assert.NoError(t, service.DeleteRole("support"))
+}
+
+func TestHasPermissions(t *testing.T) {
+	roleRepo := new(MockRoleRepository)
+	service := NewRoleService(roleRepo)
+	roleRepo.On("FindRoleByName", "support").Return(&models.Role{Name: "support", Permissions: models.StringList{models.PermissionUsersRead}}, nil)
+	roleRepo.On("FindRoleByName", "retired").Return(nil, gorm.ErrRecordNotFound)
+
+	tests := []struct {
+		role        string
+		permissions []string
+		want        bool
+	}{
+		{models.RoleAdmin, models.Permissions, true},
+		{models.RoleUser, []string{models.PermissionUsersRead}, false},
+		{models.RoleUser, nil, true},
+		{"support", []string{models.PermissionUsersRead}, true},
+		{"support", []string{models.PermissionUsersRead, models.PermissionUsersManage}, false},
+		{"retired", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := service.HasPermissions(tt.role, tt.permissions...)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s %v", tt.role, tt.permissions)
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	sessionRepository "github.com/MohamedMosalm/Todo-App/repositories/sessionRepository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session has been revoked")
+)
+
+type SessionService interface {
+	CreateSession(userID uuid.UUID, userAgent, ip string) (*models.Session, error)
+	GetSessionsByUserID(userID uuid.UUID) ([]models.Session, error)
+	// ValidateSession checks that sessionID is a live session of userID and
+	// records the activity, writing at most once per touch interval.
+	ValidateSession(sessionID, userID uuid.UUID) error
+	RevokeSession(sessionID, userID uuid.UUID) error
+	RevokeAllSessions(userID uuid.UUID) error
+}
+
+type sessionService struct {
+	sessionRepo   sessionRepository.SessionRepository
+	touchInterval time.Duration
+}
+
+func NewSessionService(sessionRepo sessionRepository.SessionRepository, touchInterval time.Duration) SessionService {
+	return &sessionService{sessionRepo: sessionRepo, touchInterval: touchInterval}
+}
+
+func (s *sessionService) CreateSession(userID uuid.UUID, userAgent, ip string) (*models.Session, error) {
+	session := &models.Session{
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		LastSeenAt: time.Now(),
+	}
+	if err := s.sessionRepo.CreateSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *sessionService) GetSessionsByUserID(userID uuid.UUID) ([]models.Session, error) {
+	return s.sessionRepo.GetActiveSessionsByUserID(userID, time.Now())
+}
+
+func (s *sessionService) ValidateSession(sessionID, userID uuid.UUID) error {
+	session, err := s.getUserSession(sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if session.RevokedAt != nil {
+		return ErrSessionRevoked
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < s.touchInterval {
+		return nil
+	}
+	return s.sessionRepo.TouchSession(sessionID, now)
+}
+
+func (s *sessionService) RevokeSession(sessionID, userID uuid.UUID) error {
+	if _, err := s.getUserSession(sessionID, userID); err != nil {
+		return err
+	}
+	return s.sessionRepo.RevokeSession(sessionID, time.Now())
+}
+
+func (s *sessionService) RevokeAllSessions(userID uuid.UUID) error {
+	return s.sessionRepo.RevokeUserSessions(userID, time.Now())
+}
+
+func (s *sessionService) getUserSession(sessionID, userID uuid.UUID) (*models.Session, error) {
+	session, err := s.sessionRepo.GetSessionByID(sessionID)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
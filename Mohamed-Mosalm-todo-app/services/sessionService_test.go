@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) CreateSession(session *models.Session) error {
+	return m.Called(session).Error(0)
+}
+
+func (m *MockSessionRepository) GetSessionByID(sessionID uuid.UUID) (*models.Session, error) {
+	args := m.Called(sessionID)
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetActiveSessionsByUserID(userID uuid.UUID, now time.Time) ([]models.Session, error) {
+	args := m.Called(userID, now)
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) TouchSession(sessionID uuid.UUID, now time.Time) error {
+	return m.Called(sessionID, now).Error(0)
+}
+
+func (m *MockSessionRepository) RevokeSession(sessionID uuid.UUID, now time.Time) error {
+	return m.Called(sessionID, now).Error(0)
+}
+
+func (m *MockSessionRepository) RevokeUserSessions(userID uuid.UUID, now time.Time) error {
+	return m.Called(userID, now).Error(0)
+}
+
+func TestValidateSessionThrottlesTouches(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, time.Minute)
+
+	userID := uuid.New()
+	fresh := &models.Session{ID: uuid.New(), UserID: userID, LastSeenAt: time.Now().Add(-10 * time.Second)}
+	stale := &models.Session{ID: uuid.New(), UserID: userID, LastSeenAt: time.Now().Add(-time.Hour)}
+	repo.On("GetSessionByID", fresh.ID).Return(fresh, nil)
+	repo.On("GetSessionByID", stale.ID).Return(stale, nil)
+	repo.On("TouchSession", stale.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	assert.NoError(t, service.ValidateSession(fresh.ID, userID))
+	assert.NoError(t, service.ValidateSession(stale.ID, userID))
+	repo.AssertNotCalled(t, "TouchSession", fresh.ID, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestValidateSessionRejectsRevokedAndForeignSessions(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, time.Minute)
+
+	userID := uuid.New()
+	revokedAt := time.Now()
+	revoked := &models.Session{ID: uuid.New(), UserID: userID, RevokedAt: &revokedAt}
+	foreign := &models.Session{ID: uuid.New(), UserID: uuid.New()}
+	repo.On("GetSessionByID", revoked.ID).Return(revoked, nil)
+	repo.On("GetSessionByID", foreign.ID).Return(foreign, nil)
+
+	assert.Equal(t, ErrSessionRevoked, service.ValidateSession(revoked.ID, userID))
+	assert.Equal(t, ErrSessionNotFound, service.ValidateSession(foreign.ID, userID))
+	assert.Equal(t, ErrSessionNotFound, service.RevokeSession(foreign.ID, userID))
+	repo.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything)
+}
@@ -1,43 +1,285 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
 	"github.com/MohamedMosalm/Todo-App/models"
+	projectRepository "github.com/MohamedMosalm/Todo-App/repositories/projectRepository"
 	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProjectNotFound    = errors.New("project not found")
+	ErrParentTaskNotFound = errors.New("parent task not found")
+	ErrInvalidSort        = errors.New("tasks cannot be sorted by this field")
+	ErrNoColumnForStatus  = errors.New("the board has no column for this status")
+
+	ErrCustomFieldsNeedProject = &CustomFieldError{Reason: "custom fields are only available on project tasks"}
 )
 
+// customFieldPrefix marks a custom field key in sort options.
+const customFieldPrefix = "cf."
+
+// TaskListOptions narrows and orders a user's task listing. CustomFields maps
+// field keys to raw filter values and requires ProjectID, as does sorting by
+// a "cf.<key>" field. Condition, when set, is typically a parsed saved filter.
+type TaskListOptions struct {
+	ProjectID    *uuid.UUID
+	CustomFields map[string]string
+	Condition    taskRepository.Condition
+	SortBy       string
+	Desc         bool
+}
+
 type TaskService interface {
 	CreateTask(task *models.Task) error
 	GetTasksByUserID(userID uuid.UUID) ([]models.Task, error)
+	ListTasks(userID uuid.UUID, options TaskListOptions) ([]models.Task, error)
 	UpdateTask(taskID uuid.UUID, updates map[string]interface{}) error
-	DeleteTask(taskID, userID uuid.UUID) error
+	DeleteTask(ctx context.Context, taskID, userID uuid.UUID) error
 	GetTaskByID(taskID uuid.UUID) (*models.Task, error)
+	CountTasks(userID uuid.UUID) (taskRepository.TaskCounts, error)
 }
 
 type taskService struct {
-	taskRepo taskRepository.TaskRepository
+	taskRepo    taskRepository.TaskRepository
+	projectRepo projectRepository.ProjectRepository
+	attachments AttachmentService
 }
 
-func NewTaskService(taskRepo taskRepository.TaskRepository) TaskService {
-	return &taskService{taskRepo: taskRepo}
+func NewTaskService(taskRepo taskRepository.TaskRepository, projectRepo projectRepository.ProjectRepository, attachments AttachmentService) TaskService {
+	return &taskService{taskRepo: taskRepo, projectRepo: projectRepo, attachments: attachments}
 }
 
+// CreateTask stores a new task. Tasks created in a project land at the end of
+// the project's first board column, unless it is at its WIP limit, and have
+// their custom fields validated against the project's schema.
 func (s *taskService) CreateTask(task *models.Task) error {
-	return s.taskRepo.CreateTask(task)
+	if task.ParentID != nil {
+		parent, err := s.taskRepo.GetTaskByID(*task.ParentID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrParentTaskNotFound
+			}
+			return err
+		}
+		if parent.UserID != task.UserID {
+			return ErrParentTaskNotFound
+		}
+	}
+
+	if task.ProjectID == nil {
+		if len(task.CustomFields) > 0 {
+			return ErrCustomFieldsNeedProject
+		}
+		return s.taskRepo.CreateTask(task)
+	}
+
+	project, err := s.getUserProject(*task.ProjectID, task.UserID)
+	if err != nil {
+		return err
+	}
+
+	values, err := validateCustomFieldValues(project.Fields, task.CustomFields)
+	if err != nil {
+		return err
+	}
+	task.CustomFields = values
+
+	if len(project.Columns) == 0 {
+		return s.taskRepo.CreateTask(task)
+	}
+	column := project.Columns[0]
+	task.State, task.Status = column.State, column.IsDone
+	created, err := s.projectRepo.CreateTaskInColumn(task, column.WIPLimit)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrWIPLimitExceeded
+	}
+	return nil
 }
 
 func (s *taskService) GetTasksByUserID(userID uuid.UUID) ([]models.Task, error) {
 	return s.taskRepo.GetTasksByUserID(userID)
 }
 
+func (s *taskService) ListTasks(userID uuid.UUID, options TaskListOptions) ([]models.Task, error) {
+	criteria := taskRepository.TaskCriteria{
+		UserID:    userID,
+		ProjectID: options.ProjectID,
+		Condition: options.Condition,
+	}
+
+	var fields []models.CustomField
+	if options.ProjectID != nil {
+		project, err := s.getUserProject(*options.ProjectID, userID)
+		if err != nil {
+			return nil, err
+		}
+		fields = project.Fields
+	} else if len(options.CustomFields) > 0 || strings.HasPrefix(options.SortBy, customFieldPrefix) {
+		return nil, ErrCustomFieldsNeedProject
+	}
+
+	customFields, err := customFieldCriteria(fields, options.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	criteria.CustomFields = customFields
+
+	if options.SortBy != "" {
+		sort := taskRepository.TaskSort{Column: options.SortBy, Desc: options.Desc}
+		if key, ok := strings.CutPrefix(options.SortBy, customFieldPrefix); ok {
+			field := findField(fields, key)
+			if field == nil {
+				return nil, &CustomFieldError{Field: key, Reason: "is not defined for this project"}
+			}
+			sort = taskRepository.TaskSort{CustomField: key, Type: field.Type, Desc: options.Desc}
+		} else if !taskRepository.SortableColumns[options.SortBy] {
+			return nil, ErrInvalidSort
+		}
+		criteria.Sort = []taskRepository.TaskSort{sort}
+	}
+
+	return s.taskRepo.FindTasks(criteria)
+}
+
+// UpdateTask applies updates to a task. Custom field values given under
+// "custom_fields" are merged into the existing ones, with null removing a
+// value, and the result is validated against the project's schema. On a
+// board, the column decides the status, so changing "status" moves the task
+// to the end of the first column that is done, or not done, accordingly; the
+// other updates are written along with the move.
 func (s *taskService) UpdateTask(taskID uuid.UUID, updates map[string]interface{}) error {
+	if values, ok := updates["custom_fields"].(map[string]interface{}); ok {
+		merged, err := s.mergeCustomFields(taskID, values)
+		if err != nil {
+			return err
+		}
+		updates["custom_fields"] = merged
+	}
+	if status, ok := updates["status"].(bool); ok {
+		task, column, err := s.columnForStatus(taskID, status)
+		if err != nil {
+			return err
+		}
+		if task != nil {
+			delete(updates, "status")
+		}
+		if column != nil {
+			moved, err := s.projectRepo.MoveTask(task, column.State, -1, column.IsDone, column.WIPLimit, updates)
+			if err != nil {
+				return err
+			}
+			if !moved {
+				return ErrWIPLimitExceeded
+			}
+			return nil
+		}
+	}
 	return s.taskRepo.UpdateTask(taskID, updates)
 }
 
-func (s *taskService) DeleteTask(taskID, userID uuid.UUID) error {
-	return s.taskRepo.DeleteTask(taskID, userID)
+// DeleteTask deletes the task and its subtasks, then the blobs of their
+// attachments. The task is gone once its rows are, so blobs that fail to
+// delete are only logged.
+func (s *taskService) DeleteTask(ctx context.Context, taskID, userID uuid.UUID) error {
+	storageKeys, err := s.taskRepo.DeleteTask(taskID, userID)
+	if err != nil {
+		return err
+	}
+	// A client hanging up must not leave the blobs behind.
+	if err := s.attachments.DeleteBlobs(context.WithoutCancel(ctx), storageKeys); err != nil {
+		log.Printf("deleting attachments of task %s failed: %v", taskID, err)
+	}
+	return nil
 }
 
 func (s *taskService) GetTaskByID(taskID uuid.UUID) (*models.Task, error) {
 	return s.taskRepo.GetTaskByID(taskID)
 }
+
+func (s *taskService) CountTasks(userID uuid.UUID) (taskRepository.TaskCounts, error) {
+	return s.taskRepo.CountTasks(userID, time.Now())
+}
+
+// columnForStatus returns the task if it is on a board, along with the
+// column to move it to for status, or nil if its column already matches.
+func (s *taskService) columnForStatus(taskID uuid.UUID, status bool) (*models.Task, *models.BoardColumn, error) {
+	task, err := s.taskRepo.GetTaskByID(taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if task.ProjectID == nil {
+		return nil, nil, nil
+	}
+	project, err := s.getUserProject(*task.ProjectID, task.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(project.Columns) == 0 {
+		return nil, nil, nil
+	}
+	if task.Status == status {
+		return task, nil, nil
+	}
+
+	for i := range project.Columns {
+		if project.Columns[i].IsDone == status {
+			return task, &project.Columns[i], nil
+		}
+	}
+	return nil, nil, ErrNoColumnForStatus
+}
+
+func (s *taskService) mergeCustomFields(taskID uuid.UUID, values map[string]interface{}) (models.CustomFieldValues, error) {
+	task, err := s.taskRepo.GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.ProjectID == nil {
+		if len(values) > 0 {
+			return nil, ErrCustomFieldsNeedProject
+		}
+		return models.CustomFieldValues{}, nil
+	}
+
+	project, err := s.getUserProject(*task.ProjectID, task.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values of fields that were since removed from the schema are dropped.
+	merged := make(models.CustomFieldValues, len(task.CustomFields)+len(values))
+	for key, value := range task.CustomFields {
+		if findField(project.Fields, key) != nil {
+			merged[key] = value
+		}
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return validateCustomFieldValues(project.Fields, merged)
+}
+
+func (s *taskService) getUserProject(projectID, userID uuid.UUID) (*models.Project, error) {
+	project, err := s.projectRepo.GetProjectByID(projectID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+	if project.UserID != userID {
+		return nil, ErrProjectNotFound
+	}
+	return project, nil
+}
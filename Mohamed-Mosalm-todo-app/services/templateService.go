@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+	templateRepository "github.com/MohamedMosalm/Todo-App/repositories/templateRepository"
+	"github.com/google/uuid"
+)
+
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// maxTaskTitleLength is the title limit CreateTaskDTO enforces.
+const maxTaskTitleLength = 100
+
+// MissingVariableError is returned when a template references a variable
+// that was not supplied on instantiation.
+type MissingVariableError struct {
+	Name string
+}
+
+func (e *MissingVariableError) Error() string {
+	return fmt.Sprintf("missing value for template variable %q", e.Name)
+}
+
+// InvalidTitleError is returned when a title rendered on instantiation is
+// empty or longer than task titles may be.
+type InvalidTitleError struct {
+	Pattern string
+}
+
+func (e *InvalidTitleError) Error() string {
+	return fmt.Sprintf("title rendered from %q must be 1 to %d characters long", e.Pattern, maxTaskTitleLength)
+}
+
+type TemplateService interface {
+	CreateTemplate(template *models.TaskTemplate) error
+	GetTemplatesByUserID(userID uuid.UUID) ([]models.TaskTemplate, error)
+	GetTemplateByID(templateID uuid.UUID) (*models.TaskTemplate, error)
+	UpdateTemplate(template *models.TaskTemplate) error
+	DeleteTemplate(templateID, userID uuid.UUID) error
+	Instantiate(template *models.TaskTemplate, variables map[string]string, anchor time.Time) (*models.Task, error)
+}
+
+type templateService struct {
+	templateRepo templateRepository.TemplateRepository
+	taskRepo     taskRepository.TaskRepository
+}
+
+func NewTemplateService(templateRepo templateRepository.TemplateRepository, taskRepo taskRepository.TaskRepository) TemplateService {
+	return &templateService{templateRepo: templateRepo, taskRepo: taskRepo}
+}
+
+func (s *templateService) CreateTemplate(template *models.TaskTemplate) error {
+	return s.templateRepo.CreateTemplate(template)
+}
+
+func (s *templateService) GetTemplatesByUserID(userID uuid.UUID) ([]models.TaskTemplate, error) {
+	return s.templateRepo.GetTemplatesByUserID(userID)
+}
+
+func (s *templateService) GetTemplateByID(templateID uuid.UUID) (*models.TaskTemplate, error) {
+	return s.templateRepo.GetTemplateByID(templateID)
+}
+
+func (s *templateService) UpdateTemplate(template *models.TaskTemplate) error {
+	return s.templateRepo.UpdateTemplate(template)
+}
+
+func (s *templateService) DeleteTemplate(templateID, userID uuid.UUID) error {
+	return s.templateRepo.DeleteTemplate(templateID, userID)
+}
+
+// Instantiate renders the template into a task tree owned by the template's
+// user and stores it in one transaction. Due offsets are counted in days
+// from anchor.
+func (s *templateService) Instantiate(template *models.TaskTemplate, variables map[string]string, anchor time.Time) (*models.Task, error) {
+	root, err := renderTask(models.TemplateItem{
+		TitlePattern:  template.TitlePattern,
+		Description:   template.Description,
+		Tags:          template.Tags,
+		DueOffsetDays: template.DueOffsetDays,
+		Subtasks:      template.Subtasks,
+	}, template.UserID, variables, anchor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.taskRepo.CreateTaskTree(root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func renderTask(item models.TemplateItem, userID uuid.UUID, variables map[string]string, anchor time.Time) (*models.Task, error) {
+	title, err := renderPattern(item.TitlePattern, variables)
+	if err != nil {
+		return nil, err
+	}
+	if length := utf8.RuneCountInString(title); length == 0 || length > maxTaskTitleLength {
+		return nil, &InvalidTitleError{Pattern: item.TitlePattern}
+	}
+	description, err := renderPattern(item.Description, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &models.Task{
+		Title:       title,
+		Description: description,
+		Tags:        append(models.StringList{}, item.Tags...),
+		UserID:      userID,
+	}
+	if item.DueOffsetDays != nil {
+		due := anchor.AddDate(0, 0, *item.DueOffsetDays)
+		task.DueDate = &due
+	}
+
+	for _, sub := range item.Subtasks {
+		subtask, err := renderTask(sub, userID, variables, anchor)
+		if err != nil {
+			return nil, err
+		}
+		task.Subtasks = append(task.Subtasks, *subtask)
+	}
+	return task, nil
+}
+
+func renderPattern(pattern string, variables map[string]string) (string, error) {
+	var missing error
+	rendered := templateVariable.ReplaceAllStringFunc(pattern, func(match string) string {
+		name := templateVariable.FindStringSubmatch(match)[1]
+		value, ok := variables[name]
+		if !ok && missing == nil {
+			missing = &MissingVariableError{Name: name}
+		}
+		return value
+	})
+	return rendered, missing
+}
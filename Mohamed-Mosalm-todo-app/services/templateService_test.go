@@ -0,0 +1,124 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/models"
+	taskRepository "github.com/MohamedMosalm/Todo-App/repositories/taskRepository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTaskRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaskRepository) CreateTask(task *models.Task) error {
+	return m.Called(task).Error(0)
+}
+
+func (m *MockTaskRepository) CreateTaskTree(root *models.Task) error {
+	return m.Called(root).Error(0)
+}
+
+func (m *MockTaskRepository) GetTasksByUserID(userID uuid.UUID) ([]models.Task, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) FindTasks(criteria taskRepository.TaskCriteria) ([]models.Task, error) {
+	args := m.Called(criteria)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) UpdateTask(taskID uuid.UUID, updates map[string]interface{}) error {
+	return m.Called(taskID, updates).Error(0)
+}
+
+func (m *MockTaskRepository) DeleteTask(taskID, userID uuid.UUID) ([]string, error) {
+	args := m.Called(taskID, userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetTaskByID(taskID uuid.UUID) (*models.Task, error) {
+	args := m.Called(taskID)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountTasks(userID uuid.UUID, now time.Time) (taskRepository.TaskCounts, error) {
+	args := m.Called(userID, now)
+	return args.Get(0).(taskRepository.TaskCounts), args.Error(1)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestInstantiateTemplate(t *testing.T) {
+	mockTaskRepo := new(MockTaskRepository)
+	service := NewTemplateService(nil, mockTaskRepo)
+
+	template := &models.TaskTemplate{
+		TitlePattern:  "Release {{ version }}",
+		Tags:          models.StringList{"release"},
+		DueOffsetDays: intPtr(7),
+		UserID:        uuid.New(),
+		Subtasks: models.TemplateItems{
+			{
+				TitlePattern:  "Tag {{version}}",
+				DueOffsetDays: intPtr(-1),
+				Subtasks:      models.TemplateItems{{TitlePattern: "Notify {{team}}"}},
+			},
+		},
+	}
+	anchor := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	mockTaskRepo.On("CreateTaskTree", mock.AnythingOfType("*models.Task")).Return(nil)
+
+	root, err := service.Instantiate(template, map[string]string{"version": "1.2", "team": "ops"}, anchor)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Release 1.2", root.Title)
+	assert.Equal(t, template.UserID, root.UserID)
+	assert.Equal(t, models.StringList{"release"}, root.Tags)
+	assert.Equal(t, anchor.AddDate(0, 0, 7), *root.DueDate)
+
+	assert.Len(t, root.Subtasks, 1)
+	assert.Equal(t, "Tag 1.2", root.Subtasks[0].Title)
+	assert.Equal(t, anchor.AddDate(0, 0, -1), *root.Subtasks[0].DueDate)
+	assert.Equal(t, "Notify ops", root.Subtasks[0].Subtasks[0].Title)
+	assert.Nil(t, root.Subtasks[0].Subtasks[0].DueDate)
+
+	mockTaskRepo.AssertExpectations(t)
+}
+
+func TestInstantiateTemplateMissingVariable(t *testing.T) {
+	mockTaskRepo := new(MockTaskRepository)
+	service := NewTemplateService(nil, mockTaskRepo)
+
+	template := &models.TaskTemplate{TitlePattern: "Onboard {{name}}"}
+
+	_, err := service.Instantiate(template, nil, time.Now())
+	assert.Equal(t, &MissingVariableError{Name: "name"}, err)
+	mockTaskRepo.AssertNotCalled(t, "CreateTaskTree", mock.Anything)
+}
+
+func TestInstantiateTemplateInvalidTitle(t *testing.T) {
+	mockTaskRepo := new(MockTaskRepository)
+	service := NewTemplateService(nil, mockTaskRepo)
+
+	template := &models.TaskTemplate{
+		TitlePattern: "Onboard {{name}}",
+		Subtasks:     models.TemplateItems{{TitlePattern: "{{team}}"}},
+	}
+
+	_, err := service.Instantiate(template, map[string]string{"name": strings.Repeat("x", 100), "team": "ops"}, time.Now())
+	assert.Equal(t, &InvalidTitleError{Pattern: "Onboard {{name}}"}, err)
+
+	_, err = service.Instantiate(template, map[string]string{"name": "Jane", "team": ""}, time.Now())
+	assert.Equal(t, &InvalidTitleError{Pattern: "{{team}}"}, err)
+	mockTaskRepo.AssertNotCalled(t, "CreateTaskTree", mock.Anything)
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"time"
+
 	"github.com/MohamedMosalm/Todo-App/models"
 	userRepository "github.com/MohamedMosalm/Todo-App/repositories/userRepository"
 	"github.com/google/uuid"
@@ -10,14 +12,42 @@ type UserService interface {
 	CreateUser(user *models.User) error
 	FindUserByEmail(email string) (*models.User, error)
 	FindUserByID(id uuid.UUID) (*models.User, error)
+	UpdatePassword(id uuid.UUID, hashedPassword string) error
+	// ListUsers returns a page of the users matching options and how many
+	// match in all.
+	ListUsers(options UserListOptions) ([]models.User, int64, error)
+	UpdateUser(id uuid.UUID, updates map[string]interface{}) error
+	// DeactivateUser signs the user out everywhere and keeps them from
+	// logging in or using their tokens and API keys until ReactivateUser.
+	// Their data is kept.
+	DeactivateUser(id uuid.UUID) error
+	// DeprovisionUser deactivates the user on behalf of the provisioning
+	// client that deleted them, which stops seeing them. ReactivateUser
+	// undoes both.
+	DeprovisionUser(id uuid.UUID) error
+	ReactivateUser(id uuid.UUID) error
+}
+
+// UserListOptions narrows a user listing; zero fields match every user.
+type UserListOptions struct {
+	Email      string
+	ExternalID string
+	Active     *bool
+	Search     string
+	Role       string
+	Offset     int
+	Limit      int
+	// ExcludeDeprovisioned leaves out users the provisioning client deleted.
+	ExcludeDeprovisioned bool
 }
 
 type userService struct {
-	userRepo userRepository.UserRepository
+	userRepo       userRepository.UserRepository
+	sessionService SessionService
 }
 
-func NewUserService(userRepo userRepository.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+func NewUserService(userRepo userRepository.UserRepository, sessionService SessionService) UserService {
+	return &userService{userRepo: userRepo, sessionService: sessionService}
 }
 
 func (s *userService) CreateUser(user *models.User) error {
@@ -31,3 +61,51 @@ func (s *userService) FindUserByEmail(email string) (*models.User, error) {
 func (s *userService) FindUserByID(id uuid.UUID) (*models.User, error) {
 	return s.userRepo.FindUserByID(id)
 }
+
+func (s *userService) UpdatePassword(id uuid.UUID, hashedPassword string) error {
+	return s.userRepo.UpdatePassword(id, hashedPassword)
+}
+
+func (s *userService) ListUsers(options UserListOptions) ([]models.User, int64, error) {
+	return s.userRepo.FindUsers(userRepository.UserCriteria{
+		Email:      options.Email,
+		ExternalID: options.ExternalID,
+		Active:     options.Active,
+		Search:     options.Search,
+		Role:       options.Role,
+		Offset:     options.Offset,
+		Limit:      options.Limit,
+
+		ExcludeDeprovisioned: options.ExcludeDeprovisioned,
+	})
+}
+
+func (s *userService) UpdateUser(id uuid.UUID, updates map[string]interface{}) error {
+	return s.userRepo.UpdateUser(id, updates)
+}
+
+func (s *userService) DeactivateUser(id uuid.UUID) error {
+	user, err := s.userRepo.FindUserByID(id)
+	if err != nil {
+		return err
+	}
+	if user.IsActive() {
+		if err := s.userRepo.UpdateUser(id, map[string]interface{}{"deactivated_at": time.Now()}); err != nil {
+			return err
+		}
+	}
+	// Access tokens are refused from now on anyway; this ends the sessions
+	// so that reactivating the account does not bring them back.
+	return s.sessionService.RevokeAllSessions(id)
+}
+
+func (s *userService) DeprovisionUser(id uuid.UUID) error {
+	if err := s.DeactivateUser(id); err != nil {
+		return err
+	}
+	return s.userRepo.UpdateUser(id, map[string]interface{}{"deprovisioned_at": time.Now()})
+}
+
+func (s *userService) ReactivateUser(id uuid.UUID) error {
+	return s.userRepo.UpdateUser(id, map[string]interface{}{"deactivated_at": nil, "deprovisioned_at": nil})
+}
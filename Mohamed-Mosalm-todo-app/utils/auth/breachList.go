@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sha1HexLength = 40
+	// rangePrefixLength is how many leading hex digits of a hash name the
+	// range it belongs to in the k-anonymity layout.
+	rangePrefixLength = 5
+)
+
+// BreachList looks passwords up in a local copy of the Pwned Passwords
+// SHA-1 list, in either layout the official downloader writes:
+//
+//   - one file of "HASH:COUNT" lines sorted by hash, which is
+//     binary-searched on disk, so even the full list never has to fit in
+//     memory;
+//   - a directory of k-anonymity range files, "00000.txt" to "FFFFF.txt",
+//     each holding the "SUFFIX:COUNT" lines of the hashes starting with the
+//     file's five-digit prefix, as the range API returns them.
+//
+// Either way passwords are only ever hashed locally.
+type BreachList struct {
+	file *os.File
+	size int64
+	// dir is set instead of file for the range layout.
+	dir string
+}
+
+func OpenBreachList(path string) (*BreachList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening breached password list: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return openBreachRanges(path)
+	}
+
+	list := &BreachList{file: file, size: info.Size()}
+	first, _, err := list.lineFrom(0)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !isSHA1Hex(breachHash(first)) {
+		file.Close()
+		return nil, errors.New("breached password list must hold sorted HASH:COUNT lines of SHA-1 hashes")
+	}
+	return list, nil
+}
+
+// openBreachRanges checks that dir looks like a complete set of range
+// files by reading the first one.
+func openBreachRanges(dir string) (*BreachList, error) {
+	file, err := os.Open(filepath.Join(dir, strings.Repeat("0", rangePrefixLength)+".txt"))
+	if err != nil {
+		return nil, fmt.Errorf("opening breached password ranges: %w", err)
+	}
+	defer file.Close()
+
+	first, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !isHex(breachHash(first), sha1HexLength-rangePrefixLength) {
+		return nil, errors.New("breached password ranges must hold SUFFIX:COUNT lines of SHA-1 hash suffixes")
+	}
+	return &BreachList{dir: dir}, nil
+}
+
+func (b *BreachList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	target := strings.ToUpper(hex.EncodeToString(sum[:]))
+	if b.dir != "" {
+		return b.rangeContains(target)
+	}
+
+	low, high := int64(0), b.size
+	for low < high {
+		mid := low + (high-low)/2
+		line, start, err := b.lineFrom(mid)
+		if err != nil {
+			return false, err
+		}
+		if line == "" {
+			high = mid
+			continue
+		}
+
+		switch hash := breachHash(line); {
+		case hash == target:
+			return true, nil
+		case hash < target:
+			low = start + int64(len(line)) + 1
+		default:
+			high = mid
+		}
+	}
+	return false, nil
+}
+
+// rangeContains scans the range file for target's prefix. Lines with a
+// count of 0 are padding the range API can add, not breached passwords.
+func (b *BreachList) rangeContains(target string) (bool, error) {
+	prefix, suffix := target[:rangePrefixLength], target[rangePrefixLength:]
+	file, err := os.Open(filepath.Join(b.dir, prefix+".txt"))
+	if err != nil {
+		return false, fmt.Errorf("opening breached password range: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, count, _ := strings.Cut(scanner.Text(), ":")
+		if strings.EqualFold(strings.TrimSpace(hash), suffix) {
+			return strings.TrimSpace(count) != "0", nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+func (b *BreachList) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	return b.file.Close()
+}
+
+// lineFrom returns the first line starting at or after offset, without its
+// newline, and where it starts. It returns an empty line past the end.
+func (b *BreachList) lineFrom(offset int64) (string, int64, error) {
+	start := offset
+	if offset > 0 {
+		start = offset - 1
+	}
+	reader := bufio.NewReader(io.NewSectionReader(b.file, start, b.size-start))
+	if offset > 0 {
+		skipped, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return "", b.size, nil
+		}
+		if err != nil {
+			return "", 0, err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+	return strings.TrimRight(line, "\r\n"), start, nil
+}
+
+func breachHash(line string) string {
+	hash, _, _ := strings.Cut(line, ":")
+	return strings.ToUpper(strings.TrimSpace(hash))
+}
+
+func isSHA1Hex(s string) bool {
+	return isHex(s, sha1HexLength)
+}
+
+// isHex reports whether s is length hex digits.
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -8,23 +8,175 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	ErrTokenExpired          = errors.New("token has expired")
+	ErrTokenMalformed        = errors.New("token is malformed")
+	ErrTokenSignatureInvalid = errors.New("token signature is invalid")
+	// ErrTokenClaimsInvalid covers well-formed, correctly signed tokens that
+	// were issued by or for someone else, are not valid yet, or lack a
+	// required claim.
+	ErrTokenClaimsInvalid = errors.New("token claims are invalid")
+)
+
+// JWTOptions configure the tokens a JWTService issues and accepts.
+type JWTOptions struct {
+	Issuer         string
+	Audience       string
+	AccessTokenTTL time.Duration
+	// Leeway absorbs clock skew when checking exp, nbf and iat.
+	Leeway time.Duration
+}
+
+// AccessClaims are the claims of an access token.
+type AccessClaims struct {
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"sid"`
+	// Purpose is set on tokens that are not access tokens, such as MFA
+	// challenges, so they are never accepted as one.
+	Purpose string `json:"purpose,omitempty"`
+	// Actor is set on impersonation tokens to the admin acting as UserID.
+	// SessionID is then the admin's session.
+	Actor *ActorClaim `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ActorClaim is the act claim of RFC 8693: who is acting on the subject's
+// behalf.
+type ActorClaim struct {
+	Subject uuid.UUID `json:"sub"`
+}
+
 type JWTService struct {
-	secretKey string
+	keys    *KeySet
+	options JWTOptions
+}
+
+func NewJWTService(keys *KeySet, options JWTOptions) (*JWTService, error) {
+	if keys == nil {
+		return nil, errors.New("JWT signing keys not set")
+	}
+	if options.AccessTokenTTL <= 0 {
+		return nil, errors.New("access token TTL must be positive")
+	}
+	if options.Issuer == "" || options.Audience == "" {
+		return nil, errors.New("JWT issuer and audience must be set")
+	}
+	if options.Leeway < 0 {
+		return nil, errors.New("JWT leeway must not be negative")
+	}
+	return &JWTService{keys: keys, options: options}, nil
+}
+
+// AccessTokenTTL is how long tokens from GenerateToken stay valid.
+func (s *JWTService) AccessTokenTTL() time.Duration {
+	return s.options.AccessTokenTTL
+}
+
+func (s *JWTService) registeredClaims(ttl time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		Issuer:    s.options.Issuer,
+		Audience:  jwt.ClaimStrings{s.options.Audience},
+		ID:        uuid.NewString(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+}
+
+// GenerateToken signs an access token for userID within the login session
+// sessionID. Its jti claim is what logout records in the revocation store.
+func (s *JWTService) GenerateToken(userID, sessionID uuid.UUID) (string, error) {
+	return s.keys.Sign(&AccessClaims{
+		UserID:           userID,
+		SessionID:        sessionID,
+		RegisteredClaims: s.registeredClaims(s.options.AccessTokenTTL),
+	})
+}
+
+// GenerateImpersonationToken signs an access token that lets actorID act as
+// userID for ttl. It is tied to the actor's session, so it stops working
+// when that session ends.
+func (s *JWTService) GenerateImpersonationToken(userID, actorID, actorSessionID uuid.UUID, ttl time.Duration) (string, error) {
+	return s.keys.Sign(&AccessClaims{
+		UserID:           userID,
+		SessionID:        actorSessionID,
+		Actor:            &ActorClaim{Subject: actorID},
+		RegisteredClaims: s.registeredClaims(ttl),
+	})
+}
+
+// ValidateToken verifies an access token's signature and standard claims
+// and returns its claims. Errors are ErrTokenExpired, ErrTokenMalformed,
+// ErrTokenSignatureInvalid or ErrTokenClaimsInvalid.
+func (s *JWTService) ValidateToken(tokenString string) (*AccessClaims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" || claims.SessionID == uuid.Nil {
+		return nil, ErrTokenClaimsInvalid
+	}
+	if claims.Actor != nil && claims.Actor.Subject == uuid.Nil {
+		return nil, ErrTokenClaimsInvalid
+	}
+	return claims, nil
 }
 
-func NewJWTService(secretKey string) (*JWTService, error) {
-	if secretKey == "" {
-		return nil, errors.New("JWT_SECRET environment variable not set")
+func (s *JWTService) parse(tokenString string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, s.keys.Keyfunc,
+		jwt.WithValidMethods(s.keys.Methods()),
+		jwt.WithIssuer(s.options.Issuer),
+		jwt.WithAudience(s.options.Audience),
+		jwt.WithLeeway(s.options.Leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, classifyTokenError(err)
+	}
+
+	if claims.IssuedAt == nil || claims.ID == "" || claims.UserID == uuid.Nil {
+		return nil, ErrTokenClaimsInvalid
 	}
-	return &JWTService{secretKey: secretKey}, nil
+	return claims, nil
 }
 
-func (s *JWTService) GenerateToken(userID uuid.UUID) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID.String(),
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+func classifyTokenError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ErrTokenMalformed
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return ErrTokenSignatureInvalid
+	default:
+		return ErrTokenClaimsInvalid
 	}
+}
+
+// mfaTokenPurpose marks challenge tokens so they are never mistaken for
+// access tokens.
+const mfaTokenPurpose = "mfa"
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
+var ErrInvalidMFAToken = errors.New("invalid or expired MFA token")
+
+// GenerateMFAToken signs a challenge token proving userID passed the
+// password check. It is only good for completing the second factor.
+func (s *JWTService) GenerateMFAToken(userID uuid.UUID, ttl time.Duration) (string, error) {
+	return s.keys.Sign(&AccessClaims{
+		UserID:           userID,
+		Purpose:          mfaTokenPurpose,
+		RegisteredClaims: s.registeredClaims(ttl),
+	})
+}
+
+// ParseMFAToken returns the user a challenge token was issued to.
+func (s *JWTService) ParseMFAToken(tokenString string) (uuid.UUID, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil || claims.Purpose != mfaTokenPurpose {
+		return uuid.Nil, ErrInvalidMFAToken
+	}
+	return claims.UserID, nil
 }
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testJWTOptions = JWTOptions{Issuer: "https://todo.example.com", Audience: "todo-api", AccessTokenTTL: time.Minute, Leeway: 30 * time.Second}
+
+func newTestJWTService(t *testing.T) (*JWTService, *KeySet) {
+	t.Helper()
+	keys, err := NewHMACKeySet("test_secret")
+	require.NoError(t, err)
+	service, err := NewJWTService(keys, testJWTOptions)
+	require.NoError(t, err)
+	return service, keys
+}
+
+func TestValidateToken(t *testing.T) {
+	service, _ := newTestJWTService(t)
+	userID, sessionID := uuid.New(), uuid.New()
+
+	token, err := service.GenerateToken(userID, sessionID)
+	require.NoError(t, err)
+
+	claims, err := service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, sessionID, claims.SessionID)
+	assert.Equal(t, "https://todo.example.com", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"todo-api"}, claims.Audience)
+	assert.NotEmpty(t, claims.ID)
+	assert.NotNil(t, claims.NotBefore)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), claims.ExpiresAt.Time, 2*time.Second)
+}
+
+func TestImpersonationToken(t *testing.T) {
+	service, _ := newTestJWTService(t)
+	userID, actorID, sessionID := uuid.New(), uuid.New(), uuid.New()
+
+	token, err := service.GenerateImpersonationToken(userID, actorID, sessionID, 5*time.Minute)
+	require.NoError(t, err)
+
+	claims, err := service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, sessionID, claims.SessionID)
+	require.NotNil(t, claims.Actor)
+	assert.Equal(t, actorID, claims.Actor.Subject)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), claims.ExpiresAt.Time, 2*time.Second)
+
+	plain, err := service.GenerateToken(userID, sessionID)
+	require.NoError(t, err)
+	claims, err = service.ValidateToken(plain)
+	require.NoError(t, err)
+	assert.Nil(t, claims.Actor)
+}
+
+func TestValidateTokenErrors(t *testing.T) {
+	service, keys := newTestJWTService(t)
+	now := time.Now()
+	sign := func(mutate func(*AccessClaims)) string {
+		claims := &AccessClaims{UserID: uuid.New(), SessionID: uuid.New(), RegisteredClaims: service.registeredClaims(time.Minute)}
+		mutate(claims)
+		token, err := keys.Sign(claims)
+		require.NoError(t, err)
+		return token
+	}
+	valid := sign(func(*AccessClaims) {})
+
+	otherKeys, _ := NewHMACKeySet("other_secret")
+	otherService, _ := NewJWTService(otherKeys, testJWTOptions)
+	forged, _ := otherService.GenerateToken(uuid.New(), uuid.New())
+	challenge, _ := service.GenerateMFAToken(uuid.New(), time.Minute)
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired", sign(func(c *AccessClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) }), ErrTokenExpired},
+		{"garbage", "not.a.token", ErrTokenMalformed},
+		{"truncated", valid[:strings.LastIndex(valid, ".")], ErrTokenMalformed},
+		{"other secret", forged, ErrTokenSignatureInvalid},
+		{"tampered signature", valid[:len(valid)-4] + "AAAA", ErrTokenSignatureInvalid},
+		{"wrong issuer", sign(func(c *AccessClaims) { c.Issuer = "https://evil.example.com" }), ErrTokenClaimsInvalid},
+		{"wrong audience", sign(func(c *AccessClaims) { c.Audience = jwt.ClaimStrings{"billing-api"} }), ErrTokenClaimsInvalid},
+		{"not yet valid", sign(func(c *AccessClaims) { c.NotBefore = jwt.NewNumericDate(now.Add(time.Hour)) }), ErrTokenClaimsInvalid},
+		{"issued in the future", sign(func(c *AccessClaims) { c.IssuedAt = jwt.NewNumericDate(now.Add(time.Hour)) }), ErrTokenClaimsInvalid},
+		{"missing iat", sign(func(c *AccessClaims) { c.IssuedAt = nil }), ErrTokenClaimsInvalid},
+		{"missing jti", sign(func(c *AccessClaims) { c.ID = "" }), ErrTokenClaimsInvalid},
+		{"missing session", sign(func(c *AccessClaims) { c.SessionID = uuid.Nil }), ErrTokenClaimsInvalid},
+		{"MFA challenge", challenge, ErrTokenClaimsInvalid},
+		{"actor without subject", sign(func(c *AccessClaims) { c.Actor = &ActorClaim{} }), ErrTokenClaimsInvalid},
+	}
+	for _, tt := range tests {
+		_, err := service.ValidateToken(tt.token)
+		assert.Equal(t, tt.want, err, tt.name)
+	}
+}
+
+func TestValidateTokenLeeway(t *testing.T) {
+	service, keys := newTestJWTService(t)
+	claims := &AccessClaims{UserID: uuid.New(), SessionID: uuid.New(), RegisteredClaims: service.registeredClaims(time.Minute)}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+	claims.NotBefore = jwt.NewNumericDate(time.Now().Add(10 * time.Second))
+	token, err := keys.Sign(claims)
+	require.NoError(t, err)
+
+	_, err = service.ValidateToken(token)
+	assert.NoError(t, err, "skew within the leeway is tolerated")
+}
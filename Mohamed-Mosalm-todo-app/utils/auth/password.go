@@ -1,21 +1,212 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
-type PasswordService struct{}
+	"github.com/MohamedMosalm/Todo-App/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
 
-func NewPasswordService() *PasswordService {
-	return &PasswordService{}
+// bcryptMaxPasswordBytes is the most input bcrypt uses; anything longer
+// would silently be ignored.
+const bcryptMaxPasswordBytes = 72
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+var (
+	ErrPasswordMismatch      = errors.New("password does not match")
+	ErrPasswordTooLong       = fmt.Errorf("password must not be longer than %d bytes", bcryptMaxPasswordBytes)
+	ErrUnknownHashFormat     = errors.New("unrecognised password hash format")
+	argon2idHashEncoding     = base64.RawStdEncoding
+	errMalformedArgon2idHash = errors.New("malformed argon2id hash")
+)
+
+// PasswordHasher hashes passwords with one algorithm and checks hashes it
+// produced, whatever parameters they were made with.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Recognizes reports whether encoded was produced by this algorithm.
+	Recognizes(encoded string) bool
+	Verify(encoded, password string) error
+	// NeedsRehash reports whether encoded was made with parameters other
+	// than the hasher's current ones.
+	NeedsRehash(encoded string) bool
+}
+
+// NewPasswordHasher returns the hasher cfg selects for new passwords.
+func NewPasswordHasher(cfg config.PasswordConfig) (PasswordHasher, error) {
+	switch cfg.Algorithm {
+	case "argon2id":
+		return NewArgon2idHasher(cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism), nil
+	case "bcrypt":
+		return NewBcryptHasher(cfg.BcryptCost), nil
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", cfg.Algorithm)
+	}
+}
+
+// PasswordService hashes new passwords with its hasher and still verifies
+// hashes made by any supported algorithm, so switching algorithms does not
+// lock anyone out.
+type PasswordService struct {
+	hasher  PasswordHasher
+	hashers []PasswordHasher
+}
+
+func NewPasswordService(hasher PasswordHasher) *PasswordService {
+	return &PasswordService{
+		hasher: hasher,
+		// Parameters come from the hash itself when verifying, so the
+		// defaults here only matter for recognising the format.
+		hashers: []PasswordHasher{hasher, NewArgon2idHasher(0, 0, 0), NewBcryptHasher(bcrypt.DefaultCost)},
+	}
 }
 
 func (s *PasswordService) HashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return s.hasher.Hash(password)
+}
+
+func (s *PasswordService) ComparePasswords(hashedPassword, plainPassword string) error {
+	for _, hasher := range s.hashers {
+		if hasher.Recognizes(hashedPassword) {
+			return hasher.Verify(hashedPassword, plainPassword)
+		}
+	}
+	return ErrUnknownHashFormat
+}
+
+// NeedsRehash reports whether hashedPassword should be replaced by a fresh
+// hash, because it uses another algorithm or outdated parameters.
+func (s *PasswordService) NeedsRehash(hashedPassword string) bool {
+	return !s.hasher.Recognizes(hashedPassword) || s.hasher.NeedsRehash(hashedPassword)
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) PasswordHasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	if len(password) > bcryptMaxPasswordBytes {
+		return "", ErrPasswordTooLong
+	}
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
 	if err != nil {
 		return "", err
 	}
 	return string(hashedBytes), nil
 }
 
-func (s *PasswordService) ComparePasswords(hashedPassword, plainPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plainPassword))
+func (h *bcryptHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// Verify refuses over-long passwords rather than letting bcrypt compare
+// only their first 72 bytes.
+func (h *bcryptHasher) Verify(encoded, password string) error {
+	if len(password) > bcryptMaxPasswordBytes {
+		return ErrPasswordMismatch
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return ErrPasswordMismatch
+		}
+		return err
+	}
+	return nil
+}
+
+func (h *bcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.cost
+}
+
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+type argon2idHasher struct {
+	params argon2idParams
+}
+
+// NewArgon2idHasher hashes with memory KiB of memory, iterations passes
+// and parallelism lanes, encoding the result in the PHC string format:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) PasswordHasher {
+	return &argon2idHasher{params: argon2idParams{memory: memory, iterations: iterations, parallelism: parallelism}}
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.iterations, h.params.memory, h.params.parallelism, argon2idKeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.memory, h.params.iterations, h.params.parallelism,
+		argon2idHashEncoding.EncodeToString(salt), argon2idHashEncoding.EncodeToString(key)), nil
+}
+
+func (h *argon2idHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *argon2idHasher) Verify(encoded, password string) error {
+	params, salt, key, err := decodeArgon2idHash(encoded)
+	if err != nil {
+		return err
+	}
+	candidate := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h *argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, key, err := decodeArgon2idHash(encoded)
+	return err != nil || params != h.params || len(key) != argon2idKeyLength
+}
+
+func decodeArgon2idHash(encoded string) (argon2idParams, []byte, []byte, error) {
+	var params argon2idParams
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+	if params.iterations == 0 || params.parallelism == 0 {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+
+	salt, err := argon2idHashEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+	key, err := argon2idHashEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+	return params, salt, key, nil
 }
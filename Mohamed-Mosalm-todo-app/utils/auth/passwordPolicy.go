@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// minPersonalInfoLength keeps very short names from banning half the
+// dictionary.
+const minPersonalInfoLength = 3
+
+// maxScoredLength bounds how much of a password zxcvbn scores; its running
+// time grows much faster than the input.
+const maxScoredLength = 100
+
+// PasswordPolicyError lists every rule a password breaks, in words fit to
+// show the user.
+type PasswordPolicyError struct {
+	Reasons []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password " + strings.Join(e.Reasons, "; ")
+}
+
+type PasswordPolicy struct {
+	minLength   int
+	maxLength   int
+	minStrength int
+	breaches    *BreachList
+}
+
+// NewPasswordPolicy builds the policy cfg describes, opening the breached
+// password list if one is configured.
+func NewPasswordPolicy(cfg config.PasswordConfig) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		minLength:   cfg.MinLength,
+		maxLength:   cfg.MaxLength,
+		minStrength: cfg.MinStrength,
+	}
+	if cfg.BreachListPath != "" {
+		breaches, err := OpenBreachList(cfg.BreachListPath)
+		if err != nil {
+			return nil, err
+		}
+		policy.breaches = breaches
+	}
+	return policy, nil
+}
+
+// Validate checks password for an account with the given personal details,
+// such as its email and names. It returns a *PasswordPolicyError when the
+// password breaks the policy, and other errors only if the check failed.
+func (p *PasswordPolicy) Validate(password string, personalInfo ...string) error {
+	var reasons []string
+
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength {
+		reasons = append(reasons, fmt.Sprintf("must be at least %d characters long", p.minLength))
+	}
+	if p.maxLength > 0 && length > p.maxLength {
+		// Too long to be worth scoring, whatever else is wrong with it.
+		return &PasswordPolicyError{Reasons: []string{fmt.Sprintf("must be at most %d characters long", p.maxLength)}}
+	}
+
+	inputs := personalInputs(personalInfo)
+	lowered := strings.ToLower(password)
+	for _, input := range inputs {
+		if strings.Contains(lowered, input) {
+			reasons = append(reasons, "must not contain your email address or name")
+			break
+		}
+	}
+
+	scored := password
+	if length > maxScoredLength {
+		scored = string([]rune(password)[:maxScoredLength])
+	}
+	if strength := zxcvbn.PasswordStrength(scored, inputs); strength.Score < p.minStrength {
+		reasons = append(reasons, "is too easy to guess; try a longer passphrase or less common words")
+	}
+
+	if p.breaches != nil {
+		breached, err := p.breaches.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			reasons = append(reasons, "has appeared in a data breach; please choose another one")
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &PasswordPolicyError{Reasons: reasons}
+	}
+	return nil
+}
+
+// personalInputs lowercases personal details and splits emails so both
+// the whole address and its local part count.
+func personalInputs(personalInfo []string) []string {
+	var inputs []string
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		candidates := []string{info}
+		if local, _, ok := strings.Cut(info, "@"); ok {
+			candidates = append(candidates, local)
+		}
+		for _, candidate := range candidates {
+			if utf8.RuneCountInString(candidate) >= minPersonalInfoLength {
+				inputs = append(inputs, candidate)
+			}
+		}
+	}
+	return inputs
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeBreachList writes passwords as a sorted HASH:COUNT list, padded with
+// unrelated hashes so the search has to skip around.
+func writeBreachList(t *testing.T, passwords ...string) string {
+	var lines []string
+	for i, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), i+1))
+	}
+	for i := 0; i < 500; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("filler-%d", i)))
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), i*7))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1.txt")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o644))
+	return path
+}
+
+func TestBreachList(t *testing.T) {
+	list, err := OpenBreachList(writeBreachList(t, "hunter2", "correct horse battery staple", "P@ssw0rd"))
+	assert.NoError(t, err)
+	defer list.Close()
+
+	for _, password := range []string{"hunter2", "correct horse battery staple", "P@ssw0rd"} {
+		found, err := list.Contains(password)
+		assert.NoError(t, err)
+		assert.True(t, found, password)
+	}
+	for _, password := range []string{"hunter3", "p@ssw0rd", "", "filler"} {
+		found, err := list.Contains(password)
+		assert.NoError(t, err)
+		assert.False(t, found, password)
+	}
+
+	path := filepath.Join(t.TempDir(), "not-a-list.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("password123\n"), 0o644))
+	_, err = OpenBreachList(path)
+	assert.Error(t, err)
+}
+
+// writeBreachRanges writes passwords as k-anonymity range files, one
+// PREFIX.txt of SUFFIX:COUNT lines per hash prefix, starting with 00000.txt.
+func writeBreachRanges(t *testing.T, passwords ...string) string {
+	ranges := map[string][]string{"00000": {"0005AD76BD555C1D6D771DE417A4B87E4B4:10"}}
+	for i, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		hash := hex.EncodeToString(sum[:])
+		prefix := strings.ToUpper(hash[:5])
+		ranges[prefix] = append(ranges[prefix], fmt.Sprintf("%s:%d", hash[5:], i+1))
+	}
+
+	dir := t.TempDir()
+	for prefix, lines := range ranges {
+		sort.Strings(lines)
+		path := filepath.Join(dir, prefix+".txt")
+		assert.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o644))
+	}
+	return dir
+}
+
+func TestBreachRanges(t *testing.T) {
+	dir := writeBreachRanges(t, "hunter2", "P@ssw0rd")
+	list, err := OpenBreachList(dir)
+	assert.NoError(t, err)
+	defer list.Close()
+
+	for _, password := range []string{"hunter2", "P@ssw0rd"} {
+		found, err := list.Contains(password)
+		assert.NoError(t, err)
+		assert.True(t, found, password)
+	}
+
+	// Padding lines the range API adds carry a count of 0.
+	sum := sha1.Sum([]byte("hunter3"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	padding := hash[5:] + ":0\r\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(padding), 0o644))
+	found, err := list.Contains("hunter3")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	// A partial download is missing ranges, which must not pass silently.
+	_, err = list.Contains("p@ssw0rd")
+	assert.Error(t, err)
+
+	_, err = OpenBreachList(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy, err := NewPasswordPolicy(config.PasswordConfig{
+		MinLength:      10,
+		MaxLength:      64,
+		MinStrength:    3,
+		BreachListPath: writeBreachList(t, "velvet-crane-spindle-07"),
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, policy.Validate("plum-orbit-canvas-tide", "jane.doe@example.com", "Jane", "Doe"))
+
+	tests := []struct {
+		password string
+		reason   string
+	}{
+		{"Xk9#", "must be at least 10 characters long"},
+		{strings.Repeat("plum-orbit-", 6), "must be at most 64 characters long"},
+		{"password12345", "is too easy to guess; try a longer passphrase or less common words"},
+		{"Jane.Doe-plum-orbit", "must not contain your email address or name"},
+		{"orbit-JANE.DOE@example.com", "must not contain your email address or name"},
+		{"velvet-crane-spindle-07", "has appeared in a data breach; please choose another one"},
+	}
+	for _, tt := range tests {
+		err := policy.Validate(tt.password, "jane.doe@example.com", "Jane", "Doe")
+		policyErr, ok := err.(*PasswordPolicyError)
+		if assert.True(t, ok, "%q: got %v", tt.password, err) {
+			assert.Contains(t, policyErr.Reasons, tt.reason, tt.password)
+		}
+	}
+
+	// Overlong passwords are refused before zxcvbn, which would take minutes.
+	start := time.Now()
+	err = policy.Validate(strings.Repeat("a", 100000))
+	assert.Equal(t, &PasswordPolicyError{Reasons: []string{"must be at most 64 characters long"}}, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// Names too short to be meaningful are not banned.
+	assert.NoError(t, policy.Validate("plum-orbit-canvas-tide", "al@example.com", "Al", "Li"))
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := NewArgon2idHasher(64, 1, 1)
+
+	encoded, err := hasher.Hash("correct horse battery staple")
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^\$argon2id\$v=19\$m=64,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`), encoded)
+	assert.True(t, hasher.Recognizes(encoded))
+
+	assert.NoError(t, hasher.Verify(encoded, "correct horse battery staple"))
+	assert.Equal(t, ErrPasswordMismatch, hasher.Verify(encoded, "Correct horse battery staple"))
+
+	other, _ := hasher.Hash("correct horse battery staple")
+	assert.NotEqual(t, encoded, other, "every hash gets its own salt")
+
+	assert.False(t, hasher.NeedsRehash(encoded))
+	assert.True(t, NewArgon2idHasher(128, 1, 1).NeedsRehash(encoded))
+	assert.True(t, NewArgon2idHasher(64, 2, 1).NeedsRehash(encoded))
+
+	assert.Error(t, hasher.Verify("$argon2id$v=19$m=64,t=1$c2FsdA$a2V5", "x"))
+}
+
+func TestBcryptHasherLengthLimit(t *testing.T) {
+	hasher := NewBcryptHasher(bcrypt.MinCost)
+	long := strings.Repeat("a", 72)
+
+	encoded, err := hasher.Hash(long)
+	assert.NoError(t, err)
+	assert.NoError(t, hasher.Verify(encoded, long))
+
+	_, err = hasher.Hash(long + "b")
+	assert.Equal(t, ErrPasswordTooLong, err)
+	assert.Equal(t, ErrPasswordMismatch, hasher.Verify(encoded, long+"b"), "bytes past 72 must not be ignored")
+}
+
+func TestPasswordServiceMigratesHashes(t *testing.T) {
+	bcryptHash, err := NewBcryptHasher(bcrypt.MinCost).Hash("password123")
+	assert.NoError(t, err)
+	argonHash, err := NewArgon2idHasher(64, 1, 1).Hash("password123")
+	assert.NoError(t, err)
+
+	service := NewPasswordService(NewArgon2idHasher(64, 1, 1))
+	assert.NoError(t, service.ComparePasswords(bcryptHash, "password123"), "old bcrypt hashes keep working")
+	assert.NoError(t, service.ComparePasswords(argonHash, "password123"))
+	assert.Error(t, service.ComparePasswords(bcryptHash, "password124"))
+	assert.Equal(t, ErrUnknownHashFormat, service.ComparePasswords("plaintext", "plaintext"))
+
+	assert.True(t, service.NeedsRehash(bcryptHash), "other algorithm")
+	assert.False(t, service.NeedsRehash(argonHash))
+
+	bcryptService := NewPasswordService(NewBcryptHasher(bcrypt.MinCost + 1))
+	assert.True(t, bcryptService.NeedsRehash(bcryptHash), "outdated cost")
+	assert.True(t, bcryptService.NeedsRehash(argonHash))
+	assert.NoError(t, bcryptService.ComparePasswords(argonHash, "password123"))
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for RS256.
+const minRSAKeyBits = 2048
+
+var (
+	ErrNoSigningKey = errors.New("no signing key is active yet")
+	ErrUnknownKeyID = errors.New("token was signed with an unknown key")
+)
+
+// SigningKey is one entry of a KeySet. Keys loaded from a public key only
+// verify tokens; they are how retired keys stay trusted after their private
+// half has been destroyed.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	// ActiveFrom is when the key starts signing. It is published and
+	// verifies tokens before then, so other services learn it in advance.
+	ActiveFrom time.Time
+
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// CanSign reports whether the key holds its private half.
+func (k *SigningKey) CanSign() bool {
+	return k.private != nil
+}
+
+// ParseSigningKey reads an RSA or Ed25519 key, private or public, from PEM.
+// RSA keys sign with RS256 and Ed25519 keys with EdDSA.
+func ParseSigningKey(id string, pemData []byte, activeFrom time.Time) (*SigningKey, error) {
+	if id == "" {
+		return nil, errors.New("signing key needs a key ID")
+	}
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %q: no PEM block found", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("signing key %q: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing key %q: %w", id, err)
+	}
+
+	key := &SigningKey{ID: id, ActiveFrom: activeFrom}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("signing key %q: only RSA and Ed25519 keys are supported", id)
+	}
+
+	if rsaKey, ok := key.public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("signing key %q: RSA keys must be at least %d bits", id, minRSAKeyBits)
+	}
+	return key, nil
+}
+
+// KeySet signs and verifies JWTs. It either holds asymmetric keys told apart
+// by their kid header, or, for deployments without key files, a single
+// HS256 secret.
+type KeySet struct {
+	// keys is ordered by ActiveFrom, oldest first.
+	keys       []*SigningKey
+	hmacSecret []byte
+}
+
+// NewHMACKeySet signs and verifies with a shared secret. Anyone able to
+// verify such tokens can also mint them, so nothing is published as JWKS.
+func NewHMACKeySet(secret string) (*KeySet, error) {
+	if secret == "" {
+		return nil, errors.New("JWT_SECRET environment variable not set")
+	}
+	return &KeySet{hmacSecret: []byte(secret)}, nil
+}
+
+// NewKeySet signs with the most recently activated key that has a private
+// half, and verifies with all of them.
+func NewKeySet(keys ...*SigningKey) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	canSign := false
+	for _, key := range keys {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("signing key ID %q is used twice", key.ID)
+		}
+		seen[key.ID] = true
+		canSign = canSign || key.CanSign()
+	}
+	if !canSign {
+		return nil, errors.New("at least one signing key needs its private key")
+	}
+
+	sorted := append([]*SigningKey(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom)
+	})
+	return &KeySet{keys: sorted}, nil
+}
+
+// LoadKeySet builds the key set described by the configuration: the PEM
+// signing keys if any are configured, the HS256 secret otherwise.
+func LoadKeySet(cfg config.AppConfig) (*KeySet, error) {
+	if len(cfg.Auth.SigningKeys) == 0 {
+		return NewHMACKeySet(cfg.JWTSecret)
+	}
+
+	keys := make([]*SigningKey, 0, len(cfg.Auth.SigningKeys))
+	for _, keyCfg := range cfg.Auth.SigningKeys {
+		pemData, err := os.ReadFile(keyCfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", keyCfg.ID, err)
+		}
+		key, err := ParseSigningKey(keyCfg.ID, pemData, keyCfg.ActiveFrom)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	keySet, err := NewKeySet(keys...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := keySet.currentKey(time.Now()); err != nil {
+		return nil, err
+	}
+	return keySet, nil
+}
+
+// currentKey is the key that signs tokens at now.
+func (s *KeySet) currentKey(now time.Time) (*SigningKey, error) {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i].CanSign() && !now.Before(s.keys[i].ActiveFrom) {
+			return s.keys[i], nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// Sign signs claims with the current key and names it in the kid header.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if s.hmacSecret != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.hmacSecret)
+	}
+
+	key, err := s.currentKey(time.Now())
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// Keyfunc finds the key a token was signed with, for jwt.Parse.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if s.hmacSecret != nil {
+		return s.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range s.keys {
+		if key.ID == kid {
+			if token.Method.Alg() != key.Method.Alg() {
+				return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+			}
+			return key.public, nil
+		}
+	}
+	return nil, ErrUnknownKeyID
+}
+
+// Methods lists the algorithms tokens may be signed with. Pass it to
+// jwt.WithValidMethods so a token cannot pick its own algorithm.
+func (s *KeySet) Methods() []string {
+	if s.hmacSecret != nil {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+
+	var methods []string
+	seen := map[string]bool{}
+	for _, key := range s.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every key, newest first. A shared HS256
+// secret is never published, so the set is then empty.
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		key := s.keys[i]
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
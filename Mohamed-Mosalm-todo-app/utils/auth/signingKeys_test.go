@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pemEncode(t *testing.T, blockType string, der []byte) []byte {
+	t.Helper()
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func newEd25519Key(t *testing.T, id string, activeFrom time.Time) (*SigningKey, ed25519.PublicKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	key, err := ParseSigningKey(id, pemEncode(t, "PRIVATE KEY", der), activeFrom)
+	require.NoError(t, err)
+	return key, public
+}
+
+func parseWith(keys *KeySet, token string) error {
+	_, err := jwt.Parse(token, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+	return err
+}
+
+func TestKeySetRotation(t *testing.T) {
+	now := time.Now()
+	old, oldPublic := newEd25519Key(t, "2024-01", now.Add(-90*24*time.Hour))
+	current, _ := newEd25519Key(t, "2024-04", now.Add(-time.Hour))
+	next, _ := newEd25519Key(t, "2024-07", now.Add(24*time.Hour))
+
+	before, err := NewKeySet(old)
+	require.NoError(t, err)
+	oldToken, err := before.Sign(jwt.MapClaims{"sub": "1"})
+	require.NoError(t, err)
+
+	// The old key's private half is gone after rotation; its public key
+	// keeps already issued tokens valid.
+	publicDER, err := x509.MarshalPKIXPublicKey(oldPublic)
+	require.NoError(t, err)
+	retired, err := ParseSigningKey("2024-01", pemEncode(t, "PUBLIC KEY", publicDER), old.ActiveFrom)
+	require.NoError(t, err)
+	assert.False(t, retired.CanSign())
+
+	keys, err := NewKeySet(next, retired, current)
+	require.NoError(t, err)
+
+	token, err := keys.Sign(jwt.MapClaims{"sub": "1"})
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2024-04", parsed.Header["kid"], "the newest active key signs, not the scheduled one")
+
+	assert.NoError(t, parseWith(keys, token))
+	assert.NoError(t, parseWith(keys, oldToken), "tokens signed with a retired key still verify")
+
+	stranger, _ := newEd25519Key(t, "2024-04", now)
+	strangerKeys, _ := NewKeySet(stranger)
+	forged, _ := strangerKeys.Sign(jwt.MapClaims{"sub": "1"})
+	assert.Error(t, parseWith(keys, forged))
+
+	unknown, _ := newEd25519Key(t, "elsewhere", now)
+	unknownKeys, _ := NewKeySet(unknown)
+	other, _ := unknownKeys.Sign(jwt.MapClaims{"sub": "1"})
+	assert.ErrorIs(t, parseWith(keys, other), ErrUnknownKeyID)
+
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 3, "scheduled keys are published ahead of time")
+	assert.Equal(t, "2024-07", jwks.Keys[0].Kid)
+	assert.Equal(t, JWK{Kty: "OKP", Kid: "2024-01", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(oldPublic)}, jwks.Keys[2])
+}
+
+func TestKeySetRejectsAlgorithmConfusion(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := ParseSigningKey("rsa", pemEncode(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private)), time.Time{})
+	require.NoError(t, err)
+	keys, err := NewKeySet(key)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"RS256"}, keys.Methods())
+
+	// An HS256 token keyed with the published public key must not verify.
+	publicPEM := pemEncode(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&private.PublicKey))
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"})
+	forged.Header["kid"] = "rsa"
+	forgedString, err := forged.SignedString(publicPEM)
+	require.NoError(t, err)
+	assert.Error(t, parseWith(keys, forgedString))
+
+	jwk := keys.JWKS().Keys[0]
+	n, _ := base64.RawURLEncoding.DecodeString(jwk.N)
+	e, _ := base64.RawURLEncoding.DecodeString(jwk.E)
+	assert.Equal(t, "RSA", jwk.Kty)
+	assert.Equal(t, 0, new(big.Int).SetBytes(n).Cmp(private.N))
+	assert.Equal(t, int64(private.E), new(big.Int).SetBytes(e).Int64())
+}
+
+func TestParseSigningKeyRejectsWeakKeys(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = ParseSigningKey("weak", pemEncode(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private)), time.Time{})
+	assert.Error(t, err)
+
+	_, err = ParseSigningKey("garbage", []byte("not a key"), time.Time{})
+	assert.Error(t, err)
+}
+
+func TestLoadKeySetFromPEMFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "current.pem")
+	require.NoError(t, os.WriteFile(path, pemEncode(t, "PRIVATE KEY", der), 0o600))
+
+	cfg := config.AppConfig{Auth: config.AuthConfig{SigningKeys: []config.SigningKeyConfig{{ID: "current", Path: path}}}}
+	keys, err := LoadKeySet(cfg)
+	require.NoError(t, err)
+
+	jwtService, err := NewJWTService(keys, testJWTOptions)
+	require.NoError(t, err)
+	userID := uuid.New()
+	challenge, err := jwtService.GenerateMFAToken(userID, time.Minute)
+	require.NoError(t, err)
+	got, err := jwtService.ParseMFAToken(challenge)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, got)
+
+	cfg.Auth.SigningKeys[0].ActiveFrom = time.Now().Add(time.Hour)
+	_, err = LoadKeySet(cfg)
+	assert.ErrorIs(t, err, ErrNoSigningKey, "some key has to be able to sign right away")
+}
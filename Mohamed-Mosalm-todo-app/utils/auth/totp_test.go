@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The SHA-1 test vectors of RFC 6238, appendix B, truncated to six digits.
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		code, err := TOTPCode(secret, time.Unix(unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "t=%d", unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	previous, _ := TOTPCode(secret, now.Add(-30*time.Second))
+	counter, ok := ValidateTOTP(secret, previous, now, 0)
+	assert.True(t, ok, "one step of clock drift is tolerated")
+
+	_, ok = ValidateTOTP(secret, previous, now, counter)
+	assert.False(t, ok, "a code cannot be used twice")
+
+	stale, _ := TOTPCode(secret, now.Add(-2*time.Minute))
+	_, ok = ValidateTOTP(secret, stale, now, 0)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Todo App", "jane@example.com", "JBSWY3DPEHPK3PXP")
+	assert.Equal(t, "otpauth://totp/Todo%20App:jane@example.com?algorithm=SHA1&digits=6&issuer=Todo+App&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
@@ -0,0 +1,139 @@
+// Package directory checks passwords against an LDAP directory such as
+// OpenLDAP or Active Directory.
+package directory
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ErrInvalidCredentials means no single entry matches the email address or
+// the directory refused its password.
+var ErrInvalidCredentials = errors.New("invalid directory credentials")
+
+// Entry is what the application uses from a user's directory entry.
+type Entry struct {
+	DN        string
+	Email     string
+	FirstName string
+	LastName  string
+	// Groups are the DNs of the groups the user is a member of.
+	Groups []string
+}
+
+// Directory authenticates users with search and bind: it finds the user's
+// entry with the service account and binds as the entry with the password
+// given. Every call opens its own connection.
+type Directory struct {
+	cfg config.LDAPConfig
+}
+
+func NewDirectory(cfg config.LDAPConfig) *Directory {
+	return &Directory{cfg: cfg}
+}
+
+// Authenticate returns the entry of the user with the given email address if
+// password is theirs.
+func (d *Directory) Authenticate(ctx context.Context, email, password string) (*Entry, error) {
+	// An empty password would make the bind unauthenticated, which
+	// directories accept.
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.Bind(d.cfg.BindDN, d.cfg.BindPassword); err != nil {
+		return nil, fmt.Errorf("binding as the LDAP service account: %w", err)
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		d.cfg.UserBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(d.cfg.UserFilter, ldap.EscapeFilter(email)),
+		[]string{"mail", "givenName", "sn", "memberOf"}, nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("searching for the LDAP user: %w", err)
+	}
+	if result == nil || len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	userEntry := result.Entries[0]
+
+	entry := &Entry{
+		DN:        userEntry.DN,
+		Email:     userEntry.GetAttributeValue("mail"),
+		FirstName: userEntry.GetAttributeValue("givenName"),
+		LastName:  userEntry.GetAttributeValue("sn"),
+		Groups:    userEntry.GetAttributeValues("memberOf"),
+	}
+	// Groups are looked up while still bound as the service account, which
+	// may read more than the user can.
+	if d.cfg.GroupBaseDN != "" {
+		if entry.Groups, err = d.groups(conn, entry.DN); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("binding as the LDAP user: %w", err)
+	}
+	return entry, nil
+}
+
+func (d *Directory) groups(conn *ldap.Conn, userDN string) ([]string, error) {
+	result, err := conn.Search(ldap.NewSearchRequest(
+		d.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(d.cfg.GroupFilter, ldap.EscapeFilter(userDN)),
+		[]string{"1.1"}, nil, // no attributes, just the DNs
+	))
+	if err != nil {
+		return nil, fmt.Errorf("searching for the LDAP user's groups: %w", err)
+	}
+	groups := make([]string, len(result.Entries))
+	for i, group := range result.Entries {
+		groups[i] = group.DN
+	}
+	return groups, nil
+}
+
+func (d *Directory) connect(ctx context.Context) (*ldap.Conn, error) {
+	serverURL, err := url.Parse(d.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing LDAP_URL: %w", err)
+	}
+	tlsConfig := &tls.Config{ServerName: serverURL.Hostname()}
+
+	dialer := &net.Dialer{Timeout: d.cfg.Timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	conn, err := ldap.DialURL(d.cfg.URL, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the LDAP server: %w", err)
+	}
+	conn.SetTimeout(d.cfg.Timeout)
+
+	if d.cfg.StartTLS && strings.HasPrefix(d.cfg.URL, "ldap://") {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("starting TLS with the LDAP server: %w", err)
+		}
+	}
+	return conn, nil
+}
@@ -0,0 +1,67 @@
+package directory
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(url string) config.LDAPConfig {
+	return config.LDAPConfig{
+		URL:          url,
+		Timeout:      5 * time.Second,
+		BindDN:       "cn=admin,dc=example,dc=org",
+		BindPassword: "admin",
+		UserBaseDN:   "ou=people,dc=example,dc=org",
+		UserFilter:   "(mail=%s)",
+		GroupBaseDN:  "ou=groups,dc=example,dc=org",
+		GroupFilter:  "(member=%s)",
+	}
+}
+
+func TestEmptyPasswordIsRefused(t *testing.T) {
+	// Nothing listens there; the password is refused before connecting.
+	directory := NewDirectory(testConfig("ldap://127.0.0.1:1"))
+	_, err := directory.Authenticate(context.Background(), "jane@example.org", "")
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+// TestDirectoryAgainstOpenLDAP runs against the OpenLDAP container, which
+// loads testdata/seed.ldif:
+//
+//	docker compose up -d openldap
+//	LDAP_TEST_URL=ldap://localhost:389 go test ./utils/directory/
+func TestDirectoryAgainstOpenLDAP(t *testing.T) {
+	url := os.Getenv("LDAP_TEST_URL")
+	if url == "" {
+		t.Skip("LDAP_TEST_URL not set")
+	}
+	ctx := context.Background()
+	directory := NewDirectory(testConfig(url))
+
+	entry, err := directory.Authenticate(ctx, "jane@example.org", "jane-password")
+	require.NoError(t, err)
+	assert.Equal(t, &Entry{
+		DN:        "uid=jane,ou=people,dc=example,dc=org",
+		Email:     "jane@example.org",
+		FirstName: "Jane",
+		LastName:  "Doe",
+		Groups:    []string{"cn=admins,ou=groups,dc=example,dc=org"},
+	}, entry)
+
+	entry, err = directory.Authenticate(ctx, "john@example.org", "john-password")
+	require.NoError(t, err)
+	assert.Empty(t, entry.Groups)
+
+	_, err = directory.Authenticate(ctx, "john@example.org", "jane-password")
+	assert.Equal(t, ErrInvalidCredentials, err)
+	_, err = directory.Authenticate(ctx, "nobody@example.org", "jane-password")
+	assert.Equal(t, ErrInvalidCredentials, err)
+	_, err = directory.Authenticate(ctx, "*", "jane-password")
+	assert.Equal(t, ErrInvalidCredentials, err, "the email is escaped in the filter")
+}
@@ -7,18 +7,89 @@ type AppError struct {
 	Message string
 	Details error
 	Status  int
+	// Fields maps request fields to what is wrong with each.
+	Fields map[string][]string
 }
 
 func (e *AppError) Error() string {
 	return e.Message
 }
 
+// WithFields returns a copy of e carrying field-level reasons, leaving the
+// shared error value untouched.
+func (e *AppError) WithFields(fields map[string][]string) *AppError {
+	appErr := *e
+	appErr.Fields = fields
+	return &appErr
+}
+
 // Auth Errors
 var ErrInvalidCredentials = &AppError{Code: "INVALID_CREDENTIALS", Message: "Invalid email or password", Status: http.StatusUnauthorized}
 var ErrUserExists = &AppError{Code: "USER_EXISTS", Message: "User with this email already exists", Status: http.StatusConflict}
 var ErrRegistrationFailed = &AppError{Code: "REGISTRATION_FAILED", Message: "Failed to register user", Status: http.StatusInternalServerError}
 var ErrUserNotFound = &AppError{Code: "USER_NOT_FOUND", Message: "User not found", Status: http.StatusNotFound}
 var ErrTokenGenerationFailed = &AppError{Code: "TOKEN_GENERATION_FAILED", Message: "Failed to generate access token", Status: http.StatusInternalServerError}
+var ErrInvalidRefreshToken = &AppError{Code: "INVALID_REFRESH_TOKEN", Message: "Refresh token is invalid or expired", Status: http.StatusUnauthorized}
+var ErrTokenExpired = &AppError{Code: "TOKEN_EXPIRED", Message: "Access token has expired", Status: http.StatusUnauthorized}
+var ErrTokenMalformed = &AppError{Code: "TOKEN_MALFORMED", Message: "Access token is malformed", Status: http.StatusUnauthorized}
+var ErrTokenSignatureInvalid = &AppError{Code: "TOKEN_SIGNATURE_INVALID", Message: "Access token signature is invalid", Status: http.StatusUnauthorized}
+var ErrTokenClaimsInvalid = &AppError{Code: "TOKEN_CLAIMS_INVALID", Message: "Access token is not valid for this service", Status: http.StatusUnauthorized}
+var ErrAccountNotFound = &AppError{Code: "ACCOUNT_NOT_FOUND", Message: "Account no longer exists", Status: http.StatusUnauthorized}
+var ErrAccountDeactivated = &AppError{Code: "ACCOUNT_DEACTIVATED", Message: "Account has been deactivated", Status: http.StatusForbidden}
+var ErrTokenRevoked = &AppError{Code: "TOKEN_REVOKED", Message: "Token has been revoked", Status: http.StatusUnauthorized}
+var ErrRevocationCheckFailed = &AppError{Code: "REVOCATION_CHECK_FAILED", Message: "Failed to verify token", Status: http.StatusInternalServerError}
+var ErrUserLookupFailed = &AppError{Code: "USER_LOOKUP_FAILED", Message: "Failed to look up the account", Status: http.StatusInternalServerError}
+var ErrLogoutFailed = &AppError{Code: "LOGOUT_FAILED", Message: "Failed to log out", Status: http.StatusInternalServerError}
+var ErrSessionRevoked = &AppError{Code: "SESSION_REVOKED", Message: "Session has been revoked", Status: http.StatusUnauthorized}
+var ErrInvalidSessionID = &AppError{Code: "INVALID_SESSION_ID", Message: "Invalid session ID", Status: http.StatusBadRequest}
+var ErrSessionNotFound = &AppError{Code: "SESSION_NOT_FOUND", Message: "Session not found", Status: http.StatusNotFound}
+var ErrFetchSessionsFailed = &AppError{Code: "FETCH_SESSIONS_FAILED", Message: "Failed to retrieve sessions", Status: http.StatusInternalServerError}
+var ErrRevokeSessionFailed = &AppError{Code: "REVOKE_SESSION_FAILED", Message: "Failed to revoke session", Status: http.StatusInternalServerError}
+var ErrInvalidResetToken = &AppError{Code: "INVALID_RESET_TOKEN", Message: "Password reset link is invalid or has expired", Status: http.StatusBadRequest}
+var ErrPasswordResetFailed = &AppError{Code: "PASSWORD_RESET_FAILED", Message: "Failed to reset password", Status: http.StatusInternalServerError}
+var ErrPasswordTooLong = &AppError{Code: "PASSWORD_TOO_LONG", Message: "Password must not be longer than 72 bytes", Status: http.StatusBadRequest}
+var ErrWeakPassword = &AppError{Code: "WEAK_PASSWORD", Message: "Password does not meet the password policy", Status: http.StatusBadRequest}
+var ErrPasswordPolicyCheckFailed = &AppError{Code: "PASSWORD_POLICY_CHECK_FAILED", Message: "Failed to check password", Status: http.StatusInternalServerError}
+var ErrIncorrectPassword = &AppError{Code: "INCORRECT_PASSWORD", Message: "Current password is incorrect", Status: http.StatusBadRequest}
+var ErrReauthenticationRequired = &AppError{Code: "REAUTHENTICATION_REQUIRED", Message: "Confirm it is you with an authentication code or a passkey", Status: http.StatusBadRequest}
+var ErrChangePasswordFailed = &AppError{Code: "CHANGE_PASSWORD_FAILED", Message: "Failed to change password", Status: http.StatusInternalServerError}
+var ErrEmailNotVerified = &AppError{Code: "EMAIL_NOT_VERIFIED", Message: "Please verify your email address first", Status: http.StatusForbidden}
+var ErrInvalidVerificationToken = &AppError{Code: "INVALID_VERIFICATION_TOKEN", Message: "Verification link is invalid or has expired", Status: http.StatusBadRequest}
+var ErrEmailVerificationFailed = &AppError{Code: "EMAIL_VERIFICATION_FAILED", Message: "Failed to verify email address", Status: http.StatusInternalServerError}
+var ErrRefreshTokenReused = &AppError{Code: "REFRESH_TOKEN_REUSED", Message: "Refresh token was already used; please log in again", Status: http.StatusUnauthorized}
+var ErrMFAAlreadyEnabled = &AppError{Code: "MFA_ALREADY_ENABLED", Message: "Two-factor authentication is already enabled", Status: http.StatusConflict}
+var ErrMFANotEnrolled = &AppError{Code: "MFA_NOT_ENROLLED", Message: "Two-factor authentication is not set up", Status: http.StatusBadRequest}
+var ErrInvalidMFACode = &AppError{Code: "INVALID_MFA_CODE", Message: "Invalid authentication code", Status: http.StatusUnauthorized}
+var ErrInvalidMFAToken = &AppError{Code: "INVALID_MFA_TOKEN", Message: "Two-factor challenge is invalid or has expired; please log in again", Status: http.StatusUnauthorized}
+var ErrMFAFailed = &AppError{Code: "MFA_FAILED", Message: "Failed to process two-factor authentication", Status: http.StatusInternalServerError}
+var ErrAccountLocked = &AppError{Code: "ACCOUNT_LOCKED", Message: "Too many failed login attempts; try again later", Status: http.StatusTooManyRequests}
+var ErrLoginAttemptCheckFailed = &AppError{Code: "LOGIN_ATTEMPT_CHECK_FAILED", Message: "Failed to check login attempts", Status: http.StatusInternalServerError}
+var ErrAuthenticationUnavailable = &AppError{Code: "AUTHENTICATION_UNAVAILABLE", Message: "Could not check the credentials; please try again later", Status: http.StatusBadGateway}
+var ErrTooManyMagicLinks = &AppError{Code: "TOO_MANY_MAGIC_LINKS", Message: "Too many sign-in links were requested for this email; try again later", Status: http.StatusTooManyRequests}
+var ErrInvalidMagicLink = &AppError{Code: "INVALID_MAGIC_LINK", Message: "Sign-in link is invalid, expired or already used", Status: http.StatusBadRequest}
+var ErrMagicLinkWrongDevice = &AppError{Code: "MAGIC_LINK_WRONG_DEVICE", Message: "Open the sign-in link in the browser you requested it from", Status: http.StatusForbidden}
+var ErrMagicLinkFailed = &AppError{Code: "MAGIC_LINK_FAILED", Message: "Failed to sign in with the link", Status: http.StatusInternalServerError}
+var ErrInvalidPasskeyCeremony = &AppError{Code: "INVALID_PASSKEY_CEREMONY", Message: "Passkey challenge is invalid or has expired; please start again", Status: http.StatusBadRequest}
+var ErrPasskeyRejected = &AppError{Code: "PASSKEY_REJECTED", Message: "Passkey was not accepted", Status: http.StatusUnauthorized}
+var ErrNoPasskeys = &AppError{Code: "NO_PASSKEYS", Message: "No passkeys are registered", Status: http.StatusBadRequest}
+var ErrInvalidPasskeyID = &AppError{Code: "INVALID_PASSKEY_ID", Message: "Invalid passkey ID", Status: http.StatusBadRequest}
+var ErrPasskeyNotFound = &AppError{Code: "PASSKEY_NOT_FOUND", Message: "Passkey not found", Status: http.StatusNotFound}
+var ErrPasskeyFailed = &AppError{Code: "PASSKEY_FAILED", Message: "Failed to process the passkey", Status: http.StatusInternalServerError}
+var ErrUnknownOIDCProvider = &AppError{Code: "UNKNOWN_OIDC_PROVIDER", Message: "Unknown identity provider", Status: http.StatusNotFound}
+var ErrInvalidOIDCState = &AppError{Code: "INVALID_OIDC_STATE", Message: "Sign-in is invalid or has expired; please start again", Status: http.StatusBadRequest}
+var ErrOIDCLoginFailed = &AppError{Code: "OIDC_LOGIN_FAILED", Message: "Sign-in with the identity provider failed", Status: http.StatusUnauthorized}
+var ErrOIDCEmailNotVerified = &AppError{Code: "OIDC_EMAIL_NOT_VERIFIED", Message: "The identity provider did not share a verified email address", Status: http.StatusForbidden}
+var ErrOIDCProviderUnavailable = &AppError{Code: "OIDC_PROVIDER_UNAVAILABLE", Message: "Could not reach the identity provider", Status: http.StatusBadGateway}
+var ErrInvalidAPIKey = &AppError{Code: "INVALID_API_KEY", Message: "API key is invalid, expired or revoked", Status: http.StatusUnauthorized}
+var ErrAPIKeyCheckFailed = &AppError{Code: "API_KEY_CHECK_FAILED", Message: "Failed to verify API key", Status: http.StatusInternalServerError}
+var ErrInsufficientScope = &AppError{Code: "INSUFFICIENT_SCOPE", Message: "API key does not have the scope required for this request", Status: http.StatusForbidden}
+var ErrInvalidAPIKeyID = &AppError{Code: "INVALID_API_KEY_ID", Message: "Invalid API key ID", Status: http.StatusBadRequest}
+var ErrInvalidAPIKeyScope = &AppError{Code: "INVALID_API_KEY_SCOPE", Message: "Unknown API key scope", Status: http.StatusBadRequest}
+var ErrAPIKeyExpiryPassed = &AppError{Code: "INVALID_API_KEY_EXPIRY", Message: "API key expiry must be in the future", Status: http.StatusBadRequest}
+var ErrAPIKeyNotFound = &AppError{Code: "API_KEY_NOT_FOUND", Message: "API key not found", Status: http.StatusNotFound}
+var ErrCreateAPIKeyFailed = &AppError{Code: "CREATE_API_KEY_FAILED", Message: "Failed to create API key", Status: http.StatusInternalServerError}
+var ErrFetchAPIKeysFailed = &AppError{Code: "FETCH_API_KEYS_FAILED", Message: "Failed to retrieve API keys", Status: http.StatusInternalServerError}
+var ErrRevokeAPIKeyFailed = &AppError{Code: "REVOKE_API_KEY_FAILED", Message: "Failed to revoke API key", Status: http.StatusInternalServerError}
 
 // Task Errors
 var ErrInvalidTaskID = &AppError{Code: "INVALID_TASK_ID", Message: "Invalid task ID", Status: http.StatusBadRequest}
@@ -27,6 +98,81 @@ var ErrCreateTaskFailed = &AppError{Code: "CREATE_FAILED", Message: "Failed to c
 var ErrFetchTasksFailed = &AppError{Code: "FETCH_ERROR", Message: "Failed to retrieve tasks", Status: http.StatusInternalServerError}
 var ErrUpdateTaskFailed = &AppError{Code: "UPDATE_FAILED", Message: "Failed to update task", Status: http.StatusInternalServerError}
 var ErrDeleteTaskFailed = &AppError{Code: "DELETE_FAILED", Message: "Failed to delete task", Status: http.StatusInternalServerError}
+var ErrInvalidCustomField = &AppError{Code: "INVALID_CUSTOM_FIELD", Message: "Invalid custom field", Status: http.StatusBadRequest}
+var ErrInvalidSort = &AppError{Code: "INVALID_SORT", Message: "Tasks cannot be sorted by this field", Status: http.StatusBadRequest}
+var ErrParentTaskNotFound = &AppError{Code: "PARENT_TASK_NOT_FOUND", Message: "Parent task not found", Status: http.StatusNotFound}
+
+// Template Errors
+var ErrInvalidTemplateID = &AppError{Code: "INVALID_TEMPLATE_ID", Message: "Invalid template ID", Status: http.StatusBadRequest}
+var ErrTemplateNotFound = &AppError{Code: "TEMPLATE_NOT_FOUND", Message: "Template not found", Status: http.StatusNotFound}
+var ErrCreateTemplateFailed = &AppError{Code: "CREATE_TEMPLATE_FAILED", Message: "Failed to create template", Status: http.StatusInternalServerError}
+var ErrFetchTemplatesFailed = &AppError{Code: "FETCH_TEMPLATES_FAILED", Message: "Failed to retrieve templates", Status: http.StatusInternalServerError}
+var ErrUpdateTemplateFailed = &AppError{Code: "UPDATE_TEMPLATE_FAILED", Message: "Failed to update template", Status: http.StatusInternalServerError}
+var ErrDeleteTemplateFailed = &AppError{Code: "DELETE_TEMPLATE_FAILED", Message: "Failed to delete template", Status: http.StatusInternalServerError}
+var ErrMissingTemplateVariable = &AppError{Code: "MISSING_TEMPLATE_VARIABLE", Message: "Missing template variable", Status: http.StatusBadRequest}
+var ErrInvalidTemplateTitle = &AppError{Code: "INVALID_TEMPLATE_TITLE", Message: "Rendered task title is empty or too long", Status: http.StatusBadRequest}
+var ErrInvalidAnchorDate = &AppError{Code: "INVALID_ANCHOR_DATE", Message: "Invalid anchor date", Status: http.StatusBadRequest}
+var ErrInstantiateTemplateFailed = &AppError{Code: "INSTANTIATE_FAILED", Message: "Failed to instantiate template", Status: http.StatusInternalServerError}
+
+// Filter Errors
+var ErrInvalidFilterID = &AppError{Code: "INVALID_FILTER_ID", Message: "Invalid filter ID", Status: http.StatusBadRequest}
+var ErrFilterNotFound = &AppError{Code: "FILTER_NOT_FOUND", Message: "Filter not found", Status: http.StatusNotFound}
+var ErrInvalidFilterQuery = &AppError{Code: "INVALID_FILTER_QUERY", Message: "Invalid filter query", Status: http.StatusBadRequest}
+var ErrCreateFilterFailed = &AppError{Code: "CREATE_FILTER_FAILED", Message: "Failed to create filter", Status: http.StatusInternalServerError}
+var ErrFetchFiltersFailed = &AppError{Code: "FETCH_FILTERS_FAILED", Message: "Failed to retrieve filters", Status: http.StatusInternalServerError}
+var ErrUpdateFilterFailed = &AppError{Code: "UPDATE_FILTER_FAILED", Message: "Failed to update filter", Status: http.StatusInternalServerError}
+var ErrDeleteFilterFailed = &AppError{Code: "DELETE_FILTER_FAILED", Message: "Failed to delete filter", Status: http.StatusInternalServerError}
+
+// Attachment Errors
+var ErrInvalidAttachmentID = &AppError{Code: "INVALID_ATTACHMENT_ID", Message: "Invalid attachment ID", Status: http.StatusBadRequest}
+var ErrAttachmentNotFound = &AppError{Code: "ATTACHMENT_NOT_FOUND", Message: "Attachment not found", Status: http.StatusNotFound}
+var ErrMissingFile = &AppError{Code: "MISSING_FILE", Message: "Request has no file part", Status: http.StatusBadRequest}
+var ErrAttachmentTooLarge = &AppError{Code: "ATTACHMENT_TOO_LARGE", Message: "Attachment exceeds the maximum size", Status: http.StatusRequestEntityTooLarge}
+var ErrMIMETypeNotAllowed = &AppError{Code: "MIME_TYPE_NOT_ALLOWED", Message: "Attachment type is not allowed", Status: http.StatusUnsupportedMediaType}
+var ErrQuotaExceeded = &AppError{Code: "QUOTA_EXCEEDED", Message: "Attachment storage quota exceeded", Status: http.StatusRequestEntityTooLarge}
+var ErrUploadFailed = &AppError{Code: "UPLOAD_FAILED", Message: "Failed to upload attachment", Status: http.StatusInternalServerError}
+var ErrFetchAttachmentsFailed = &AppError{Code: "FETCH_ATTACHMENTS_FAILED", Message: "Failed to retrieve attachments", Status: http.StatusInternalServerError}
+var ErrDownloadFailed = &AppError{Code: "DOWNLOAD_FAILED", Message: "Failed to download attachment", Status: http.StatusInternalServerError}
+var ErrDeleteAttachmentFailed = &AppError{Code: "DELETE_ATTACHMENT_FAILED", Message: "Failed to delete attachment", Status: http.StatusInternalServerError}
+
+// Project Errors
+var ErrInvalidProjectID = &AppError{Code: "INVALID_PROJECT_ID", Message: "Invalid project ID", Status: http.StatusBadRequest}
+var ErrProjectNotFound = &AppError{Code: "PROJECT_NOT_FOUND", Message: "Project not found", Status: http.StatusNotFound}
+var ErrCreateProjectFailed = &AppError{Code: "CREATE_PROJECT_FAILED", Message: "Failed to create project", Status: http.StatusInternalServerError}
+var ErrFetchProjectsFailed = &AppError{Code: "FETCH_PROJECTS_FAILED", Message: "Failed to retrieve projects", Status: http.StatusInternalServerError}
+var ErrUpdateColumnsFailed = &AppError{Code: "UPDATE_COLUMNS_FAILED", Message: "Failed to update board columns", Status: http.StatusInternalServerError}
+var ErrInvalidColumns = &AppError{Code: "INVALID_COLUMNS", Message: "Invalid board columns", Status: http.StatusBadRequest}
+var ErrColumnNotEmpty = &AppError{Code: "COLUMN_NOT_EMPTY", Message: "Cannot remove a column that still holds tasks", Status: http.StatusConflict}
+var ErrUnknownState = &AppError{Code: "UNKNOWN_STATE", Message: "State does not match any board column", Status: http.StatusBadRequest}
+var ErrNoColumnForStatus = &AppError{Code: "NO_COLUMN_FOR_STATUS", Message: "The project board has no column for this status", Status: http.StatusConflict}
+var ErrWIPLimitExceeded = &AppError{Code: "WIP_LIMIT_EXCEEDED", Message: "Column work-in-progress limit reached", Status: http.StatusConflict}
+var ErrUpdateFieldsFailed = &AppError{Code: "UPDATE_FIELDS_FAILED", Message: "Failed to update custom fields", Status: http.StatusInternalServerError}
+var ErrMoveTaskFailed = &AppError{Code: "MOVE_FAILED", Message: "Failed to move task", Status: http.StatusInternalServerError}
+
+// Admin Errors
+var ErrPermissionDenied = &AppError{Code: "PERMISSION_DENIED", Message: "Your role does not allow this request", Status: http.StatusForbidden}
+var ErrPermissionCheckFailed = &AppError{Code: "PERMISSION_CHECK_FAILED", Message: "Failed to check permissions", Status: http.StatusInternalServerError}
+var ErrImpersonationForbidden = &AppError{Code: "IMPERSONATION_FORBIDDEN", Message: "This action is not allowed while impersonating a user", Status: http.StatusForbidden}
+var ErrCannotImpersonate = &AppError{Code: "CANNOT_IMPERSONATE", Message: "You cannot impersonate this user", Status: http.StatusForbidden}
+var ErrImpersonationFailed = &AppError{Code: "IMPERSONATION_FAILED", Message: "Failed to impersonate user", Status: http.StatusInternalServerError}
+var ErrAuditFailed = &AppError{Code: "AUDIT_FAILED", Message: "Failed to write the audit log", Status: http.StatusInternalServerError}
+var ErrFetchAuditFailed = &AppError{Code: "FETCH_AUDIT_FAILED", Message: "Failed to retrieve the audit log", Status: http.StatusInternalServerError}
+var ErrFetchUsersFailed = &AppError{Code: "FETCH_USERS_FAILED", Message: "Failed to retrieve users", Status: http.StatusInternalServerError}
+var ErrCannotDeactivateSelf = &AppError{Code: "CANNOT_DEACTIVATE_SELF", Message: "You cannot deactivate your own account", Status: http.StatusConflict}
+var ErrCannotManageUser = &AppError{Code: "CANNOT_MANAGE_USER", Message: "You cannot manage a user whose role grants more than yours", Status: http.StatusForbidden}
+var ErrCannotChangeOwnRole = &AppError{Code: "CANNOT_CHANGE_OWN_ROLE", Message: "You cannot change your own role", Status: http.StatusConflict}
+var ErrReactivateUserFailed = &AppError{Code: "REACTIVATE_USER_FAILED", Message: "Failed to reactivate user", Status: http.StatusInternalServerError}
+var ErrForcePasswordResetFailed = &AppError{Code: "FORCE_PASSWORD_RESET_FAILED", Message: "Failed to reset the user's password", Status: http.StatusInternalServerError}
+var ErrRevokeSessionsFailed = &AppError{Code: "REVOKE_SESSIONS_FAILED", Message: "Failed to revoke the user's sessions", Status: http.StatusInternalServerError}
+var ErrChangeRoleFailed = &AppError{Code: "CHANGE_ROLE_FAILED", Message: "Failed to change the user's role", Status: http.StatusInternalServerError}
+var ErrDeactivateUserFailed = &AppError{Code: "DEACTIVATE_USER_FAILED", Message: "Failed to deactivate user", Status: http.StatusInternalServerError}
+var ErrInvalidRoleName = &AppError{Code: "INVALID_ROLE_NAME", Message: "Role names are 1 to 32 lowercase letters, digits, - or _, starting with a letter", Status: http.StatusBadRequest}
+var ErrUnknownPermission = &AppError{Code: "UNKNOWN_PERMISSION", Message: "Unknown permission", Status: http.StatusBadRequest}
+var ErrRoleNotFound = &AppError{Code: "ROLE_NOT_FOUND", Message: "Role not found", Status: http.StatusNotFound}
+var ErrRoleExists = &AppError{Code: "ROLE_EXISTS", Message: "A role with this name already exists", Status: http.StatusConflict}
+var ErrBuiltInRole = &AppError{Code: "BUILT_IN_ROLE", Message: "Built-in roles cannot be changed", Status: http.StatusConflict}
+var ErrRoleInUse = &AppError{Code: "ROLE_IN_USE", Message: "Role is still assigned to users", Status: http.StatusConflict}
+var ErrRoleFailed = &AppError{Code: "ROLE_FAILED", Message: "Failed to save the role", Status: http.StatusInternalServerError}
 
 // General Errors
 var ErrInvalidRequest = &AppError{Code: "INVALID_REQUEST", Message: "Invalid request body", Status: http.StatusBadRequest}
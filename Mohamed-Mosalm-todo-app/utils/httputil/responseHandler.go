@@ -12,8 +12,10 @@ func HandleError(c *gin.Context, err *errors.AppError) {
 	resp := response.Response{
 		Status: "error",
 		Error: &response.ErrorInfo{
+			Code:    err.Code,
 			Message: err.Message,
 			Details: "",
+			Fields:  err.Fields,
 		},
 	}
 	if err.Details != nil {
@@ -0,0 +1,27 @@
+package httputil
+
+import (
+	"strconv"
+
+	"github.com/MohamedMosalm/Todo-App/utils/response"
+	"github.com/gin-gonic/gin"
+)
+
+const scimContentType = "application/scim+json; charset=utf-8"
+
+// SendSCIM responds with a SCIM resource or message.
+func SendSCIM(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, body)
+}
+
+// HandleSCIMError responds with a SCIM error. scimType is one of the error
+// types of RFC 7644, e.g. "uniqueness", or empty.
+func HandleSCIMError(c *gin.Context, status int, scimType, detail string) {
+	SendSCIM(c, status, response.SCIMError{
+		Schemas:  []string{"urn:ietf:params:scim:api:messages:2.0:Error"},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+)
+
+type logMailer struct {
+	from string
+}
+
+// NewLogMailer writes messages to the application log instead of sending
+// them, which is enough to follow emailed links during local development.
+func NewLogMailer(from string) Mailer {
+	return &logMailer{from: from}
+}
+
+func (m *logMailer) Send(_ context.Context, msg Message) error {
+	log.Printf("mail to %s\n%s", msg.To, format(m.from, msg))
+	return nil
+}
+
+type fileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+// NewFileMailer appends every message to the file at path, separated by
+// blank lines, so tests and local setups can read what would have been sent.
+func NewFileMailer(path, from string) (Mailer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return &fileMailer{path: path, from: from}, nil
+}
+
+func (m *fileMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(format(m.from, msg), "\r\n\r\n"...)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+func NewMailer(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		return NewFileMailer(cfg.FilePath, cfg.From)
+	default:
+		return NewLogMailer(cfg.From), nil
+	}
+}
+
+// asyncMailer sends on a background goroutine so request handlers never
+// wait on, or reveal through their timing, the delivery of a message.
+type asyncMailer struct {
+	mailer  Mailer
+	timeout time.Duration
+}
+
+// NewAsyncMailer wraps m so that Send returns immediately. Delivery errors
+// are logged rather than returned.
+func NewAsyncMailer(m Mailer) Mailer {
+	return &asyncMailer{mailer: m, timeout: 30 * time.Second}
+}
+
+func (m *asyncMailer) Send(_ context.Context, msg Message) error {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		defer cancel()
+		if err := m.mailer.Send(ctx, msg); err != nil {
+			log.Printf("failed to send %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+	return nil
+}
+
+// format renders msg as an RFC 5322 message. Header values are stripped of
+// line breaks so user-controlled input cannot inject headers.
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(msg.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
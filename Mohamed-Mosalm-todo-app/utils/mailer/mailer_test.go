@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m, err := NewFileMailer(path, "Todo App <no-reply@example.com>")
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Reset your password\r\nBcc: attacker@example.com",
+		Body:    "Follow the link:\nhttps://example.com/reset",
+	}))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	text := string(content)
+	assert.Contains(t, text, "To: user@example.com\r\n")
+	assert.Contains(t, text, "Follow the link:\r\nhttps://example.com/reset")
+	for _, line := range strings.Split(text, "\r\n") {
+		assert.False(t, strings.HasPrefix(line, "Bcc:"), "header injection through the subject")
+	}
+}
@@ -1,18 +1,23 @@
 package middleware
 
 import (
-	"fmt"
 	"strings"
-	"time"
 
+	"github.com/MohamedMosalm/Todo-App/models"
+	revocationRepository "github.com/MohamedMosalm/Todo-App/repositories/revocationRepository"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/auth"
 	"github.com/MohamedMosalm/Todo-App/utils/errors"
 	"github.com/MohamedMosalm/Todo-App/utils/httputil"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware admits requests with a valid access token. Requests made
+// with an impersonation token are written to the audit log and marked with
+// the ImpersonatedByHeader.
+func AuthMiddleware(jwtService *auth.JWTService, revocations revocationRepository.RevocationStore, sessions services.SessionService, users services.UserService, audit services.AuditService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,59 +33,111 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(jwtSecret), nil
-		})
-
+		claims, err := jwtService.ValidateToken(parts[1])
 		if err != nil {
-			appErr := errors.ErrUnauthorized
+			appErr := tokenError(err)
 			appErr.Details = err
 			httputil.HandleError(c, appErr)
 			c.Abort()
 			return
 		}
+		userID, sessionID := claims.UserID, claims.SessionID
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			httputil.HandleError(c, errors.ErrUnauthorized)
+		revoked, err := revocations.IsRevoked(claims.ID, userID, claims.IssuedAt.Time)
+		if err != nil {
+			appErr := errors.ErrRevocationCheckFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
 			c.Abort()
 			return
 		}
-
-		exp, ok := claims["exp"].(float64)
-		if !ok {
-			httputil.HandleError(c, errors.ErrUnauthorized)
+		if revoked {
+			httputil.HandleError(c, errors.ErrTokenRevoked)
 			c.Abort()
 			return
 		}
 
-		if float64(time.Now().Unix()) > exp {
-			httputil.HandleError(c, errors.ErrUnauthorized)
-			c.Abort()
-			return
+		// An impersonation token rides on the admin's session, and the admin
+		// must still be allowed in too.
+		sessionUserID := userID
+		if claims.Actor != nil {
+			sessionUserID = claims.Actor.Subject
 		}
-
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			httputil.HandleError(c, errors.ErrUnauthorized)
+		if err := sessions.ValidateSession(sessionID, sessionUserID); err != nil {
+			if err == services.ErrSessionRevoked || err == services.ErrSessionNotFound {
+				httputil.HandleError(c, errors.ErrSessionRevoked)
+				c.Abort()
+				return
+			}
+			appErr := errors.ErrRevocationCheckFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
 			c.Abort()
 			return
 		}
+		if claims.Actor != nil {
+			if _, ok := checkUser(c, users, claims.Actor.Subject); !ok {
+				c.Abort()
+				return
+			}
+		}
 
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			appErr := errors.ErrUnauthorized
-			appErr.Details = err
-			httputil.HandleError(c, appErr)
+		// Deleting an account does not revoke its tokens, so check that the
+		// user is still there and active.
+		user, ok := checkUser(c, users, userID)
+		if !ok {
 			c.Abort()
 			return
 		}
 
 		c.Set("user_id", userID.String())
+		c.Set("session_id", sessionID.String())
+		c.Set("jti", claims.ID)
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+		c.Set("user_role", user.Role)
+		if claims.Actor != nil {
+			c.Set("actor_id", claims.Actor.Subject.String())
+			if !recordImpersonation(c, audit, claims.Actor.Subject, userID) {
+				c.Abort()
+				return
+			}
+		}
 		c.Next()
 	}
 }
+
+// tokenError maps JWTService.ValidateToken errors to responses, so clients
+// can tell a token worth refreshing from a broken one.
+func tokenError(err error) *errors.AppError {
+	switch err {
+	case auth.ErrTokenExpired:
+		return errors.ErrTokenExpired
+	case auth.ErrTokenMalformed:
+		return errors.ErrTokenMalformed
+	case auth.ErrTokenSignatureInvalid:
+		return errors.ErrTokenSignatureInvalid
+	default:
+		return errors.ErrTokenClaimsInvalid
+	}
+}
+
+// checkUser responds with an error unless the user exists and is active,
+// and reports whether the request may go ahead.
+func checkUser(c *gin.Context, users services.UserService, userID uuid.UUID) (*models.User, bool) {
+	user, err := users.FindUserByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.HandleError(c, errors.ErrAccountNotFound)
+			return nil, false
+		}
+		appErr := errors.ErrUserLookupFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return nil, false
+	}
+	if !user.IsActive() {
+		httputil.HandleError(c, errors.ErrAccountDeactivated)
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"github.com/MohamedMosalm/Todo-App/models"
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ImpersonatedByHeader carries the ID of the admin on every response to a
+// request made with an impersonation token, so clients can show that
+// someone else is acting as the user.
+const ImpersonatedByHeader = "X-Impersonated-By"
+
+// ForbidImpersonation refuses requests made with an impersonation token. It
+// must run after AuthMiddleware, on routes that change how a user signs in
+// or that an admin should only reach as themselves.
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("actor_id") != "" {
+			httputil.HandleError(c, errors.ErrImpersonationForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// recordImpersonation marks the response and writes the request to the
+// audit log before it is carried out. Like the admin actions, it fails
+// closed: a request that cannot be audited is refused.
+func recordImpersonation(c *gin.Context, audit services.AuditService, actorID, userID uuid.UUID) bool {
+	c.Header(ImpersonatedByHeader, actorID.String())
+
+	err := audit.Record(&models.AuditEvent{
+		ActorID:   &actorID,
+		Action:    models.AuditImpersonatedRequest,
+		TargetID:  &userID,
+		Details:   models.AuditDetails{"method": c.Request.Method, "path": c.Request.URL.Path},
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		appErr := errors.ErrAuditFailed
+		appErr.Details = err
+		httputil.HandleError(c, appErr)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission refuses requests unless the user's role grants every
+// one of permissions. It must run after AuthMiddleware. API keys carry no
+// permissions, so requests made with one are refused too.
+func RequirePermission(roles services.RoleService, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIKey := c.Get("api_key_id"); isAPIKey {
+			httputil.HandleError(c, errors.ErrPermissionDenied)
+			c.Abort()
+			return
+		}
+
+		allowed, err := roles.HasPermissions(c.GetString("user_role"), permissions...)
+		if err != nil {
+			appErr := errors.ErrPermissionCheckFailed
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+			c.Abort()
+			return
+		}
+		if !allowed {
+			httputil.HandleError(c, errors.ErrPermissionDenied)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
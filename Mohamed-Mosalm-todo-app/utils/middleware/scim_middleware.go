@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// SCIMAuth admits requests bearing token, the one provisioning clients are
+// configured with. Errors are answered the SCIM way.
+func SCIMAuth(token string) gin.HandlerFunc {
+	// Comparing hashes keeps the comparison constant-time whatever the
+	// length of the presented token.
+	want := sha256.Sum256([]byte(token))
+	return func(c *gin.Context) {
+		presented, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		got := sha256.Sum256([]byte(presented))
+		if !ok || subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="scim"`)
+			httputil.HandleSCIMError(c, http.StatusUnauthorized, "", "A valid SCIM bearer token is required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
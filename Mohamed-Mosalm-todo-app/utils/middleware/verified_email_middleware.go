@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/MohamedMosalm/Todo-App/services"
+	"github.com/MohamedMosalm/Todo-App/utils/errors"
+	"github.com/MohamedMosalm/Todo-App/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequireVerifiedEmail refuses requests from users who have not confirmed
+// their email address yet. It must run after AuthMiddleware.
+func RequireVerifiedEmail(userService services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			httputil.HandleError(c, errors.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		user, err := userService.FindUserByID(userID)
+		if err != nil {
+			appErr := errors.ErrUserNotFound
+			appErr.Details = err
+			httputil.HandleError(c, appErr)
+			c.Abort()
+			return
+		}
+
+		if user.EmailVerifiedAt == nil {
+			httputil.HandleError(c, errors.ErrEmailNotVerified)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
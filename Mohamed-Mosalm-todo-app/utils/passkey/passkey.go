@@ -0,0 +1,16 @@
+package passkey
+
+import (
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// NewRelyingParty returns the WebAuthn relying party passkeys are registered
+// with and checked against.
+func NewRelyingParty(cfg config.WebAuthnConfig) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.Origins,
+	})
+}
@@ -0,0 +1,223 @@
+// Package passkeytest provides a software WebAuthn authenticator for tests,
+// in the spirit of net/http/httptest.
+package passkeytest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+var (
+	// ErrCredentialExcluded is returned by Create when the authenticator
+	// already holds one of the credentials the relying party excluded.
+	ErrCredentialExcluded = errors.New("passkeytest: authenticator already holds an excluded credential")
+	// ErrNoCredential is returned by Get when the authenticator holds no
+	// credential the relying party allows.
+	ErrNoCredential = errors.New("passkeytest: no matching credential")
+)
+
+type credential struct {
+	id         []byte
+	rpID       string
+	userHandle []byte
+	key        *ecdsa.PrivateKey
+	signCount  uint32
+}
+
+// Authenticator is a platform authenticator that keeps its passkeys in
+// memory. Its passkeys are discoverable and ES256 keys, it attests with the
+// "none" format and it always reports the user present and verified.
+// Answers are the JSON a browser would send the relying party.
+type Authenticator struct {
+	// Origin is the web origin the browser reports the ceremony ran on.
+	Origin string
+
+	mu          sync.Mutex
+	credentials []*credential
+}
+
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin}
+}
+
+// Create answers navigator.credentials.create() with a new passkey.
+func (a *Authenticator) Create(options *protocol.CredentialCreation) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	creation := options.Response
+	for _, excluded := range creation.CredentialExcludeList {
+		if a.find(creation.RelyingParty.ID, excluded.CredentialID) != nil {
+			return nil, ErrCredentialExcluded
+		}
+	}
+	userHandle, err := userHandle(creation.User.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	cred := &credential{id: make([]byte, 32), rpID: creation.RelyingParty.ID, userHandle: userHandle, key: key}
+	if _, err := rand.Read(cred.id); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := key.PublicKey.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	// The uncompressed point is 0x04 followed by X and Y.
+	point := publicKey.Bytes()
+	coseKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: point[1:33],
+		YCoord: point[33:],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authData := cred.authenticatorData(protocol.FlagAttestedCredentialData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID, all zero for "none"
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(cred.id)))
+	authData = append(authData, cred.id...)
+	authData = append(authData, coseKey...)
+
+	attestationObject, err := webauthncbor.Marshal(struct {
+		Format       string                 `cbor:"fmt"`
+		AttStatement map[string]interface{} `cbor:"attStmt"`
+		AuthData     []byte                 `cbor:"authData"`
+	}{"none", map[string]interface{}{}, authData})
+	if err != nil {
+		return nil, err
+	}
+	clientData, err := a.clientData(protocol.CreateCeremony, creation.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	a.credentials = append(a.credentials, cred)
+	return json.Marshal(protocol.CredentialCreationResponse{
+		PublicKeyCredential: cred.publicKeyCredential(),
+		AttestationResponse: protocol.AuthenticatorAttestationResponse{
+			AuthenticatorResponse: protocol.AuthenticatorResponse{ClientDataJSON: clientData},
+			AttestationObject:     attestationObject,
+			Transports:            []string{string(protocol.Internal)},
+		},
+	})
+}
+
+// Get answers navigator.credentials.get() with the first passkey the
+// relying party allows, or with any of its passkeys if it allows any.
+func (a *Authenticator) Get(options *protocol.CredentialAssertion) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	request := options.Response
+	var cred *credential
+	if len(request.AllowedCredentials) == 0 {
+		cred = a.find(request.RelyingPartyID, nil)
+	}
+	for _, allowed := range request.AllowedCredentials {
+		if cred = a.find(request.RelyingPartyID, allowed.CredentialID); cred != nil {
+			break
+		}
+	}
+	if cred == nil {
+		return nil, ErrNoCredential
+	}
+
+	cred.signCount++
+	authData := cred.authenticatorData(0)
+	clientData, err := a.clientData(protocol.AssertCeremony, request.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, cred.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(protocol.CredentialAssertionResponse{
+		PublicKeyCredential: cred.publicKeyCredential(),
+		AssertionResponse: protocol.AuthenticatorAssertionResponse{
+			AuthenticatorResponse: protocol.AuthenticatorResponse{ClientDataJSON: clientData},
+			AuthenticatorData:     authData,
+			Signature:             signature,
+			UserHandle:            cred.userHandle,
+		},
+	})
+}
+
+// find returns the passkey for rpID with id, or the first one if id is nil.
+func (a *Authenticator) find(rpID string, id []byte) *credential {
+	for _, cred := range a.credentials {
+		if cred.rpID == rpID && (id == nil || bytes.Equal(cred.id, id)) {
+			return cred
+		}
+	}
+	return nil
+}
+
+func (a *Authenticator) clientData(ceremony protocol.CeremonyType, challenge protocol.URLEncodedBase64) ([]byte, error) {
+	return json.Marshal(protocol.CollectedClientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    a.Origin,
+	})
+}
+
+// authenticatorData starts with the RP ID hash, the flags and the counter.
+func (c *credential) authenticatorData(flags protocol.AuthenticatorFlags) []byte {
+	rpIDHash := sha256.Sum256([]byte(c.rpID))
+	flags |= protocol.FlagUserPresent | protocol.FlagUserVerified
+	data := append(rpIDHash[:], byte(flags))
+	return binary.BigEndian.AppendUint32(data, c.signCount)
+}
+
+func (c *credential) publicKeyCredential() protocol.PublicKeyCredential {
+	return protocol.PublicKeyCredential{
+		Credential: protocol.Credential{
+			ID:   base64.RawURLEncoding.EncodeToString(c.id),
+			Type: string(protocol.PublicKeyCredentialType),
+		},
+		RawID:                   c.id,
+		AuthenticatorAttachment: string(protocol.Platform),
+	}
+}
+
+// userHandle reads the user ID of creation options, which are bytes when
+// built by the relying party and base64url text once sent as JSON.
+func userHandle(id interface{}) ([]byte, error) {
+	switch id := id.(type) {
+	case protocol.URLEncodedBase64:
+		return id, nil
+	case []byte:
+		return id, nil
+	case string:
+		return base64.RawURLEncoding.DecodeString(id)
+	}
+	return nil, fmt.Errorf("passkeytest: unsupported user ID %T", id)
+}
@@ -8,6 +8,17 @@ type Response struct {
 }
 
 type ErrorInfo struct {
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Code    string              `json:"code,omitempty"`
+	Message string              `json:"message"`
+	Details string              `json:"details,omitempty"`
+	Fields  map[string][]string `json:"fields,omitempty"`
+}
+
+// SCIMError is the error response of the SCIM protocol (RFC 7644,
+// section 3.12). Status repeats the HTTP status as a string.
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
 }
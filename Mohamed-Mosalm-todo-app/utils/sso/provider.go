@@ -0,0 +1,151 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// providerTimeout bounds each request to a provider.
+const providerTimeout = 10 * time.Second
+
+var (
+	// ErrInvalidIDToken means the provider's answer could not be trusted:
+	// the ID token is missing, forged, expired, meant for another client or
+	// does not carry the nonce of this login.
+	ErrInvalidIDToken = errors.New("ID token is invalid")
+	// ErrCodeExchangeFailed means the provider refused the authorization
+	// code, for instance because it was used already.
+	ErrCodeExchangeFailed = errors.New("authorization code exchange failed")
+)
+
+// Claims are what the application uses from a verified ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// Provider runs the authorization code flow with PKCE against one OpenID
+// Connect provider. The provider's discovery document is fetched on first
+// use, so an unreachable provider does not keep the server from starting.
+type Provider struct {
+	Name        string
+	DisplayName string
+
+	cfg         config.OIDCProviderConfig
+	redirectURL string
+	client      *http.Client
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewProvider returns the provider described by cfg. Users are sent back to
+// redirectURL, which must be registered with the provider.
+func NewProvider(cfg config.OIDCProviderConfig, redirectURL string) *Provider {
+	return &Provider{
+		Name:        cfg.Name,
+		DisplayName: cfg.DisplayName,
+		cfg:         cfg,
+		redirectURL: redirectURL,
+		client:      &http.Client{Timeout: providerTimeout},
+	}
+}
+
+// withClient makes the OIDC and OAuth2 libraries use p's HTTP client.
+func (p *Provider) withClient(ctx context.Context) context.Context {
+	return oidc.ClientContext(ctx, p.client)
+}
+
+// discover fetches the discovery document once it is first needed. Failures
+// are not cached, so the next login tries again.
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return p.oauth, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(p.withClient(ctx), p.cfg.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("discovering OIDC provider %q: %w", p.Name, err)
+	}
+
+	p.oauth = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       p.cfg.Scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+	return p.oauth, p.verifier, nil
+}
+
+// AuthCodeURL returns where to send the user to sign in. state and nonce
+// come back with the answer; codeVerifier is the PKCE secret that has to be
+// presented with the code.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	oauth, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+// Exchange trades the authorization code for tokens and returns the claims
+// of the verified ID token.
+func (p *Provider) Exchange(ctx context.Context, code, nonce, codeVerifier string) (*Claims, error) {
+	ctx = p.withClient(ctx)
+	oauth, idTokenVerifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCodeExchangeFailed, err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+	idToken, err := idTokenVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	var claims struct {
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+		GivenName     string      `json:"given_name"`
+		FamilyName    string      `json:"family_name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	return &Claims{
+		Subject: idToken.Subject,
+		Email:   claims.Email,
+		// Some providers send the flag as a string.
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package sso
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/MohamedMosalm/Todo-App/config"
+	"github.com/MohamedMosalm/Todo-App/utils/sso/ssotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *ssotest.Server) {
+	t.Helper()
+	server := ssotest.NewServer("todo-app", "s3cret")
+	t.Cleanup(server.Close)
+	server.SignIn(ssotest.User{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, GivenName: "Jane", FamilyName: "Doe"})
+
+	provider := NewProvider(config.OIDCProviderConfig{
+		Name:         "corp",
+		IssuerURL:    server.URL,
+		ClientID:     "todo-app",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"openid", "email", "profile"},
+	}, "https://todo.example.com/api/auth/oidc/corp/callback")
+	return provider, server
+}
+
+func TestProviderCodeFlow(t *testing.T) {
+	provider, server := newTestProvider(t)
+	ctx := context.Background()
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	parsed, _ := url.Parse(authURL)
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.NotContains(t, authURL, verifier, "only the challenge leaves the server")
+
+	code, state, err := server.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	claims, err := provider.Exchange(ctx, code, "nonce-1", verifier)
+	require.NoError(t, err)
+	assert.Equal(t, &Claims{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, GivenName: "Jane", FamilyName: "Doe"}, claims)
+
+	_, err = provider.Exchange(ctx, code, "nonce-1", verifier)
+	assert.ErrorIs(t, err, ErrCodeExchangeFailed, "codes are single-use")
+}
+
+func TestProviderRejectsBadAnswers(t *testing.T) {
+	provider, server := newTestProvider(t)
+	ctx := context.Background()
+	verifier := oauth2.GenerateVerifier()
+
+	login := func() string {
+		authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", verifier)
+		require.NoError(t, err)
+		code, _, err := server.Authorize(authURL)
+		require.NoError(t, err)
+		return code
+	}
+
+	_, err := provider.Exchange(ctx, login(), "nonce", oauth2.GenerateVerifier())
+	assert.ErrorIs(t, err, ErrCodeExchangeFailed, "the PKCE verifier must match the challenge")
+
+	_, err = provider.Exchange(ctx, login(), "another-nonce", verifier)
+	assert.ErrorIs(t, err, ErrInvalidIDToken, "the nonce ties the ID token to this login")
+}
+
+func TestProviderDiscoveryFailureIsRetried(t *testing.T) {
+	provider, server := newTestProvider(t)
+	issuer := server.URL
+	provider.cfg.IssuerURL = issuer + "/missing"
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", oauth2.GenerateVerifier())
+	assert.Error(t, err)
+
+	provider.cfg.IssuerURL = issuer
+	_, err = provider.AuthCodeURL(context.Background(), "state", "nonce", oauth2.GenerateVerifier())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalBlobStore(root)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key := "user/blob"
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("hello"), -1, "text/plain"))
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("hello again"), -1, "text/plain"), "Put replaces a blob")
+
+	r, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello again", string(data))
+
+	require.NoError(t, store.Delete(ctx, key))
+	_, err = store.Get(ctx, key)
+	assert.Equal(t, ErrBlobNotFound, err)
+	assert.NoError(t, store.Delete(ctx, key), "deleting a missing blob is not an error")
+}
+
+func TestLocalBlobStoreRejectsPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "blobs")
+	store, err := NewLocalBlobStore(root)
+	require.NoError(t, err)
+	outside := filepath.Join(dir, "outside")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0o600))
+
+	ctx := context.Background()
+	for _, key := range []string{"../outside", "user/../../outside", "/etc/passwd", "..", "", "."} {
+		assert.Error(t, store.Put(ctx, key, strings.NewReader("x"), -1, "text/plain"), key)
+		_, err := store.Get(ctx, key)
+		assert.Error(t, err, key)
+		assert.NotEqual(t, ErrBlobNotFound, err, key)
+		assert.Error(t, store.Delete(ctx, key), key)
+	}
+	data, err := os.ReadFile(outside)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(data))
+
+	// Keys that only look like they climb stay inside the root.
+	require.NoError(t, store.Put(ctx, "user/../blob", strings.NewReader("x"), -1, "text/plain"))
+	assert.FileExists(t, filepath.Join(root, "blob"))
+}
+
+// failingReader returns some content and then an error, like an upload that
+// is cut off halfway.
+type failingReader struct {
+	sent bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errors.New("connection reset")
+	}
+	r.sent = true
+	return copy(p, "partial"), nil
+}
+
+func TestLocalBlobStoreCleansUpFailedPut(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalBlobStore(root)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "user/blob", strings.NewReader("complete"), -1, "text/plain"))
+	assert.Error(t, store.Put(ctx, "user/blob", &failingReader{}, -1, "text/plain"))
+
+	entries, err := os.ReadDir(filepath.Join(root, "user"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no temporary file is left behind")
+	assert.Equal(t, "blob", entries[0].Name())
+
+	r, err := store.Get(ctx, "user/blob")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "complete", string(data), "the earlier blob is untouched")
+}
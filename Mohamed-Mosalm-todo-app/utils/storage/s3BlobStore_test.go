@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestS3BlobStore runs against a local MinIO, e.g.
+//
+//	docker compose up -d minio
+//	MINIO_TEST_ENDPOINT=localhost:9000 go test ./utils/storage/
+//
+// The bucket named by MINIO_TEST_BUCKET (default "todo-test") must exist.
+func TestS3BlobStore(t *testing.T) {
+	endpoint := os.Getenv("MINIO_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("MINIO_TEST_ENDPOINT not set")
+	}
+	bucket := os.Getenv("MINIO_TEST_BUCKET")
+	if bucket == "" {
+		bucket = "todo-test"
+	}
+
+	store, err := NewS3BlobStore(endpoint, getenv("MINIO_ROOT_USER", "minioadmin"), getenv("MINIO_ROOT_PASSWORD", "minioadmin"), bucket, false)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key := "test/" + t.Name()
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("hello"), -1, "text/plain"))
+
+	r, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete(ctx, key))
+	_, err = store.Get(ctx, key)
+	assert.Equal(t, ErrBlobNotFound, err)
+}
+
+func getenv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}